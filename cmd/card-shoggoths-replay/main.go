@@ -0,0 +1,180 @@
+// Command card-shoggoths-replay re-executes recorded hands from their seed
+// and prints the table after every step. It exits non-zero if the engine
+// does not reproduce the record, which makes it useful both for reviewing a
+// disputed hand and as a regression check after engine changes.
+//
+//	card-shoggoths-replay -game <id> [-hand N] [-db ./data/game.db]
+//	card-shoggoths-replay -game <id> -hand N -export hand.json
+//	card-shoggoths-replay -file hand.json
+package main
+
+import (
+	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/store"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	dbPath := flag.String("db", "./data/game.db", "game database")
+	gameID := flag.String("game", "", "game (session) ID to replay")
+	hand := flag.Int("hand", 0, "hand number to replay; 0 replays every archived hand")
+	file := flag.String("file", "", "replay an exported hand instead of reading the database")
+	export := flag.String("export", "", "write the hand, with its seed, to this file instead of replaying it")
+	quiet := flag.Bool("q", false, "only report whether each hand matches")
+	flag.Parse()
+
+	var hands []*game.HandRecord
+	var err error
+	if *file != "" {
+		hands, err = readFile(*file)
+	} else {
+		hands, err = readStore(*dbPath, *gameID, *hand)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(hands) == 0 {
+		log.Fatal("no hands to replay")
+	}
+
+	if *export != "" {
+		if len(hands) != 1 {
+			log.Fatal("-export needs a single -hand")
+		}
+		data, err := json.MarshalIndent(hands[0], "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*export, data, 0644); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	failed := 0
+	for _, rec := range hands {
+		fmt.Printf("== game %s, hand %d (seed %d)\n", rec.GameID, rec.Number, rec.Seed)
+		var step func(game.Event, *game.GameState)
+		if !*quiet {
+			step = printStep
+		}
+		if _, err := game.Replay(rec, rec.Seed, step); err != nil {
+			fmt.Printf("MISMATCH: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Println("OK")
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// readStore loads the archived hands of a game, oldest first, with the game
+// seed filled in.
+func readStore(path, id string, number int) ([]*game.HandRecord, error) {
+	if id == "" {
+		return nil, fmt.Errorf("-game or -file is required")
+	}
+	st, err := store.NewSQLiteStore(path)
+	if err != nil {
+		return nil, err
+	}
+	g, err := st.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, fmt.Errorf("no game %s in %s", id, path)
+	}
+	if g.Seed == 0 {
+		return nil, fmt.Errorf("game %s predates seeded deals and cannot be replayed", id)
+	}
+
+	all, err := st.History(id, g.HandNumber)
+	if err != nil {
+		return nil, err
+	}
+	var hands []*game.HandRecord
+	for i := len(all) - 1; i >= 0; i-- {
+		if number == 0 || all[i].Number == number {
+			all[i].Seed = g.Seed
+			hands = append(hands, all[i])
+		}
+	}
+	if number != 0 && len(hands) == 0 {
+		return nil, fmt.Errorf("game %s has no archived hand %d", id, number)
+	}
+	return hands, nil
+}
+
+// readFile loads a hand written by -export.
+func readFile(path string) ([]*game.HandRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec game.HandRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	if rec.Seed == 0 {
+		return nil, fmt.Errorf("%s has no seed to replay from", path)
+	}
+	return []*game.HandRecord{&rec}, nil
+}
+
+func printStep(e game.Event, g *game.GameState) {
+	who := "table"
+	if e.Seat >= 0 && e.Seat < len(g.Players) {
+		who = g.Players[e.Seat].Name
+	}
+	line := fmt.Sprintf("%3d %-10s %-22s", e.Seq, e.Type, who)
+	if e.Amount != 0 {
+		line += fmt.Sprintf(" %d", e.Amount)
+	}
+	if e.Detail != "" {
+		line += " " + e.Detail
+	}
+	fmt.Println(line)
+
+	// Show the table after each decision; everything else is bookkeeping
+	switch e.Type {
+	case game.EventCheck, game.EventCall, game.EventBet, game.EventRaise, game.EventAllIn, game.EventFold, game.EventDiscard:
+	default:
+		return
+	}
+	fmt.Printf("    phase=%s pot=%d bet=%d turn=%d\n", g.GamePhase, g.Pot, g.CurrentBet, g.TurnIndex)
+	for i, p := range g.Players {
+		rs := g.RoundStates[i]
+		marks := ""
+		if rs.Folded {
+			marks += " folded"
+		}
+		if rs.AllIn {
+			marks += " all-in"
+		}
+		if rs.SittingOut {
+			marks += " sitting-out"
+		}
+		fmt.Printf("    [%d] %-22s sanity=%-4d bet=%-3d %s%s\n", i, p.Name, p.Sanity, rs.Bet, cards(rs.Hand), marks)
+	}
+}
+
+func cards(h game.Hand) string {
+	suits := map[game.Suit]string{game.Spades: "s", game.Hearts: "h", game.Diamonds: "d", game.Clubs: "c"}
+	parts := make([]string, len(h))
+	for i, c := range h {
+		rank := strings.ToUpper(string(c.Rank))
+		if len(rank) > 2 {
+			rank = rank[:1]
+		}
+		parts[i] = rank + suits[c.Suit]
+	}
+	return strings.Join(parts, " ")
+}
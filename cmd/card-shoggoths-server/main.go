@@ -1,11 +1,15 @@
 package main
 
 import (
+	"card-shoggoths/internal/profile"
 	"card-shoggoths/internal/server"
 	"card-shoggoths/internal/store"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	chi "github.com/go-chi/chi/v5"
 )
@@ -19,11 +23,56 @@ func main() {
 	}
 	server.Init(st)
 
+	// Sessions: SESSION_TTL and SESSION_ROTATE are Go durations
+	cfg := server.DefaultSessionConfig
+	cfg.TTL = durationEnv("SESSION_TTL", cfg.TTL)
+	cfg.RotateEvery = durationEnv("SESSION_ROTATE", cfg.RotateEvery)
+	if err := server.InitSessions(cfg); err != nil {
+		log.Fatalf("Failed to init sessions: %v", err)
+	}
+
+	// TURN_TIMEOUT is how long a player at a lobby table has to act
+	server.TurnTimeout = durationEnv("TURN_TIMEOUT", server.TurnTimeout)
+	server.ResumeTurns()
+
+	// Avatars are the monster icons in static/avatars
+	icons, err := filepath.Glob("./static/avatars/*.png")
+	if err != nil {
+		log.Printf("[ERROR] Failed to list avatars: %v", err)
+	}
+	var avatars []string
+	for _, icon := range icons {
+		avatars = append(avatars, strings.TrimSuffix(filepath.Base(icon), ".png"))
+	}
+	profile.SetAvatars(avatars)
+	if len(avatars) == 0 {
+		log.Println("[WARN] No avatars in ./static/avatars, so profiles can pick none")
+	} else {
+		log.Printf("Found %d avatars", len(avatars))
+	}
+
 	r := chi.NewRouter()
 
 	log.Println("Registering default static file handler")
 	r.Handle("/*", http.FileServer(http.Dir("./static")))
 
+	// Everything but the static files needs a session
+	r.Group(func(r chi.Router) {
+		r.Use(server.Sessions)
+		routes(r)
+	})
+
+	// TLS_CERT and TLS_KEY serve over TLS, which also secures the cookie
+	if cert, key := os.Getenv("TLS_CERT"), os.Getenv("TLS_KEY"); cert != "" && key != "" {
+		log.Println("Serving TLS on :8443...")
+		log.Fatal(http.ListenAndServeTLS(":8443", cert, key, r))
+	}
+	log.Println("Serving on :8080...")
+	log.Fatal(http.ListenAndServe(":8080", r))
+}
+
+// routes registers the API and the socket.
+func routes(r chi.Router) {
 	log.Println("Registering handlers...")
 	r.HandleFunc("/api/state", server.StateHandler)
 	r.HandleFunc("/api/deal", server.DealHandler)
@@ -31,12 +80,50 @@ func main() {
 	r.HandleFunc("/api/discard", server.DiscardHandler)
 	r.HandleFunc("/api/showdown", server.ShowdownHandler)
 	r.HandleFunc("/api/rebuy", server.RebuyHandler)
+	r.HandleFunc("/api/history", server.HistoryHandler)
+	r.HandleFunc("/api/personalities", server.PersonalitiesHandler)
+	r.HandleFunc("/api/personality", server.PersonalityHandler)
 	r.HandleFunc("/api/esp/start", server.ESPStartHandler)
 	r.HandleFunc("/api/esp/guess", server.ESPGuessHandler)
 	r.HandleFunc("/api/esp/exit", server.ESPExitHandler)
+
+	// Lobby: tables shared by many sessions
+	r.Get("/api/tables", server.TablesHandler)
+	r.Post("/api/tables", server.CreateTableHandler)
+	r.Get("/api/tables/{id}", server.TableHandler)
+	r.Post("/api/tables/{id}/join", server.JoinTableHandler)
+	r.Post("/api/tables/{id}/spectate", server.SpectateTableHandler)
+	r.Post("/api/tables/{id}/leave", server.LeaveTableHandler)
+	r.Post("/api/tables/{id}/deal", server.TableDealHandler)
+	r.Post("/api/tables/{id}/bet", server.TableActionHandler)
+	r.Post("/api/tables/{id}/discard", server.TableDiscardHandler)
+
+	// Accounts: a session logged in plays as its account
+	r.Get("/api/account", server.AccountHandler)
+	r.Post("/api/account/register", server.RegisterHandler)
+	r.Post("/api/account/login", server.LoginHandler)
+	r.Post("/api/account/logout", server.LogoutHandler)
+	r.Post("/api/account/revoke", server.RevokeSessionsHandler)
+	r.Get("/api/profile/{id}", server.ProfileHandler)
+	r.Put("/api/profile", server.UpdateProfileHandler)
+	r.Get("/api/avatars", server.AvatarsHandler)
+
+	// Leaderboards: net, win_rate, streak or esp, over a day, week or all time
+	r.Get("/api/leaderboards/{board}", server.LeaderboardHandler)
+
 	r.HandleFunc("/ws/chat", server.ChatHandler)
 	r.HandleFunc("/debug/clear-session", server.ClearSessionHandler)
+}
 
-	log.Println("Serving on :8080...")
-	log.Fatal(http.ListenAndServe(":8080", r))
+// durationEnv reads a duration from the environment, or def if it is unset.
+func durationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s %q: want a positive duration like 720h", name, v)
+	}
+	return d
 }
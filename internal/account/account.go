@@ -0,0 +1,128 @@
+// Package account keeps the players who have signed up, so their games and
+// what the AI has learned about them outlive any one session's cookie.
+package account
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Username and password limits.
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
+	MinPasswordLength = 8
+	MaxPasswordLength = 256
+)
+
+// Iterations is how many rounds of PBKDF2-SHA256 new passwords are hashed
+// with. Each account keeps its own count, so it can be raised later.
+const Iterations = 600_000
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// ErrTaken is returned when a username is already in use.
+var ErrTaken = errors.New("that username is taken")
+
+// Account is a player who has signed up. Its ID is public; the player ID is
+// what its games and stats are stored under, and like a session ID it must
+// never be shown to anyone else.
+type Account struct {
+	ID         string    `json:"id"`
+	Username   string    `json:"username"`
+	PlayerID   string    `json:"-"`
+	Salt       []byte    `json:"-"`
+	Hash       []byte    `json:"-"` // PBKDF2-SHA256 of the password
+	Iterations int       `json:"-"`
+	Created    time.Time `json:"created"`
+}
+
+// New signs up username with password. The account plays under playerID,
+// which carries over an anonymous session's game, or a new ID if empty.
+func New(username, password, playerID string) (*Account, error) {
+	username = strings.TrimSpace(username)
+	if err := checkUsername(username); err != nil {
+		return nil, err
+	}
+	if err := checkPassword(password); err != nil {
+		return nil, err
+	}
+	if playerID == "" {
+		playerID = uuid.NewString()
+	}
+
+	a := &Account{
+		ID:         uuid.NewString(),
+		Username:   username,
+		PlayerID:   playerID,
+		Salt:       make([]byte, saltLength),
+		Iterations: Iterations,
+		Created:    time.Now(),
+	}
+	rand.Read(a.Salt)
+	hash, err := a.hash(password)
+	if err != nil {
+		return nil, err
+	}
+	a.Hash = hash
+	return a, nil
+}
+
+// Check reports whether password is the account's, in constant time.
+func (a *Account) Check(password string) bool {
+	hash, err := a.hash(password)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(hash, a.Hash) == 1
+}
+
+// nobody stands in for a username that has no account: its hash is worked
+// out like any other, and matches no password.
+var nobody = &Account{Salt: make([]byte, saltLength), Iterations: Iterations}
+
+// Verify reports whether password is the account's. Without an account it
+// hashes the password all the same before refusing it, so how long a login
+// takes does not tell whether the username exists.
+func Verify(a *Account, password string) bool {
+	if a == nil {
+		nobody.Check(password)
+		return false
+	}
+	return a.Check(password)
+}
+
+func (a *Account) hash(password string) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, password, a.Salt, a.Iterations, keyLength)
+}
+
+func checkUsername(name string) error {
+	if n := len([]rune(name)); n < MinUsernameLength || n > MaxUsernameLength {
+		return fmt.Errorf("a username is %d to %d characters", MinUsernameLength, MaxUsernameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return fmt.Errorf("a username is letters, digits, _ and -")
+		}
+	}
+	return nil
+}
+
+func checkPassword(password string) error {
+	if n := len(password); n < MinPasswordLength || n > MaxPasswordLength {
+		return fmt.Errorf("a password is %d to %d characters", MinPasswordLength, MaxPasswordLength)
+	}
+	return nil
+}
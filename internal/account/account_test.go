@@ -0,0 +1,88 @@
+package account
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestNewHashesThePassword(t *testing.T) {
+	a, err := New(" Armitage ", "miskatonic", "")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if a.Username != "Armitage" || a.PlayerID == "" || a.PlayerID == a.ID {
+		t.Errorf("unexpected account %+v", a)
+	}
+	if bytes.Contains(a.Hash, []byte("miskatonic")) || len(a.Salt) != saltLength {
+		t.Errorf("expected a salted hash, got %x", a.Hash)
+	}
+	if !a.Check("miskatonic") || a.Check("Miskatonic") || a.Check("") {
+		t.Errorf("expected only the password to check")
+	}
+
+	b, _ := New("Whateley", "miskatonic", "anon")
+	if b.PlayerID != "anon" || bytes.Equal(a.Hash, b.Hash) {
+		t.Errorf("expected the anonymous game kept and a salt of its own, got %+v", b)
+	}
+}
+
+func TestNewChecksTheCredentials(t *testing.T) {
+	for _, c := range []struct{ username, password string }{
+		{"ab", "miskatonic"},
+		{"Armitage!", "miskatonic"},
+		{"Armitage", "short"},
+	} {
+		if _, err := New(c.username, c.password, ""); err == nil {
+			t.Errorf("expected %q with %q to be refused", c.username, c.password)
+		}
+	}
+}
+
+func TestVerifyRefusesNobody(t *testing.T) {
+	a, _ := New("Armitage", "miskatonic", "")
+	if !Verify(a, "miskatonic") || Verify(a, "necronomicon") {
+		t.Errorf("expected only the password to verify")
+	}
+	if Verify(nil, "miskatonic") || Verify(nil, "") {
+		t.Errorf("expected no password to verify without an account")
+	}
+}
+
+func TestLimiterRefusesUntilTheWindowPasses(t *testing.T) {
+	l := NewLimiter(3, time.Minute)
+	now := time.Now()
+	for i := range 3 {
+		if !l.Allow("1.2.3.4", now.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("expected attempt %d allowed", i)
+		}
+	}
+	if l.Allow("1.2.3.4", now.Add(10*time.Second)) {
+		t.Errorf("expected the fourth attempt within the window refused")
+	}
+	if !l.Allow("5.6.7.8", now.Add(10*time.Second)) {
+		t.Errorf("expected other keys unaffected")
+	}
+	if !l.Allow("1.2.3.4", now.Add(time.Minute)) {
+		t.Errorf("expected an attempt once the first left the window")
+	}
+
+	l.Reset("1.2.3.4")
+	if !l.Allow("1.2.3.4", now.Add(time.Minute)) {
+		t.Errorf("expected a reset key allowed")
+	}
+}
+
+func TestLimiterPermitsWithoutRecording(t *testing.T) {
+	l := NewLimiter(1, time.Minute)
+	now := time.Now()
+	for range 3 {
+		if !l.Permits("Armitage", now) {
+			t.Fatalf("expected checking alone not to use up attempts")
+		}
+	}
+	l.Record("Armitage", now)
+	if l.Permits("Armitage", now) || l.Allow("Armitage", now) {
+		t.Errorf("expected the recorded attempt to reach the limit")
+	}
+}
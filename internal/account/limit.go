@@ -0,0 +1,86 @@
+package account
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter refuses attempts once a key, like a client address or a username,
+// has made Max of them within Window, so guessing passwords online costs
+// time. It is safe for concurrent use.
+type Limiter struct {
+	Max    int
+	Window time.Duration
+
+	mu       sync.Mutex
+	attempts map[string][]time.Time // Per key, oldest first, all within the window
+	swept    time.Time
+}
+
+// NewLimiter allows max attempts per key within window.
+func NewLimiter(max int, window time.Duration) *Limiter {
+	return &Limiter{Max: max, Window: window, attempts: make(map[string][]time.Time)}
+}
+
+// Allow records an attempt by key at now and reports whether it may go
+// ahead. A refused attempt is not recorded, so a key is let through again
+// once its oldest attempts leave the window.
+func (l *Limiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.permits(key, now) {
+		return false
+	}
+	l.attempts[key] = append(l.attempts[key], now)
+	return true
+}
+
+// Permits reports whether an attempt by key at now may go ahead, without
+// recording it, for an attempt that must pass other limiters too.
+func (l *Limiter) Permits(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.permits(key, now)
+}
+
+// Record records an attempt by key at now, whether or not it is permitted.
+func (l *Limiter) Record(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts[key] = append(l.attempts[key], now)
+}
+
+// permits is Permits with l.mu held.
+func (l *Limiter) permits(key string, now time.Time) bool {
+	// Keys that have gone quiet are dropped once a window
+	if now.Sub(l.swept) > l.Window {
+		for k := range l.attempts {
+			l.trim(k, now)
+		}
+		l.swept = now
+	}
+	return l.trim(key, now) < l.Max
+}
+
+// Reset forgets the attempts of key, as once it has logged in.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+}
+
+// trim drops the attempts of key that have left the window and returns how
+// many are left.
+func (l *Limiter) trim(key string, now time.Time) int {
+	times := l.attempts[key]
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= l.Window {
+		i++
+	}
+	if i == len(times) {
+		delete(l.attempts, key)
+		return 0
+	}
+	l.attempts[key] = times[i:]
+	return len(times) - i
+}
@@ -0,0 +1,131 @@
+package game
+
+import "fmt"
+
+// LegalActions is everything a seat may do right now. SeatAction and
+// SeatDiscard check every action against it, so a client that only offers
+// what it allows never has an action refused.
+type LegalActions struct {
+	Fold       bool      `json:"fold"`
+	Check      bool      `json:"check"`
+	Call       int       `json:"call,omitempty"`        // What calling puts in, all the seat has if it is short; 0 if it cannot call
+	Bet        *BetRange `json:"bet,omitempty"`         // Sizes of an opening bet, when nothing has been bet this round
+	Raise      *BetRange `json:"raise,omitempty"`       // Sizes of a raise, on top of the call
+	AllIn      int       `json:"all_in,omitempty"`      // What going all in puts in; 0 if it cannot
+	Discard    bool      `json:"discard"`               // Whether it is the seat's turn to draw
+	MaxDiscard int       `json:"max_discard,omitempty"` // ... and the most cards it may throw
+	ESP        bool      `json:"esp"`                   // Whether ESP training can start
+}
+
+// LegalActions returns what seat may do. A seat that is not at the table
+// may do nothing.
+func (g *GameState) LegalActions(seat int) LegalActions {
+	var legal LegalActions
+	if seat < 0 || seat >= len(g.Players) {
+		return legal
+	}
+	player := g.Players[seat]
+	rs := g.RoundStates[seat]
+	legal.ESP = g.CanStartESP()
+	legal.Fold = g.handInProgress() && !rs.Folded
+
+	if g.GamePhase == PhaseDiscard && g.TurnIndex == seat && g.toDiscard(seat) {
+		legal.Discard = true
+		legal.MaxDiscard = len(rs.Hand)
+	}
+
+	if !g.isBetting() || g.TurnIndex != seat {
+		return legal
+	}
+	toCall := g.CurrentBet - rs.Bet
+	legal.Check = toCall <= 0
+	if toCall > 0 {
+		legal.Call = min(toCall, player.Sanity)
+	}
+	r, ok := g.BetRange(seat)
+	switch {
+	case ok && g.CurrentBet == 0:
+		legal.Bet = &r
+	case ok:
+		legal.Raise = &r
+	}
+	// Shoving is a call when it is no more than the call, and otherwise a
+	// raise that has to be within the limits
+	if over := player.Sanity - toCall; player.Sanity > 0 && (over <= 0 || ok && over <= r.Max) {
+		legal.AllIn = player.Sanity
+	}
+	return legal
+}
+
+// illegal explains why legal, the legal actions of seat, does not allow
+// action for amount, or returns "" if it does.
+func (g *GameState) illegal(seat int, legal LegalActions, action string, amount int) string {
+	if action == "fold" {
+		if !legal.Fold {
+			return "There is nothing to fold."
+		}
+		return ""
+	}
+	if g.TurnIndex != seat || !g.isBetting() {
+		return "It is not your turn."
+	}
+
+	switch action {
+	case "check":
+		if !legal.Check {
+			return "Cannot check when there is a bet to call."
+		}
+	case "call":
+		if legal.Call == 0 {
+			return "Nothing to call, please Check."
+		}
+	case "allin":
+		if legal.AllIn == 0 {
+			if g.Players[seat].Sanity <= 0 {
+				return "You have no sanity left to wager."
+			}
+			return "Going all in would break the betting limit; call or raise instead."
+		}
+	case "bet", "raise":
+		if amount <= 0 {
+			return "Bet amount must be positive."
+		}
+		cost := g.CurrentBet - g.RoundStates[seat].Bet + amount
+		if sanity := g.Players[seat].Sanity; sanity < cost {
+			return fmt.Sprintf("Not enough sanity. You need %d but have %d.", cost, sanity)
+		}
+		if action == "bet" && legal.Raise != nil {
+			return "There is already a bet, so raise instead."
+		}
+		if action == "raise" && legal.Bet != nil {
+			return "There is nothing to raise, so bet instead."
+		}
+		return g.checkRaise(seat, amount)
+	default:
+		return "Invalid action."
+	}
+	return ""
+}
+
+// illegalDiscard explains why seat may not throw the cards at indices, or
+// returns "" if it may.
+func (g *GameState) illegalDiscard(seat int, indices []int) string {
+	legal := g.LegalActions(seat)
+	if g.GamePhase != PhaseDiscard {
+		return "Cannot discard now."
+	}
+	if !legal.Discard {
+		return "It is not your turn."
+	}
+	if len(indices) > legal.MaxDiscard {
+		return fmt.Sprintf("You may discard at most %d cards.", legal.MaxDiscard)
+	}
+	seen := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		if i < 0 || i >= len(g.RoundStates[seat].Hand) || seen[i] {
+			return "Invalid discard."
+		}
+		seen[i] = true
+	}
+	return ""
+}
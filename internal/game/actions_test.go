@@ -0,0 +1,134 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// clientAllows is whether a client reading legal would offer action for
+// amount.
+func clientAllows(legal LegalActions, action string, amount int) bool {
+	within := func(r *BetRange) bool { return r != nil && amount >= r.Min && amount <= r.Max }
+	switch action {
+	case "fold":
+		return legal.Fold
+	case "check":
+		return legal.Check
+	case "call":
+		return legal.Call > 0
+	case "allin":
+		return legal.AllIn > 0
+	case "bet":
+		return within(legal.Bet)
+	case "raise":
+		return within(legal.Raise)
+	}
+	return false
+}
+
+// cloneGame copies g the way it is saved and loaded.
+func cloneGame(t *testing.T, g *GameState) *GameState {
+	t.Helper()
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c GameState
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	return &c
+}
+
+func TestSeatActionAgreesWithLegalActions(t *testing.T) {
+	for _, rules := range []Rules{{}, {Betting: BettingPotLimit}, {Betting: BettingFixedLimit}} {
+		for seed := uint64(1); seed <= 5; seed++ {
+			g := NewGame("p")
+			g.SetSeed(seed)
+			g.SetRules(rules)
+			g.CollectAnte(10)
+			g.OpponentTurn()
+			for i := 0; i < 20 && g.handInProgress(); i++ {
+				if g.GamePhase == PhaseDiscard {
+					g.PerformDiscard([]int{0})
+					g.OpponentTurn()
+					continue
+				}
+				legal := g.LegalActions(0)
+				var amounts []int
+				for _, r := range []*BetRange{legal.Bet, legal.Raise} {
+					if r != nil {
+						amounts = append(amounts, r.Min-1, r.Min, r.Max, r.Max+1)
+					}
+				}
+				amounts = append(amounts, 1, 10)
+				for _, action := range []string{"fold", "check", "call", "allin", "bet", "raise"} {
+					for _, amount := range amounts {
+						ok, msg := cloneGame(t, g).SeatAction(0, action, amount)
+						if want := clientAllows(legal, action, amount); ok != want {
+							t.Fatalf("%+v seed %d: %s %d allowed %v, legal actions say %v (%s)", rules, seed, action, amount, ok, want, msg)
+						}
+					}
+				}
+
+				if legal.Check {
+					g.PlayerAction("check", 0)
+				} else {
+					g.PlayerAction("call", 0)
+				}
+				g.OpponentTurn()
+			}
+		}
+	}
+}
+
+func TestLegalActionsFacingABet(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	if legal := g.LegalActions(1); !legal.Fold || legal.Check || legal.Bet != nil || legal.AllIn != 0 {
+		t.Errorf("out of turn a seat may only fold, got %+v", legal)
+	}
+	act(t, g, "bet", 10)
+
+	legal := g.LegalActions(1)
+	if legal.Check || legal.Call != 10 || legal.Bet != nil || legal.Raise == nil || legal.AllIn != g.Players[1].Sanity {
+		t.Errorf("expected call 10, raise or all in, got %+v", legal)
+	}
+	refuse(t, g, "bet", 10)
+	if legal.ESP {
+		t.Errorf("ESP should wait for the hand to finish")
+	}
+}
+
+func TestLegalDiscards(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	act(t, g, "check", 0)
+	act(t, g, "check", 0)
+
+	seat := g.TurnIndex
+	if legal := g.LegalActions(seat); !legal.Discard || legal.MaxDiscard != 5 {
+		t.Errorf("expected up to five cards to draw, got %+v", legal)
+	}
+	if legal := g.LegalActions(1 - seat); legal.Discard {
+		t.Errorf("expected the other seat to wait its turn")
+	}
+	for _, indices := range [][]int{{5}, {-1}, {0, 0}, {0, 1, 2, 3, 4, 0}} {
+		if ok, _ := g.SeatDiscard(seat, indices); ok {
+			t.Errorf("expected discarding %v to be refused", indices)
+		}
+	}
+	if ok, msg := g.SeatDiscard(seat, []int{0, 1, 2, 3, 4}); !ok {
+		t.Errorf("expected a five-card draw: %s", msg)
+	}
+}
+
+func TestViewIncludesLegalActions(t *testing.T) {
+	g := NewGame("p")
+	if v := g.View("p"); !v.LegalActions.ESP || v.LegalActions.Fold {
+		t.Errorf("expected only ESP before the deal, got %+v", v.LegalActions)
+	}
+	if v := g.View("stranger"); v.LegalActions.ESP {
+		t.Errorf("expected nothing for a viewer who is not seated, got %+v", v.LegalActions)
+	}
+}
@@ -1,19 +1,21 @@
 package game
 
 import (
-	"math/rand"
 	"os"
+	"slices"
 	"strconv"
 )
 
 // AIConfig holds configuration for the AI
 type AIConfig struct {
 	DiscardSimulations int
+	BetSimulations     int     // Opponent hands simulated per betting decision
 	Courage            float64 // Multiplier for winProb (e.g., 1.0 = normal, 1.2 = brave, 0.8 = timid)
 }
 
 var DefaultAI = AIConfig{
 	DiscardSimulations: 100, // Number of trials per permutation
+	BetSimulations:     300,
 	Courage:            1.2, // Default courage (Brave)
 }
 
@@ -44,31 +46,11 @@ func ScoreHand(hv HandValue) float64 {
 	return score
 }
 
-// getUnknownCards returns a deck containing all cards NOT in the exclusions list
-func getUnknownCards(exclusions []Card) Deck {
-	fullDeck := NewDeck() // This is already shuffled by NewDeck, effectively
-	// But NewDeck shuffles. We need to filter.
-
-	// Map for O(1) lookup
-	excluded := make(map[string]bool)
-	for _, c := range exclusions {
-		excluded[string(c.Suit)+string(c.Rank)] = true
-	}
-
-	var unknown Deck
-	for _, c := range fullDeck {
-		if !excluded[string(c.Suit)+string(c.Rank)] {
-			unknown = append(unknown, c)
-		}
-	}
-	// Reshuffle to be sure, though NewDeck is shuffled.
-	// The subset extraction preserves NewDeck's randomness order, so it's fine.
-	return unknown
-}
-
 // ChooseDiscard determines the best indices to discard from the hand.
-// It iterates through all 32 combinations of keeping/discarding cards.
-func (c AIConfig) ChooseDiscard(hand Hand) []int {
+// It iterates through all 32 combinations of keeping/discarding cards,
+// drawing simulated replacements from rng. Wild cards are always kept, and
+// hands are scored by the rules' ranking, so lowball draws to a low.
+func (c AIConfig) ChooseDiscard(hand Hand, rules Rules, rng *RNG) []int {
 	n := len(hand)
 	limit := 1 << n // 2^n combinations
 
@@ -77,12 +59,13 @@ func (c AIConfig) ChooseDiscard(hand Hand) []int {
 
 	// Pre-calculate unknown deck to avoid recreating it every sim?
 	// Actually, we must shuffle it every sim or draw randomly.
-	baseUnknown := getUnknownCards(hand)
+	baseUnknown := rules.unknownCards(hand)
 
 	for mask := 0; mask < limit; mask++ {
 		// Identify kept cards and discard indices for this mask
 		var kept Hand
 		var discardIndices []int
+		keepsWilds := true
 
 		for i := 0; i < n; i++ {
 			if (mask>>i)&1 == 0 {
@@ -91,14 +74,17 @@ func (c AIConfig) ChooseDiscard(hand Hand) []int {
 			} else {
 				// 1 bit means discard
 				discardIndices = append(discardIndices, i)
+				keepsWilds = keepsWilds && !rules.Wild(hand[i])
 			}
 		}
+		if !keepsWilds {
+			continue
+		}
 
 		cardsNeeded := 5 - len(kept)
 		if cardsNeeded == 0 {
 			// No cards discarded, evaluate current hand
-			val := EvaluateHand(kept)
-			score := ScoreHand(val)
+			score := rules.score(kept)
 			if score > maxAvgScore {
 				maxAvgScore = score
 				bestDiscards = discardIndices
@@ -124,7 +110,7 @@ func (c AIConfig) ChooseDiscard(hand Hand) []int {
 			simDeck := make(Deck, len(baseUnknown))
 			copy(simDeck, baseUnknown)
 
-			rand.Shuffle(len(simDeck), func(i, j int) {
+			rng.Shuffle(len(simDeck), func(i, j int) {
 				simDeck[i], simDeck[j] = simDeck[j], simDeck[i]
 			})
 
@@ -136,8 +122,7 @@ func (c AIConfig) ChooseDiscard(hand Hand) []int {
 			copy(finalHand, kept)
 			finalHand = append(finalHand, drawn...)
 
-			val := EvaluateHand(finalHand)
-			totalScore += ScoreHand(val)
+			totalScore += rules.score(finalHand)
 		}
 
 		avgScore := totalScore / float64(c.DiscardSimulations)
@@ -155,54 +140,18 @@ func (c AIConfig) ChooseDiscard(hand Hand) []int {
 	return bestDiscards
 }
 
-// DecideAction determines the betting action.
-// Returns action string ("fold", "check", "call", "raise") and amount (for raise).
+// DecideAction determines the betting action from a Monte Carlo estimate of
+// the AI's share of the pot (see equity). It bets in proportion to its edge
+// over a fair share, calls when the equity beats the pot odds, and
+// occasionally bluffs.
+// Returns action string ("fold", "check", "call", "bet", "raise") and amount.
 func (c AIConfig) DecideAction(hand Hand, gameState *GameState) (string, int) {
-	// 1. Evaluate current hand strength (0.0 to 1.0 roughly comparable to win rate)
-	// Monte Carlo again? Or just raw hand value?
-	// For betting, we want "Win Probability".
-	// ScoreHand gives an absolute score, but checks nothing against opponent range.
-	// A simple heuristic for 5-card draw:
-	// - High pair or better is decent.
-	// - Two pair is good.
-	// - Three of a kind+ is strong.
-
-	val := EvaluateHand(hand)
-	// score := ScoreHand(val) // Unused for now, relying on rank-based winProb
-
-	// Normalize score broadly?
-	// High Card (Top A): ~14,000,000
-	// One Pair (2s): ~202,000,000 (Rank 1 * 1mil) -> Wait, Rank HighCard=0.
-	// One Pair=1. So 1,000,000 is base.
-	// Two Pair=2. 2,000,000.
-
-	// Pot Odds = Cost to Call / (Pot + Cost to Call)
-	// AI is assumed to be Players[1]
-	// opponent := gameState.Players[1] // Identity
-	opponentState := gameState.RoundStates[1]
-	callAmount := gameState.CurrentBet - opponentState.Bet
+	rng := gameState.rng()
+	seat := gameState.TurnIndex
+	callAmount := gameState.CurrentBet - gameState.RoundStates[seat].Bet
 	pot := gameState.Pot
 
-	// Win Prob estimated
-	var winProb float64
-	switch val.Rank {
-	case HighCard:
-		winProb = 0.1
-		if val.Kickers[0] > 10 {
-			winProb = 0.2
-		} // Face card high
-	case OnePair:
-		winProb = 0.4
-		if val.Primary > 10 {
-			winProb = 0.55
-		} // High pair
-	case TwoPair:
-		winProb = 0.7
-	case ThreeOfAKind:
-		winProb = 0.85
-	case Straight, Flush, FullHouse, FourOfAKind, StraightFlush, RoyalFlush:
-		winProb = 0.95
-	}
+	winProb := gameState.equity(seat, hand, c.BetSimulations)
 
 	// Apply Courage modifier
 	winProb *= c.Courage
@@ -210,18 +159,19 @@ func (c AIConfig) DecideAction(hand Hand, gameState *GameState) (string, int) {
 		winProb = 0.99
 	} // Cap at 99%
 
-	// Adjust based on game phase if needed (bluffing?)
-	// Simple logic for now.
+	// Edge over a fair share of the pot, 0 at even odds and 1 for a lock
+	fair := 1 / float64(len(gameState.contenders()))
+	edge := (winProb - fair) / (1 - fair)
 
 	if callAmount == 0 {
 		// Can check or bet
-		if winProb > 0.6 {
-			// Bet for value
-			return "bet", 20 // Standard size
+		if edge > 0.2 {
+			// Bet for value, bigger the surer we are
+			return "bet", max(5, int(edge*float64(pot)))
 		}
-		// Bluff chance?
-		if rand.Float64() < 0.1*c.Courage {
-			return "bet", 10
+		// Bluff chance? More against players who fold to bets
+		if rng.Float64() < 0.1*c.Courage*gameState.foldEquity(seat) {
+			return "bet", max(5, pot/2)
 		}
 		return "check", 0
 	}
@@ -231,18 +181,425 @@ func (c AIConfig) DecideAction(hand Hand, gameState *GameState) (string, int) {
 
 	// If winProb > potOdds, Call.
 	// If winProb much higher, Raise.
+	// Call lighter against players caught bluffing, tighter against honest ones
+	margin := 0.05 - (gameState.bettorBluffRate() - typicalBluff)
+	margin = min(max(margin, -0.15), 0.2)
 
-	if winProb > potOdds+0.1 { // Margin of safety
-		if winProb > 0.8 && rand.Float64() < 0.7*c.Courage {
-			return "raise", 20
+	if winProb > potOdds+margin { // Margin of safety
+		if edge > 0.5 && rng.Float64() < 0.7*c.Courage {
+			return "raise", max(5, int(edge*float64(pot+callAmount)))
 		}
 		return "call", 0
 	}
 
 	// Bluff call?
-	if rand.Float64() < 0.05*c.Courage {
+	if rng.Float64() < 0.05*c.Courage {
 		return "call", 0
 	}
 
 	return "fold", 0
 }
+
+// foldEquity is how much likelier than usual a bet from seat is to make
+// everyone else fold, judging by what the AI knows of them: 1 against
+// unknown players, more against players who fold to bets.
+func (g *GameState) foldEquity(seat int) float64 {
+	ratio := 1.0
+	for _, o := range g.contenders() {
+		if o == seat {
+			continue
+		}
+		foldToBet := typicalFoldToBet
+		if p := g.Players[o]; !p.IsAI {
+			foldToBet = g.StatsFor(p.ID).FoldToBet()
+		}
+		ratio *= foldToBet / typicalFoldToBet
+	}
+	return ratio
+}
+
+// bettorBluffRate is how often the player who made the last bet or raise has
+// been caught bluffing.
+func (g *GameState) bettorBluffRate() float64 {
+	for i := len(g.Events) - 1; i >= 0; i-- {
+		switch e := g.Events[i]; e.Type {
+		case EventBet, EventRaise, EventAllIn:
+			if p := g.Players[e.Seat]; !p.IsAI {
+				return g.StatsFor(p.ID).BluffRate()
+			}
+			return typicalBluff
+		}
+	}
+	return typicalBluff
+}
+
+// equity estimates seat's share of the pot with hand, from sims simulated
+// deals of the cards seat cannot see. Opponents are dealt hands consistent
+// with what they have shown: once a player has drawn, their hand before the
+// draw is one whose typical draw takes as many cards as they did (three
+// suggests a pair, one two pair or a draw, none a made hand). Players still
+// to draw, the AI included, draw typically from their hand. In Hold'em, hand
+// includes the board, opponents hold any two cards, and the rest of the
+// board is dealt. In stud, opponents hold the cards they are showing.
+func (g *GameState) equity(seat int, hand Hand, sims int) float64 {
+	rng := g.rng()
+	var opponents []int
+	for _, s := range g.contenders() {
+		if s != seat {
+			opponents = append(opponents, s)
+		}
+	}
+	if len(opponents) == 0 {
+		return 1
+	}
+	rules := g.Rules
+	switch g.variant() {
+	case VariantHoldem:
+		return boardEquity(hand, len(g.Board), len(opponents), rules, sims, rng)
+	case VariantStud:
+		showing := make([]Hand, len(opponents))
+		for i, o := range opponents {
+			showing[i] = g.RoundStates[o].Showing()
+		}
+		return studEquity(hand, showing, rules, sims, rng)
+	}
+	unknown := rules.unknownCards(hand)
+	if sims <= 0 || len(unknown) < 10*len(opponents)+5 {
+		return 0.5
+	}
+	// Opponents may still draw if anyone may; the simulations model one
+	// more draw however many are left
+	drawing := g.drawsLeft(seat) > 0 || g.GamePhase == PhaseDiscard
+
+	// Hands each drawn opponent may have started from, found once up front
+	// since some draws (standing pat) are rare
+	ranges := make(map[int][]Hand)
+	for _, o := range opponents {
+		if rs := g.RoundStates[o]; rs.Discarded {
+			ranges[o] = drawRange(unknown, rs.Drew, rules, rng)
+		}
+	}
+
+	total := 0.0
+	for sim := 0; sim < sims; sim++ {
+		d := simDeal{deck: unknown, rng: rng, rules: rules}
+
+		// Share the pot among the hands that tie for best
+		theirs := make([]Hand, len(opponents))
+		for i, o := range opponents {
+			theirs[i] = d.opponentHand(ranges[o], drawing)
+		}
+		mine := append(Hand(nil), hand...)
+		if g.drawsLeft(seat) > 0 {
+			d.draw(mine, typicalDraw(mine, rules))
+		}
+
+		total += potShare(mine, theirs, rules)
+	}
+	return total / float64(sims)
+}
+
+// drawsLeft is how many more times seat will draw this hand.
+func (g *GameState) drawsLeft(seat int) int {
+	if g.variant() != VariantDraw {
+		return 0
+	}
+	left := g.Rules.draws() - g.DrawRound
+	switch g.GamePhase {
+	case PhasePreDrawBetting:
+		return left
+	case PhaseDiscard:
+		if !g.RoundStates[seat].Discarded {
+			left++
+		}
+		return left
+	case PhasePostDrawBetting:
+		return left
+	}
+	return 0
+}
+
+// boardEquity is equity for Hold'em, where hand ends with the boardSize
+// cards on the board.
+func boardEquity(hand Hand, boardSize, opponents int, rules Rules, sims int, rng *RNG) float64 {
+	unknown := rules.unknownCards(hand)
+	toCome := 5 - boardSize
+	if sims <= 0 || len(unknown) < 2*opponents+toCome {
+		return 0.5
+	}
+	board := hand[len(hand)-boardSize:]
+
+	total := 0.0
+	for sim := 0; sim < sims; sim++ {
+		d := simDeal{deck: unknown, rng: rng}
+		runout := d.deal(toCome)
+		mine := append(slices.Clone(hand), runout...)
+		theirs := make([]Hand, opponents)
+		for i := range theirs {
+			theirs[i] = slices.Concat(d.deal(2), board, runout)
+		}
+		total += potShare(mine, theirs, rules)
+	}
+	return total / float64(sims)
+}
+
+// studEquity is equity for seven-card stud against opponents showing the
+// given cards. Their up-cards are out of the deck and in their hands; the
+// rest of every hand is dealt out to seven cards.
+func studEquity(hand Hand, showing []Hand, rules Rules, sims int, rng *RNG) float64 {
+	known := slices.Concat(append([]Hand{hand}, showing...)...)
+	unknown := rules.unknownCards(known)
+	need := 7 - len(hand)
+	for _, up := range showing {
+		need += 7 - len(up)
+	}
+	if sims <= 0 || len(unknown) < need {
+		return 0.5
+	}
+
+	total := 0.0
+	for sim := 0; sim < sims; sim++ {
+		d := simDeal{deck: unknown, rng: rng}
+		mine := append(slices.Clone(hand), d.deal(7-len(hand))...)
+		theirs := make([]Hand, len(showing))
+		for i, up := range showing {
+			theirs[i] = append(slices.Clone(up), d.deal(7-len(up))...)
+		}
+		total += potShare(mine, theirs, rules)
+	}
+	return total / float64(sims)
+}
+
+// potShare is the share of the pot mine wins against theirs: nothing if any
+// beats it, otherwise split evenly with those that tie it. In hi-lo that is
+// half of it for each half of the pot, unless no one has a low.
+func potShare(mine Hand, theirs []Hand, rules Rules) float64 {
+	high := share(mine, theirs, rules.Compare)
+	if rules.ranking() != RankingHiLo {
+		return high
+	}
+	hasLow := func(h Hand) bool {
+		low, _ := rules.BestLow(h)
+		return low > 0
+	}
+	if !hasLow(mine) && !slices.ContainsFunc(theirs, hasLow) {
+		return high
+	}
+	return (high + share(mine, theirs, rules.CompareLow)) / 2
+}
+
+// share is mine's share of a pot won by the best hand by compare.
+func share(mine Hand, theirs []Hand, compare func(a, b []Card) ComparisonResult) float64 {
+	ties := 0
+	for _, h := range theirs {
+		switch compare(mine, h) {
+		case ResultHand2Wins:
+			return 0
+		case ResultTie:
+			ties++
+		}
+	}
+	return 1 / float64(ties+1)
+}
+
+// Bounds on the search for hands matching an opponent's draw.
+const (
+	rangeSize  = 64
+	rangeTries = 20000
+)
+
+// drawRange samples hands from deck whose typical draw takes drew cards.
+// It returns nil if none turn up, in which case any hand will do.
+func drawRange(deck Deck, drew int, rules Rules, rng *RNG) []Hand {
+	d := simDeal{deck: deck, rng: rng}
+	var hands []Hand
+	for try := 0; try < rangeTries && len(hands) < rangeSize; try++ {
+		hand := d.peek(5)
+		if len(typicalDraw(hand, rules)) == drew {
+			hands = append(hands, hand)
+		}
+	}
+	return hands
+}
+
+// simDeal deals without replacement from a deck of unseen cards, shuffling
+// only as much of it as it uses. Simulated opponents draw by rules.
+type simDeal struct {
+	deck  Deck
+	pos   int
+	rng   *RNG
+	rules Rules
+}
+
+// peek moves n random undealt cards to the front of the undealt part of the
+// deck, without dealing them.
+func (d *simDeal) peek(n int) Hand {
+	for i := d.pos; i < d.pos+n; i++ {
+		j := i + d.rng.IntN(len(d.deck)-i)
+		d.deck[i], d.deck[j] = d.deck[j], d.deck[i]
+	}
+	return append(Hand(nil), d.deck[d.pos:d.pos+n]...)
+}
+
+// deal deals n random cards.
+func (d *simDeal) deal(n int) Hand {
+	h := d.peek(n)
+	d.pos += n
+	return h
+}
+
+// take deals the given cards, reporting false (and dealing nothing) if any
+// of them have already been dealt.
+func (d *simDeal) take(hand Hand) bool {
+	pos := d.pos
+	for _, c := range hand {
+		i := slices.Index(d.deck[pos:], c)
+		if i < 0 {
+			return false
+		}
+		d.deck[pos], d.deck[pos+i] = d.deck[pos+i], d.deck[pos]
+		pos++
+	}
+	d.pos = pos
+	return true
+}
+
+// draw replaces the cards of hand at indices with newly dealt ones.
+func (d *simDeal) draw(hand Hand, indices []int) {
+	for i, c := range d.deal(len(indices)) {
+		hand[indices[i]] = c
+	}
+}
+
+// opponentHand deals a final hand for an opponent who started from one of
+// hands (any hand, if empty) and draws typically if the draw is still to come
+// or already happened.
+func (d *simDeal) opponentHand(hands []Hand, drawing bool) Hand {
+	if len(hands) > 0 {
+		// Another simulated player may already hold some of these cards
+		for try := 0; try < 8; try++ {
+			hand := append(Hand(nil), hands[d.rng.IntN(len(hands))]...)
+			if d.take(hand) {
+				d.draw(hand, typicalDraw(hand, d.rules))
+				return hand
+			}
+		}
+	}
+	hand := d.deal(5)
+	if drawing || len(hands) > 0 {
+		d.draw(hand, typicalDraw(hand, d.rules))
+	}
+	return hand
+}
+
+// typicalDraw is the textbook draw, used to model other players: stand pat
+// on a straight or better, keep pairs and sets, draw one to four-card
+// flushes and open-ended straights, and otherwise keep only the high card.
+// Wild cards are always kept, along with any natural pair, or else the
+// highest natural card. Lowball draws to a low (see lowDraw), as does hi-lo
+// holding four low cards.
+func typicalDraw(hand Hand, rules Rules) []int {
+	if rules.lowball() || (rules.ranking() == RankingHiLo && lowCards(hand) >= 4) {
+		return lowDraw(hand, rules)
+	}
+	rank := rules.Strength(hand).Rank()
+	if rank >= Straight {
+		return nil
+	}
+	if slices.ContainsFunc(hand, rules.Wild) {
+		return wildDraw(hand, rules)
+	}
+
+	counts := make(map[Rank]int)
+	suits := make(map[Suit]int)
+	for _, c := range hand {
+		counts[c.Rank]++
+		suits[c.Suit]++
+	}
+
+	var discards []int
+	if rank >= OnePair {
+		for i, c := range hand {
+			if counts[c.Rank] == 1 {
+				discards = append(discards, i)
+			}
+		}
+		return discards
+	}
+
+	// Four to a flush
+	for suit, n := range suits {
+		if n == 4 {
+			for i, c := range hand {
+				if c.Suit != suit {
+					return []int{i}
+				}
+			}
+		}
+	}
+
+	// Four to an open-ended straight: four consecutive ranks, below the ace
+	values := make([]int, len(hand))
+	for i, c := range hand {
+		values[i] = CardValue(c.Rank)
+	}
+	for skip := range hand {
+		var rest []int
+		for i, v := range values {
+			if i != skip {
+				rest = append(rest, v)
+			}
+		}
+		lo, hi := slices.Min(rest), slices.Max(rest)
+		if hi-lo == 3 && hi < 14 {
+			return []int{skip}
+		}
+	}
+
+	// Keep the high card
+	high := 0
+	for i, v := range values {
+		if v > values[high] {
+			high = i
+		}
+	}
+	for i := range hand {
+		if i != high {
+			discards = append(discards, i)
+		}
+	}
+	return discards
+}
+
+// wildDraw is typicalDraw for a hand holding wild cards, short of a
+// straight: keep the wilds and any natural pairs, or else the wilds and the
+// highest natural card.
+func wildDraw(hand Hand, rules Rules) []int {
+	counts := make(map[Rank]int)
+	high := -1
+	for i, c := range hand {
+		if rules.Wild(c) {
+			continue
+		}
+		counts[c.Rank]++
+		if high < 0 || CardValue(c.Rank) > CardValue(hand[high].Rank) {
+			high = i
+		}
+	}
+	paired := false
+	for _, n := range counts {
+		paired = paired || n > 1
+	}
+
+	var discards []int
+	for i, c := range hand {
+		switch {
+		case rules.Wild(c):
+		case paired && counts[c.Rank] > 1:
+		case !paired && i == high:
+		default:
+			discards = append(discards, i)
+		}
+	}
+	return discards
+}
@@ -37,7 +37,7 @@ func TestChooseDiscard(t *testing.T) {
 		{Clubs, "2"},
 	}
 
-	discards1 := ai.ChooseDiscard(hand1)
+	discards1 := ai.ChooseDiscard(hand1, Rules{}, NewRNG(1))
 	if len(discards1) != 1 {
 		t.Fatalf("Expected 1 discard, got %d", len(discards1))
 	}
@@ -56,7 +56,7 @@ func TestChooseDiscard_KeepPatHand(t *testing.T) {
 		{Hearts, "ace"}, {Spades, "ace"}, {Clubs, "ace"},
 		{Hearts, "king"}, {Diamonds, "king"},
 	}
-	discards2 := ai.ChooseDiscard(hand2)
+	discards2 := ai.ChooseDiscard(hand2, Rules{}, NewRNG(1))
 	if len(discards2) != 0 {
 		t.Errorf("Expected 0 discards for Full House, got %v", discards2)
 	}
@@ -72,7 +72,7 @@ func TestChooseDiscard_KeepThreeOfAKind(t *testing.T) {
 		{Hearts, "ace"}, {Spades, "ace"}, {Clubs, "ace"},
 		{Hearts, "2"}, {Diamonds, "3"},
 	}
-	discards3 := ai.ChooseDiscard(hand3)
+	discards3 := ai.ChooseDiscard(hand3, Rules{}, NewRNG(1))
 
 	valid := false
 	// Expecting to discard indices 3 and 4
@@ -89,3 +89,91 @@ func TestChooseDiscard_KeepThreeOfAKind(t *testing.T) {
 		t.Errorf("Expected to discard 2 and 3 (indices 3, 4)")
 	}
 }
+
+func TestTypicalDraw(t *testing.T) {
+	cases := []struct {
+		name string
+		hand Hand
+		want []int
+	}{
+		{"pat straight", Hand{{Hearts, "5"}, {Spades, "6"}, {Clubs, "7"}, {Diamonds, "8"}, {Hearts, "9"}}, nil},
+		{"pair", handPair, []int{2, 3, 4}},
+		{"trips", handTrips, []int{3, 4}},
+		{"four flush", Hand{{Hearts, "2"}, {Hearts, "9"}, {Spades, "king"}, {Hearts, "jack"}, {Hearts, "5"}}, []int{2}},
+		{"open ended", Hand{{Hearts, "5"}, {Spades, "6"}, {Clubs, "king"}, {Diamonds, "8"}, {Hearts, "7"}}, []int{2}},
+		{"high card", handHigh, []int{1, 2, 3, 4}},
+	}
+	for _, c := range cases {
+		if got := typicalDraw(c.hand, Rules{}); fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+// afterDraw sets up a heads-up final betting round with the AI in seat 1
+// holding hand, after the human drew drew cards.
+func afterDraw(hand Hand, drew int) *GameState {
+	g := NewGame("p")
+	g.SetSeed(1)
+	g.CollectAnte(10)
+	g.GamePhase = PhasePostDrawBetting
+	for _, rs := range g.RoundStates {
+		rs.Discarded = true
+	}
+	g.RoundStates[0].Drew = drew
+	g.RoundStates[1].Hand = hand
+	g.setTurn(1)
+	return g
+}
+
+func TestEquityReadsOpponentDraw(t *testing.T) {
+	kings := Hand{{Hearts, "king"}, {Spades, "king"}, {Clubs, "9"}, {Diamonds, "4"}, {Hearts, "2"}}
+
+	vsPat := afterDraw(kings, 0).equity(1, kings, 500)
+	vsPair := afterDraw(kings, 3).equity(1, kings, 500)
+	vsNothing := afterDraw(kings, 4).equity(1, kings, 500)
+
+	if !(vsPat < vsPair && vsPair < vsNothing) {
+		t.Errorf("kings should fare worse the fewer cards the opponent drew: pat %.2f, three %.2f, four %.2f", vsPat, vsPair, vsNothing)
+	}
+	if vsPat > 0.3 {
+		t.Errorf("kings should rarely beat a pat hand, got %.2f", vsPat)
+	}
+}
+
+func TestDecideActionSizesBetsByStrength(t *testing.T) {
+	ai := DefaultAI
+	ai.Courage = 1.0
+	royal := Hand{{Spades, "ace"}, {Spades, "king"}, {Spades, "queen"}, {Spades, "jack"}, {Spades, "10"}}
+	twoPair := Hand{{Hearts, "9"}, {Spades, "9"}, {Clubs, "4"}, {Diamonds, "4"}, {Hearts, "king"}}
+
+	g := afterDraw(royal, 3)
+	g.Pot = 100
+	action, big := ai.DecideAction(royal, g)
+	if action != "bet" {
+		t.Fatalf("expected a value bet with a royal flush, got %s", action)
+	}
+
+	g = afterDraw(twoPair, 3)
+	g.Pot = 100
+	if action, small := ai.DecideAction(twoPair, g); action == "bet" && small >= big {
+		t.Errorf("two pair bet %d, should be less than the royal's %d", small, big)
+	}
+}
+
+func TestDecideActionFoldsToPatHandBet(t *testing.T) {
+	ai := DefaultAI
+	ai.Courage = 1.0
+	g := afterDraw(handHigh, 0)
+	g.Pot = 60
+	g.CurrentBet = 40
+	folds := 0
+	for i := 0; i < 20; i++ {
+		if action, _ := ai.DecideAction(handHigh, g); action == "fold" {
+			folds++
+		}
+	}
+	if folds < 15 {
+		t.Errorf("ace high should fold to a big bet from a pat hand, folded %d of 20", folds)
+	}
+}
@@ -0,0 +1,186 @@
+package game
+
+import "fmt"
+
+// Betting is a table's betting structure: how much a seat may bet or raise.
+// The zero value is BettingNoLimit.
+type Betting string
+
+const (
+	BettingNoLimit    Betting = "no_limit"    // Any amount up to the stack; a raise at least matches the last
+	BettingPotLimit   Betting = "pot_limit"   // As no-limit, but a raise at most matches the pot after calling
+	BettingFixedLimit Betting = "fixed_limit" // Bets and raises of one size, the big bet in later rounds, capped per round
+)
+
+// Default fixed-limit sizes, relative to the table's stake.
+const (
+	defaultRaiseCap = 4 // A bet and three raises
+	bigBetMultiple  = 2 // The big bet is twice the small one
+)
+
+// ParseBetting checks that s names a betting structure; empty is
+// BettingNoLimit.
+func ParseBetting(s string) (Betting, error) {
+	switch b := Betting(s); b {
+	case "", BettingNoLimit:
+		return BettingNoLimit, nil
+	case BettingPotLimit, BettingFixedLimit:
+		return b, nil
+	}
+	return "", fmt.Errorf("unknown betting structure %q", s)
+}
+
+// betting returns the table's betting structure, defaulting to no limit.
+func (r Rules) betting() Betting {
+	if r.Betting == "" {
+		return BettingNoLimit
+	}
+	return r.Betting
+}
+
+// name describes the structure for Rules.Name; no limit goes without
+// saying.
+func (b Betting) name() string {
+	switch b {
+	case BettingPotLimit:
+		return "Pot Limit"
+	case BettingFixedLimit:
+		return "Fixed Limit"
+	}
+	return ""
+}
+
+// raiseCap returns how many bets and raises a fixed-limit round allows.
+func (r Rules) raiseCap() int {
+	if r.RaiseCap > 0 {
+		return r.RaiseCap
+	}
+	return defaultRaiseCap
+}
+
+// BetRange is how much a seat may bet or raise by, on top of what it must
+// call. Min is less than the usual minimum only when it is everything the
+// seat has.
+type BetRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// BetRange returns how much seat may bet or raise by under the table's
+// betting structure, and false if it cannot bet or raise at all: it is not
+// its turn, a fixed-limit round is capped, it cannot cover more than the
+// call, or it has acted and faces only a short all-in, which does not
+// reopen the betting.
+func (g *GameState) BetRange(seat int) (BetRange, bool) {
+	if seat < 0 || seat >= len(g.Players) || seat != g.TurnIndex || !g.isBetting() || g.RoundStates[seat].Acted {
+		return BetRange{}, false
+	}
+	toCall := g.CurrentBet - g.RoundStates[seat].Bet
+	afford := g.Players[seat].Sanity - toCall
+	if afford <= 0 {
+		return BetRange{}, false
+	}
+
+	var r BetRange
+	switch g.Rules.betting() {
+	case BettingFixedLimit:
+		if g.Raises >= g.Rules.raiseCap() {
+			return BetRange{}, false
+		}
+		r = BetRange{Min: g.limitBet(), Max: g.limitBet()}
+	case BettingPotLimit:
+		// The pot already holds this round's bets, so calling makes it
+		// this big
+		r = BetRange{Min: g.minRaise(), Max: g.Pot + toCall}
+	default:
+		r = BetRange{Min: g.minRaise(), Max: afford}
+	}
+	r.Max = min(r.Max, afford)
+	r.Min = min(r.Min, r.Max)
+	return r, true
+}
+
+// minRaise is the least a no-limit or pot-limit bet or raise may be: the
+// size of the last one this round, and never less than the stake.
+func (g *GameState) minRaise() int {
+	return max(g.LastRaise, g.Stake, 1)
+}
+
+// limitBet is the size of every bet and raise in this fixed-limit round:
+// the small bet in the first half of the hand's betting rounds and the big
+// bet in the rest.
+func (g *GameState) limitBet() int {
+	small := g.Rules.SmallBet
+	if small <= 0 {
+		small = max(g.Stake, 1)
+	}
+	big := g.Rules.BigBet
+	if big <= 0 {
+		big = small * bigBetMultiple
+	}
+	if g.bigBetRound() {
+		return big
+	}
+	return small
+}
+
+// bigBetRound reports whether the current betting round plays for the big
+// bet in fixed limit: from the turn in Hold'em, from fifth street in stud,
+// and in draw poker from halfway through the draws (after the draw, in
+// single draw).
+func (g *GameState) bigBetRound() bool {
+	switch g.GamePhase {
+	case PhaseTurn, PhaseRiver, PhaseFifthStreet, PhaseSixthStreet, PhaseSeventhStreet:
+		return true
+	case PhasePostDrawBetting:
+		return g.DrawRound >= (g.Rules.draws()+1)/2
+	}
+	return false
+}
+
+// checkRaise reports why seat may not bet or raise by amount, or "" if it
+// may.
+func (g *GameState) checkRaise(seat, amount int) string {
+	r, ok := g.BetRange(seat)
+	switch {
+	case !ok && g.Rules.betting() == BettingFixedLimit:
+		return fmt.Sprintf("Betting is capped at %d bets and raises a round.", g.Rules.raiseCap())
+	case !ok:
+		return "You cannot raise now."
+	case amount < r.Min || amount > r.Max:
+		if r.Min == r.Max {
+			return fmt.Sprintf("Bets and raises are %d here.", r.Min)
+		}
+		return fmt.Sprintf("Bets and raises are from %d to %d here.", r.Min, r.Max)
+	}
+	return ""
+}
+
+// raised notes a bet or raise by amount towards the limits on the next:
+// only a full raise sets the minimum for the next one.
+func (g *GameState) raised(amount int) {
+	g.Raises++
+	g.LastRaise = max(g.LastRaise, amount)
+}
+
+// raiseTo makes seat's bet the one the others must call. An opening bet or
+// a full raise reopens the betting and counts towards the limits; a raise
+// short of that, which only an all-in can be, does neither, so the seats
+// that have acted may just call or fold.
+func (g *GameState) raiseTo(seat int) {
+	bet := g.RoundStates[seat].Bet
+	if raise := bet - g.CurrentBet; g.CurrentBet == 0 || raise >= g.fullRaise() {
+		g.raised(raise)
+		g.reopenBetting()
+	}
+	g.CurrentBet = bet
+}
+
+// fullRaise is the least raise that reopens the betting: the minimum raise,
+// or the round's bet in fixed limit.
+func (g *GameState) fullRaise() int {
+	if g.Rules.betting() == BettingFixedLimit {
+		return g.limitBet()
+	}
+	return g.minRaise()
+}
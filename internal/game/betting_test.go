@@ -0,0 +1,196 @@
+package game
+
+import "testing"
+
+// newBettingTable deals a two-seat hand of draw with 10 antes, so the pot
+// starts at 20, under betting structure b.
+func newBettingTable(t *testing.T, b Betting) *GameState {
+	t.Helper()
+	g := newHumanTable(t, 2)
+	if err := g.SetRules(Rules{Betting: b}); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	g.CollectAnte(10)
+	return g
+}
+
+// refuse fails the test if the seat to act may take action.
+func refuse(t *testing.T, g *GameState, action string, amount int) {
+	t.Helper()
+	if ok, _ := g.SeatAction(g.TurnIndex, action, amount); ok {
+		t.Fatalf("seat %d should not be allowed to %s %d in %s", g.TurnIndex, action, amount, g.GamePhase)
+	}
+}
+
+func TestNoLimitMinimumRaise(t *testing.T) {
+	g := newBettingTable(t, BettingNoLimit)
+	refuse(t, g, "bet", 5) // Below the stake
+	act(t, g, "bet", 10)
+	act(t, g, "raise", 30)
+
+	if r, ok := g.BetRange(g.TurnIndex); !ok || r.Min != 30 || r.Max != g.Players[g.TurnIndex].Sanity-30 {
+		t.Errorf("expected raises from the last raise of 30 up to the stack, got %+v", r)
+	}
+	refuse(t, g, "raise", 20)
+	act(t, g, "raise", 40)
+	if g.LastRaise != 40 {
+		t.Errorf("expected the bigger raise to set the minimum, got %d", g.LastRaise)
+	}
+}
+
+func TestNoLimitShortAllIn(t *testing.T) {
+	g := newBettingTable(t, BettingNoLimit)
+	g.Players[1].Sanity = 15
+	act(t, g, "bet", 10)
+
+	// 15 is 5 over the call: less than a full raise, but all it has
+	if r, ok := g.BetRange(1); !ok || r.Min != 5 || r.Max != 5 {
+		t.Errorf("expected only the shove, got %+v", r)
+	}
+	act(t, g, "allin", 0)
+	if g.LastRaise != 10 {
+		t.Errorf("a short all in should not raise the minimum, got %d", g.LastRaise)
+	}
+}
+
+func TestShortAllInDoesNotReopenTheBetting(t *testing.T) {
+	for _, b := range []Betting{BettingNoLimit, BettingFixedLimit} {
+		g := newHumanTable(t, 3)
+		if err := g.SetRules(Rules{Betting: b}); err != nil {
+			t.Fatalf("SetRules: %v", err)
+		}
+		g.CollectAnte(10)
+		g.Players[2].Sanity = 15
+		act(t, g, "bet", 10)
+		act(t, g, "call", 0)
+		act(t, g, "allin", 0) // 5 over the bet, short of a raise
+
+		if g.TurnIndex != 0 || g.CurrentBet != 15 {
+			t.Fatalf("%s: expected the bettor to face 15, got seat %d facing %d", b, g.TurnIndex, g.CurrentBet)
+		}
+		if legal := g.LegalActions(0); legal.Raise != nil || legal.AllIn != 0 || legal.Call != 5 {
+			t.Errorf("%s: expected the bettor only to call or fold, got %+v", b, legal)
+		}
+		refuse(t, g, "raise", 10)
+		if g.Raises != 1 || g.LastRaise != 10 {
+			t.Errorf("%s: expected the short all in not to count as a raise, got %d raises, last %d", b, g.Raises, g.LastRaise)
+		}
+		act(t, g, "call", 0)
+		act(t, g, "call", 0)
+		if g.isBetting() {
+			t.Errorf("%s: expected the calls to close the round, still in %s", b, g.GamePhase)
+		}
+	}
+}
+
+func TestPotLimitMaximum(t *testing.T) {
+	g := newBettingTable(t, BettingPotLimit)
+	refuse(t, g, "bet", 21)
+	refuse(t, g, "allin", 0)
+	act(t, g, "bet", 20)
+
+	// Calling 20 makes the pot 60, so that is the most the raise can be
+	if r, ok := g.BetRange(1); !ok || r.Min != 20 || r.Max != 60 {
+		t.Errorf("expected raises from 20 to 60, got %+v", r)
+	}
+	refuse(t, g, "raise", 61)
+	act(t, g, "raise", 60)
+	if g.CurrentBet != 80 {
+		t.Errorf("expected 80 to call, got %d", g.CurrentBet)
+	}
+}
+
+func TestFixedLimitSizesAndCap(t *testing.T) {
+	g := newBettingTable(t, BettingFixedLimit)
+	refuse(t, g, "bet", 20)
+	act(t, g, "bet", 10)
+	for range defaultRaiseCap - 1 {
+		act(t, g, "raise", 10)
+	}
+	if _, ok := g.BetRange(g.TurnIndex); ok {
+		t.Errorf("expected the round to be capped after %d bets and raises", defaultRaiseCap)
+	}
+	refuse(t, g, "raise", 10)
+	refuse(t, g, "allin", 0)
+	act(t, g, "call", 0)
+
+	// After the draw the big bet applies
+	g.SeatDiscard(g.TurnIndex, nil)
+	g.SeatDiscard(g.TurnIndex, nil)
+	if r, ok := g.BetRange(g.TurnIndex); !ok || r.Min != 20 || r.Max != 20 {
+		t.Errorf("expected the big bet of 20 after the draw, got %+v", r)
+	}
+}
+
+func TestFixedLimitBigBlindCountsTowardsCap(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.SetVariant(VariantHoldem)
+	g.SetRules(Rules{Betting: BettingFixedLimit, RaiseCap: 2})
+	g.CollectAnte(10)
+	act(t, g, "raise", 10)
+	refuse(t, g, "raise", 10)
+}
+
+func TestAIBetsWithinFixedLimit(t *testing.T) {
+	for _, p := range []Personality{PersonalityAncient, PersonalityManiac, PersonalityOracle} {
+		for seed := uint64(1); seed <= 5; seed++ {
+			g := NewGame("p")
+			g.SetSeed(seed)
+			g.SetRules(Rules{Betting: BettingFixedLimit})
+			g.SetPersonality(1, p)
+			playScripted(g)
+
+			put := map[int]int{}
+			high, raises := 0, 0
+			for _, e := range g.Events {
+				switch e.Type {
+				case EventPhase:
+					clear(put)
+					high, raises = 0, 0
+				case EventCall, EventBet, EventRaise, EventAllIn:
+					put[e.Seat] += e.Amount
+					if by := put[e.Seat] - high; by > 0 {
+						raises++
+						if by != 10 && by != 20 {
+							t.Errorf("%s seed %d: seat %d raised by %d", p, seed, e.Seat, by)
+						}
+						high = put[e.Seat]
+					}
+				}
+				if raises > defaultRaiseCap {
+					t.Fatalf("%s seed %d: %d bets and raises in a round", p, seed, raises)
+				}
+			}
+		}
+	}
+}
+
+func TestViewShowsBetRange(t *testing.T) {
+	g := NewGame("p")
+	g.SetRules(Rules{Betting: BettingPotLimit})
+	g.CollectAnte(10)
+	g.OpponentTurn()
+	v := g.View("p")
+	if g.TurnIndex != 0 {
+		if v.BetRange != nil {
+			t.Errorf("expected no bet range out of turn, got %+v", v.BetRange)
+		}
+		return
+	}
+	if v.BetRange == nil || v.BetRange.Max > g.Pot+g.CurrentBet {
+		t.Errorf("expected a pot-limit range, got %+v", v.BetRange)
+	}
+}
+
+func TestSetRulesBetting(t *testing.T) {
+	g := NewGame("p")
+	if err := g.SetRules(Rules{Betting: "spread_limit"}); err == nil {
+		t.Errorf("expected an unknown betting structure to be refused")
+	}
+	if err := g.SetRules(Rules{Betting: BettingFixedLimit, SmallBet: 20, BigBet: 10}); err == nil {
+		t.Errorf("expected a big bet smaller than the small bet to be refused")
+	}
+	if name := (Rules{Betting: BettingPotLimit}).Name(); name != "Pot Limit" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
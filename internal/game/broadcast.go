@@ -0,0 +1,54 @@
+package game
+
+import "slices"
+
+// Broadcast is a delayed broadcast of the game, like televised poker: the
+// current hand as it stood some actions ago, with every hand face up.
+type Broadcast struct {
+	View   *View   `json:"view"`
+	Events []Event `json:"events"` // The hand's events up to the point shown
+}
+
+// Broadcast projects the game for spectators delay actions behind live
+// play. It is a projection of the hand's events: those up to the cut are
+// replayed from the seed, so the broadcast can never show more than had
+// happened by then. Once the hand is over the broadcast catches up. Hole
+// cards are shown while the hand is live, so the delay is what keeps them
+// from the players; a broadcast must never reach someone seated.
+func (g *GameState) Broadcast(delay int) (*Broadcast, error) {
+	shown := g
+	cut := len(g.Events)
+	if g.handInProgress() {
+		for i, actions := len(g.Events)-1, 0; i >= 0 && actions < delay; i-- {
+			if g.Events[i].isAction() {
+				actions++
+				cut = i
+			}
+		}
+		rec := &HandRecord{GameID: g.ID, Number: g.HandNumber, Events: g.Events[:cut]}
+		replayed, err := Replay(rec, g.Seed, nil)
+		if err != nil {
+			return nil, err
+		}
+		shown = replayed
+	}
+
+	v := shown.View("")
+	v.Delay = delay
+	for i, rs := range shown.RoundStates {
+		if !rs.SittingOut {
+			v.RoundStates[i].Hand = slices.Clone(rs.Hand)
+		}
+	}
+	return &Broadcast{View: v, Events: g.EventsFor("", 0)[:cut]}, nil
+}
+
+// isAction reports whether the event is a decision a seat took, as opposed
+// to something the table did.
+func (e Event) isAction() bool {
+	switch e.Type {
+	case EventCheck, EventCall, EventBet, EventRaise, EventAllIn, EventFold, EventDiscard:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBroadcastLagsLivePlay(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+	snapshots := []*GameState{cloneGame(t, g)}
+	for _, a := range []struct {
+		action string
+		amount int
+	}{{"check", 0}, {"bet", 10}, {"call", 0}, {"raise", 20}} {
+		act(t, g, a.action, a.amount)
+		snapshots = append(snapshots, cloneGame(t, g))
+	}
+
+	for delay := 0; delay <= 6; delay++ {
+		b, err := g.Broadcast(delay)
+		if err != nil {
+			t.Fatalf("delay %d: %v", delay, err)
+		}
+		want := snapshots[max(0, len(snapshots)-1-delay)]
+		if b.View.LastAction != want.LastAction || b.View.Pot != want.Pot || b.View.TurnIndex != want.TurnIndex {
+			t.Errorf("delay %d: expected %q with %d in the pot, got %q with %d", delay, want.LastAction, want.Pot, b.View.LastAction, b.View.Pot)
+		}
+		if len(b.Events) != len(want.Events) {
+			t.Errorf("delay %d: expected %d events, got %d", delay, len(want.Events), len(b.Events))
+		}
+		if b.View.Delay != delay {
+			t.Errorf("delay %d: view says %d", delay, b.View.Delay)
+		}
+		for i, rs := range g.RoundStates {
+			if !slices.Equal(b.View.RoundStates[i].Hand, rs.Hand) {
+				t.Errorf("delay %d: expected seat %d's hand face up", delay, i)
+			}
+		}
+	}
+
+	// The live view, for spectators of a table without a delay, stays dark
+	body := viewJSON(t, g.View(""))
+	for _, rs := range g.RoundStates {
+		assertHidden(t, body, rs.Hand)
+	}
+}
+
+func TestBroadcastReplaysTheAI(t *testing.T) {
+	live := 0
+	for seed := uint64(1); seed <= 5; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		g.Stats = map[string]*PlayerStats{"p": {Hands: 3}}
+		g.CollectAnte(10)
+		g.OpponentTurn()
+		for i := 0; i < 3 && g.isBetting(); i++ {
+			legal := g.LegalActions(0)
+			if legal.Check {
+				g.PlayerAction("check", 0)
+			} else {
+				g.PlayerAction("call", 0)
+			}
+			g.OpponentTurn()
+		}
+		if g.handInProgress() {
+			live++
+		}
+
+		b, err := g.Broadcast(1)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for _, e := range b.Events {
+			if e.Stats != nil {
+				t.Errorf("seed %d: the broadcast should not carry what the AI knows", seed)
+			}
+		}
+	}
+	if live == 0 {
+		t.Errorf("expected some hands to still be live, so the AI is replayed")
+	}
+}
+
+func TestBroadcastCatchesUpAfterTheHand(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	act(t, g, "fold", 0)
+
+	b, err := g.Broadcast(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Events) != len(g.Events) || b.View.GamePhase != g.GamePhase {
+		t.Errorf("expected the whole hand once it is over, got %d of %d events in %s", len(b.Events), len(g.Events), b.View.GamePhase)
+	}
+	for i, rs := range g.RoundStates {
+		if !slices.Equal(b.View.RoundStates[i].Hand, rs.Hand) {
+			t.Errorf("expected seat %d's hand face up once the hand is over", i)
+		}
+	}
+}
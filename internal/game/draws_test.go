@@ -0,0 +1,139 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+var tripleDraw = Rules{Ranking: RankingDeuceToSeven, Draws: 3}
+
+// playDraws checks and draws seat by seat until the hand is over, each seat
+// drawing the cards at indices, and returns the phases the table went
+// through.
+func playDraws(t *testing.T, g *GameState, indices []int) []GamePhase {
+	t.Helper()
+	var phases []GamePhase
+	for i := 0; g.GamePhase != PhaseComplete; i++ {
+		if i > 200 {
+			t.Fatalf("hand did not finish, stuck in %s", g.GamePhase)
+		}
+		if len(phases) == 0 || phases[len(phases)-1] != g.GamePhase {
+			phases = append(phases, g.GamePhase)
+		}
+		if g.GamePhase == PhaseDiscard {
+			if ok, msg := g.SeatDiscard(g.TurnIndex, indices); !ok {
+				t.Fatalf("seat %d cannot draw: %s", g.TurnIndex, msg)
+			}
+			continue
+		}
+		act(t, g, "check", 0)
+	}
+	return phases
+}
+
+func TestTripleDrawPhases(t *testing.T) {
+	g := newHumanTable(t, 2)
+	if err := g.SetRules(tripleDraw); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	g.CollectAnte(10)
+	phases := playDraws(t, g, []int{0})
+
+	want := []GamePhase{PhasePreDrawBetting,
+		PhaseDiscard, PhasePostDrawBetting,
+		PhaseDiscard, PhasePostDrawBetting,
+		PhaseDiscard, PhasePostDrawBetting}
+	if !slices.Equal(phases, want) {
+		t.Errorf("expected phases %v, got %v", want, phases)
+	}
+	if g.DrawRound != 3 {
+		t.Errorf("expected three draws, got %d", g.DrawRound)
+	}
+	draws := 0
+	for _, e := range g.Events {
+		if e.Type == EventDraw {
+			draws++
+		}
+	}
+	if draws != 6 {
+		t.Errorf("expected each seat to draw three times, got %d draws", draws)
+	}
+}
+
+func TestDiscardedResetsEachDraw(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.SetRules(Rules{Draws: 2})
+	g.CollectAnte(10)
+	act(t, g, "check", 0)
+	act(t, g, "check", 0)
+	g.SeatDiscard(g.TurnIndex, []int{0, 1})
+	g.SeatDiscard(g.TurnIndex, nil)
+	act(t, g, "check", 0)
+	act(t, g, "check", 0)
+
+	if g.GamePhase != PhaseDiscard || g.DrawRound != 2 {
+		t.Fatalf("expected the second draw, got %s draw %d", g.GamePhase, g.DrawRound)
+	}
+	for seat, rs := range g.RoundStates {
+		if rs.Discarded || rs.Drew != 0 {
+			t.Errorf("seat %d: expected a fresh draw, got discarded %v drew %d", seat, rs.Discarded, rs.Drew)
+		}
+	}
+	if ok, msg := g.SeatDiscard(g.TurnIndex, []int{2}); !ok {
+		t.Errorf("expected to draw again: %s", msg)
+	}
+}
+
+func TestMuckReshuffledWhenDeckRunsOut(t *testing.T) {
+	g := newHumanTable(t, MaxSeats)
+	g.SetRules(tripleDraw)
+	g.CollectAnte(10)
+	playDraws(t, g, []int{0, 1, 2, 3, 4})
+
+	reshuffles := 0
+	for _, e := range g.Events {
+		if e.Type == EventReshuffle {
+			reshuffles++
+		}
+	}
+	if reshuffles == 0 {
+		t.Fatalf("expected six seats drawing five cards three times to exhaust the deck")
+	}
+	seen := map[Card]bool{}
+	for seat, rs := range g.RoundStates {
+		if len(rs.Hand) != 5 {
+			t.Errorf("seat %d: expected five cards, got %v", seat, rs.Hand)
+		}
+		for _, c := range rs.Hand {
+			if seen[c] {
+				t.Errorf("%v is in two hands at once", c)
+			}
+			seen[c] = true
+		}
+	}
+}
+
+func TestReplayWithDraws(t *testing.T) {
+	for seed := uint64(1); seed <= 5; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		g.SetRules(tripleDraw)
+		playScripted(g)
+		if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+			t.Fatalf("seed %d: replay failed: %v", seed, err)
+		}
+	}
+}
+
+func TestSetRulesDraws(t *testing.T) {
+	g := NewGame("p")
+	if err := g.SetRules(Rules{Draws: MaxDraws + 1}); err == nil {
+		t.Errorf("expected more than %d draws to be refused", MaxDraws)
+	}
+	if err := g.SetRules(Rules{Ranking: "tentacles"}); err == nil {
+		t.Errorf("expected an unknown ranking to be refused")
+	}
+	if name := tripleDraw.Name(); name != "Triple Draw Deuce-to-Seven Lowball" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
@@ -1,8 +1,10 @@
 package game
 
 import (
+	"cmp"
 	"fmt"
-	"sort"
+	"math/bits"
+	"slices"
 	"strconv"
 )
 
@@ -20,6 +22,7 @@ const (
 	FourOfAKind
 	StraightFlush
 	RoyalFlush
+	FiveOfAKind // Only possible with wild cards
 )
 
 // HandValue represents the value of a poker hand for comparison
@@ -49,155 +52,358 @@ func CardValue(rank Rank) int {
 	}
 }
 
-// EvaluateHand evaluates a poker hand and returns its HandValue
-func EvaluateHand(hand []Card) HandValue {
+// HandStrength is a five-card hand's place among the 7,462 distinct poker
+// hands, and above them the 13 fives of a kind that only wild cards make:
+// higher beats lower and equal strengths tie. Zero is not a hand.
+type HandStrength int
+
+// Strength scores a five-card hand by table lookup, without allocating.
+// Flushes and hands of five different ranks are looked up by which ranks
+// are present; hands with a pair or better by the product of a prime per
+// rank, which is the same for every ordering of the same ranks. Anything
+// that is not five recognisable cards has strength 0.
+func Strength(hand []Card) HandStrength {
 	if len(hand) != 5 {
-		return HandValue{Rank: HighCard}
+		return 0
+	}
+	var ranks uint16
+	product := uint32(1)
+	flush := true
+	for _, c := range hand {
+		r := rankIndex(c.Rank)
+		if r < 0 {
+			return 0
+		}
+		ranks |= 1 << r
+		product *= rankPrimes[r]
+		flush = flush && c.Suit == hand[0].Suit
 	}
 
-	// Convert cards to values and suits
-	values := make([]int, len(hand))
-	suits := make([]string, len(hand))
-	for i, card := range hand {
-		values[i] = CardValue(card.Rank)
-		suits[i] = string(card.Suit)
+	if bits.OnesCount16(ranks) == 5 {
+		if flush {
+			return flushStrength[ranks]
+		}
+		return uniqueStrength[ranks]
+	}
+	if i, ok := slices.BinarySearch(pairedProducts, product); ok {
+		return pairedStrength[i]
 	}
+	return 0
+}
 
-	// Sort values for easier analysis
-	sort.Ints(values)
+// Rank returns the category of a hand of this strength.
+func (s HandStrength) Rank() HandRank {
+	return strengthValues[s].Rank
+}
 
-	// Count occurrences of each value
-	counts := make(map[int]int)
-	for _, val := range values {
-		counts[val]++
+// Value returns the HandValue of a hand of this strength.
+func (s HandStrength) Value() HandValue {
+	v := strengthValues[s]
+	v.Kickers = slices.Clone(v.Kickers)
+	return v
+}
+
+// score is ScoreHand of a hand of this strength.
+func (s HandStrength) score() float64 {
+	return strengthScores[s]
+}
+
+// BestFive finds the strongest five cards among cards, as in stud and
+// hold'em where a player has more than five to choose from. It returns
+// their strength and their indices in cards, in order. With exactly five
+// cards it is Strength; with fewer there is no hand and it returns 0, nil.
+func BestFive(cards []Card) (HandStrength, []int) {
+	return bestFive(cards, Strength)
+}
+
+// bestFive is BestFive scoring each five cards with strength, high or low.
+func bestFive[S ~int](cards []Card, strength func([]Card) S) (S, []int) {
+	if len(cards) < 5 {
+		return 0, nil
+	}
+	if len(cards) == 5 {
+		return strength(cards), []int{0, 1, 2, 3, 4}
 	}
 
-	// Check for flush
-	isFlush := true
-	for i := 1; i < len(suits); i++ {
-		if suits[i] != suits[0] {
-			isFlush = false
-			break
-		}
+	var best S
+	var bestIdx, idx [5]int
+	hand := make(Hand, 5)
+	for i := range idx {
+		idx[i] = i
 	}
+	for {
+		for i, c := range idx {
+			hand[i] = cards[c]
+		}
+		if s := strength(hand); s > best {
+			best, bestIdx = s, idx
+		}
 
-	// Check for straight
-	isStraight := true
-	for i := 1; i < len(values); i++ {
-		if values[i] != values[i-1]+1 {
-			isStraight = false
+		// Next combination in lexicographic order
+		i := 4
+		for i >= 0 && idx[i] == len(cards)-5+i {
+			i--
+		}
+		if i < 0 {
 			break
 		}
+		idx[i]++
+		for j := i + 1; j < 5; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+	if best == 0 {
+		return 0, nil
 	}
+	return best, bestIdx[:]
+}
 
-	// Special case: A-2-3-4-5 straight (wheel)
-	if !isStraight && len(values) == 5 {
-		if values[0] == 2 && values[1] == 3 && values[2] == 4 && values[3] == 5 && values[4] == 14 {
-			isStraight = true
-			values = []int{1, 2, 3, 4, 5} // Treat ace as 1 for wheel
-		}
+// bestStrength is the strength of the best five cards among cards.
+func bestStrength(cards []Card) HandStrength {
+	if len(cards) == 5 {
+		return Strength(cards)
+	}
+	s, _ := BestFive(cards)
+	return s
+}
+
+// EvaluateHand evaluates a poker hand and returns its HandValue. Given more
+// than five cards it values the best five of them. Given fewer, as with
+// hole cards before the flop, it values only the pairs and sets among them.
+func EvaluateHand(hand []Card) HandValue {
+	if len(hand) < 5 {
+		return partialValue(hand)
 	}
+	return bestStrength(hand).Value()
+}
 
-	// Determine hand rank
-	var countGroups []int
-	for _, count := range counts {
-		countGroups = append(countGroups, count)
+// partialValue values fewer than five cards by their sets of a kind alone,
+// since they are too few to make a straight or a flush.
+func partialValue(hand []Card) HandValue {
+	counts := make(map[int]int)
+	for _, c := range hand {
+		counts[CardValue(c.Rank)]++
+	}
+	values := make([]int, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
 	}
-	sort.Sort(sort.Reverse(sort.IntSlice(countGroups)))
+	// Biggest sets first, then highest values
+	slices.SortFunc(values, func(a, b int) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(b, a)
+	})
 
-	// Royal flush check
-	if isFlush && isStraight && values[4] == 14 && values[0] == 10 {
-		return HandValue{Rank: RoyalFlush, Primary: 14}
+	var hv HandValue
+	if len(values) == 0 {
+		return hv
 	}
+	switch top := counts[values[0]]; {
+	case top == 4:
+		hv.Rank = FourOfAKind
+	case top == 3:
+		hv.Rank = ThreeOfAKind
+	case top == 2 && len(values) > 1 && counts[values[1]] == 2:
+		hv.Rank = TwoPair
+	case top == 2:
+		hv.Rank = OnePair
+	default:
+		hv.Kickers = values
+		return hv
+	}
+	hv.Primary = values[0]
+	rest := values[1:]
+	if hv.Rank == TwoPair {
+		hv.Secondary, rest = values[1], values[2:]
+	}
+	hv.Kickers = slices.Clone(rest)
+	return hv
+}
 
-	// Straight flush
-	if isFlush && isStraight {
-		return HandValue{Rank: StraightFlush, Primary: values[4]}
+// compareValues orders two HandValues: by rank, then the ranks of the
+// sets, then the kickers.
+func compareValues(a, b HandValue) int {
+	if c := cmp.Compare(a.Rank, b.Rank); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Primary, b.Primary); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Secondary, b.Secondary); c != 0 {
+		return c
 	}
+	return slices.Compare(a.Kickers, b.Kickers)
+}
 
-	// Four of a kind
-	if len(countGroups) == 2 && countGroups[0] == 4 {
-		var fourKind, kicker int
-		for val, count := range counts {
-			if count == 4 {
-				fourKind = val
-			} else {
-				kicker = val
-			}
-		}
-		return HandValue{Rank: FourOfAKind, Primary: fourKind, Secondary: kicker}
+// rankIndex numbers ranks from 0 (deuce) to 12 (ace), or -1 if rank is not
+// one.
+func rankIndex(rank Rank) int {
+	// Dispatch on the first byte; a full string switch is a hot spot
+	if rank == "" {
+		return -1
 	}
+	switch c := rank[0]; {
+	case len(rank) == 1 && c >= '2' && c <= '9':
+		return int(c - '2')
+	case c == '1' && rank == "10":
+		return 8
+	case c == 'j' && rank == "jack", rank == "J":
+		return 9
+	case c == 'q' && rank == "queen", rank == "Q":
+		return 10
+	case c == 'k' && rank == "king", rank == "K":
+		return 11
+	case c == 'a' && rank == "ace", rank == "A":
+		return 12
+	}
+	return -1
+}
 
-	// Full house
-	if len(countGroups) == 2 && countGroups[0] == 3 && countGroups[1] == 2 {
-		var threeKind, pair int
-		for val, count := range counts {
-			if count == 3 {
-				threeKind = val
-			} else {
-				pair = val
+var rankPrimes = [13]uint32{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+// Lookup tables, built once at startup by buildStrengthTables.
+var (
+	flushStrength  [1 << 13]HandStrength // By ranks present, for flushes
+	uniqueStrength [1 << 13]HandStrength // By ranks present, for five different ranks
+	pairedProducts []uint32              // Sorted rank prime products of paired hands...
+	pairedStrength []HandStrength        // ... and their strengths
+	fiveStrength   [13]HandStrength      // Five of a kind by value, deuces first; wild cards only
+	strengthValues []HandValue           // HandValue of each strength
+	strengthScores []float64             // ScoreHand of each strength
+)
+
+func init() {
+	buildStrengthTables()
+}
+
+// buildStrengthTables lists every distinct hand from weakest to strongest,
+// numbering them as it goes.
+func buildStrengthTables() {
+	strengthValues = []HandValue{{Rank: HighCard}} // Strength 0, not a hand
+	add := func(v HandValue) HandStrength {
+		strengthValues = append(strengthValues, v)
+		return HandStrength(len(strengthValues) - 1)
+	}
+	type paired struct {
+		product  uint32
+		strength HandStrength
+	}
+	var pairs []paired
+	addPaired := func(v HandValue, values ...int) {
+		product := uint32(1)
+		for _, val := range values {
+			product *= rankPrimes[val-2]
+		}
+		pairs = append(pairs, paired{product, add(v)})
+	}
+	mask := func(values []int) uint16 {
+		var m uint16
+		for _, val := range values {
+			m |= 1 << (val - 2)
+		}
+		return m
+	}
+	without := func(except ...int) []int {
+		var values []int
+		for val := 2; val <= 14; val++ {
+			if !slices.Contains(except, val) {
+				values = append(values, val)
 			}
 		}
-		return HandValue{Rank: FullHouse, Primary: threeKind, Secondary: pair}
+		return values
 	}
 
-	// Flush
-	if isFlush {
-		return HandValue{Rank: Flush, Kickers: reverseSlice(values)}
+	var plain, straights [][]int
+	for _, set := range rankSets(5, without()) {
+		if set[0]-set[4] == 4 || slices.Equal(set, []int{14, 5, 4, 3, 2}) {
+			straights = append(straights, set)
+		} else {
+			plain = append(plain, set)
+		}
 	}
-
-	// Straight
-	if isStraight {
-		return HandValue{Rank: Straight, Primary: values[4]}
+	// The wheel plays its ace low, so it is the lowest straight
+	straightHigh := func(set []int) int {
+		if set[0] == 14 && set[1] == 5 {
+			return 5
+		}
+		return set[0]
 	}
+	slices.SortFunc(straights, func(a, b []int) int { return cmp.Compare(straightHigh(a), straightHigh(b)) })
 
-	// Three of a kind
-	if len(countGroups) == 3 && countGroups[0] == 3 {
-		var threeKind int
-		var kickers []int
-		for val, count := range counts {
-			if count == 3 {
-				threeKind = val
-			} else {
-				kickers = append(kickers, val)
-			}
+	for _, set := range plain {
+		uniqueStrength[mask(set)] = add(HandValue{Rank: HighCard, Kickers: set})
+	}
+	for pair := 2; pair <= 14; pair++ {
+		for _, kickers := range rankSets(3, without(pair)) {
+			addPaired(HandValue{Rank: OnePair, Primary: pair, Kickers: kickers}, append([]int{pair, pair}, kickers...)...)
 		}
-		sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
-		return HandValue{Rank: ThreeOfAKind, Primary: threeKind, Kickers: kickers}
-	}
-
-	// Two pair
-	if len(countGroups) == 3 && countGroups[0] == 2 && countGroups[1] == 2 {
-		var pairs []int
-		var kicker int
-		for val, count := range counts {
-			if count == 2 {
-				pairs = append(pairs, val)
-			} else {
-				kicker = val
+	}
+	for high := 3; high <= 14; high++ {
+		for low := 2; low < high; low++ {
+			for _, kicker := range without(high, low) {
+				addPaired(HandValue{Rank: TwoPair, Primary: high, Secondary: low, Kickers: []int{kicker}}, high, high, low, low, kicker)
 			}
 		}
-		sort.Sort(sort.Reverse(sort.IntSlice(pairs)))
-		return HandValue{Rank: TwoPair, Primary: pairs[0], Secondary: pairs[1], Kickers: []int{kicker}}
-	}
-
-	// One pair
-	if len(countGroups) == 4 && countGroups[0] == 2 {
-		var pair int
-		var kickers []int
-		for val, count := range counts {
-			if count == 2 {
-				pair = val
-			} else {
-				kickers = append(kickers, val)
-			}
+	}
+	for trips := 2; trips <= 14; trips++ {
+		for _, kickers := range rankSets(2, without(trips)) {
+			addPaired(HandValue{Rank: ThreeOfAKind, Primary: trips, Kickers: kickers}, append([]int{trips, trips, trips}, kickers...)...)
 		}
-		sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
-		return HandValue{Rank: OnePair, Primary: pair, Kickers: kickers}
 	}
+	for _, set := range straights {
+		uniqueStrength[mask(set)] = add(HandValue{Rank: Straight, Primary: straightHigh(set)})
+	}
+	for _, set := range plain {
+		flushStrength[mask(set)] = add(HandValue{Rank: Flush, Kickers: set})
+	}
+	for trips := 2; trips <= 14; trips++ {
+		for _, pair := range without(trips) {
+			addPaired(HandValue{Rank: FullHouse, Primary: trips, Secondary: pair}, trips, trips, trips, pair, pair)
+		}
+	}
+	for quads := 2; quads <= 14; quads++ {
+		for _, kicker := range without(quads) {
+			addPaired(HandValue{Rank: FourOfAKind, Primary: quads, Secondary: kicker}, quads, quads, quads, quads, kicker)
+		}
+	}
+	for _, set := range straights {
+		rank := StraightFlush
+		if set[0] == 14 && set[4] == 10 {
+			rank = RoyalFlush
+		}
+		flushStrength[mask(set)] = add(HandValue{Rank: rank, Primary: straightHigh(set)})
+	}
+	for v := 2; v <= 14; v++ {
+		fiveStrength[v-2] = add(HandValue{Rank: FiveOfAKind, Primary: v})
+	}
+
+	slices.SortFunc(pairs, func(a, b paired) int { return cmp.Compare(a.product, b.product) })
+	pairedProducts = make([]uint32, len(pairs))
+	pairedStrength = make([]HandStrength, len(pairs))
+	for i, p := range pairs {
+		pairedProducts[i], pairedStrength[i] = p.product, p.strength
+	}
+	strengthScores = make([]float64, len(strengthValues))
+	for i, v := range strengthValues {
+		strengthScores[i] = ScoreHand(v)
+	}
+}
 
-	// High card
-	return HandValue{Rank: HighCard, Kickers: reverseSlice(values)}
+// rankSets returns every set of k different card values from values (in
+// ascending order), each listed high to low, weakest set first.
+func rankSets(k int, values []int) [][]int {
+	if k == 0 {
+		return [][]int{nil}
+	}
+	var sets [][]int
+	for i := k - 1; i < len(values); i++ {
+		for _, rest := range rankSets(k-1, values[:i]) {
+			sets = append(sets, append([]int{values[i]}, rest...))
+		}
+	}
+	return sets
 }
 
 // ComparisonResult represents the result of a hand comparison
@@ -209,54 +415,24 @@ const (
 	ResultHand2Wins ComparisonResult = 1
 )
 
-// CompareHands compares two poker hands and returns a ComparisonResult
+// CompareHands compares two poker hands and returns a ComparisonResult.
+// Hands of more than five cards play their best five; a hand without five
+// valid cards loses to any hand that has them.
 func CompareHands(hand1, hand2 []Card) ComparisonResult {
-	val1 := EvaluateHand(hand1)
-	val2 := EvaluateHand(hand2)
-
-	// Compare hand ranks first
-	if val1.Rank > val2.Rank {
-		return ResultHand1Wins
-	} else if val1.Rank < val2.Rank {
-		return ResultHand2Wins
-	}
-
-	// Same rank, compare primary values
-	if val1.Primary > val2.Primary {
-		return ResultHand1Wins
-	} else if val1.Primary < val2.Primary {
-		return ResultHand2Wins
-	}
+	return compareStrengths(bestStrength(hand1), bestStrength(hand2))
+}
 
-	// Same primary, compare secondary values
-	if val1.Secondary > val2.Secondary {
+// compareStrengths compares the strengths of two hands.
+func compareStrengths(s1, s2 HandStrength) ComparisonResult {
+	switch {
+	case s1 > s2:
 		return ResultHand1Wins
-	} else if val1.Secondary < val2.Secondary {
+	case s1 < s2:
 		return ResultHand2Wins
 	}
-
-	// Compare kickers
-	for i := 0; i < len(val1.Kickers) && i < len(val2.Kickers); i++ {
-		if val1.Kickers[i] > val2.Kickers[i] {
-			return ResultHand1Wins
-		} else if val1.Kickers[i] < val2.Kickers[i] {
-			return ResultHand2Wins
-		}
-	}
-
-	// If all comparisons are equal, it's a tie
 	return ResultTie
 }
 
-// Helper function to reverse a slice
-func reverseSlice(s []int) []int {
-	result := make([]int, len(s))
-	for i, v := range s {
-		result[len(s)-1-i] = v
-	}
-	return result
-}
-
 // GetHandName returns a human-readable name for the hand rank
 func GetHandName(rank HandRank) string {
 	switch rank {
@@ -280,6 +456,8 @@ func GetHandName(rank HandRank) string {
 		return "Straight Flush"
 	case RoyalFlush:
 		return "Royal Flush"
+	case FiveOfAKind:
+		return "Five of a Kind"
 	default:
 		return "Unknown"
 	}
@@ -305,50 +483,77 @@ type HandComparisonResult struct {
 	PlayerHandName   string
 	OpponentHandName string
 	Message          string
+	PlayerBestFive   []int // Indices of the cards each hand plays, for highlighting
+	OpponentBestFive []int
 }
 
 // CompareHandsForDisplay compares two poker hands and returns detailed result information
 // for display to the user
 func CompareHandsForDisplay(playerHand, opponentHand []Card) HandComparisonResult {
-	playerValue := EvaluateHand(playerHand)
-	opponentValue := EvaluateHand(opponentHand)
+	return Rules{}.CompareForDisplay(playerHand, opponentHand)
+}
 
-	result := CompareHands(playerHand, opponentHand)
+// CompareForDisplay is CompareHandsForDisplay under these rules.
+func (r Rules) CompareForDisplay(playerHand, opponentHand []Card) HandComparisonResult {
+	return r.CompareForDisplayAgainst(playerHand, opponentHand, aiNames[0])
+}
+
+// CompareForDisplayAgainst is CompareForDisplay against the named
+// opponent, as one of the lesser horrors.
+func (r Rules) CompareForDisplayAgainst(playerHand, opponentHand []Card, opponent string) HandComparisonResult {
+	if r.ranking() == RankingHiLo {
+		return r.compareHiLoForDisplay(playerHand, opponentHand, opponent)
+	}
+	playerStrength, playerBest := r.BestFive(playerHand)
+	opponentStrength, opponentBest := r.BestFive(opponentHand)
+	playerValue := playerStrength.Value()
+	opponentValue := opponentStrength.Value()
+
+	result := compareStrengths(playerStrength, opponentStrength)
 
 	playerHandName := GetHandName(playerValue.Rank)
 	opponentHandName := GetHandName(opponentValue.Rank)
 
+	// Lows are named by their top cards, never as lesser pairs
+	sameRank := playerValue.Rank == opponentValue.Rank
+	if r.lowball() {
+		result = r.CompareLow(playerHand, opponentHand)
+		playerHandName, opponentHandName = r.HandName(playerHand), r.HandName(opponentHand)
+		playerBest, opponentBest = r.used(playerHand), r.used(opponentHand)
+		sameRank = false
+	}
+
 	var winner string
 	var message string
 
 	switch result {
 	case ResultHand1Wins:
 		winner = "player"
-		if playerValue.Rank == opponentValue.Rank {
+		if sameRank {
 			switch playerValue.Rank {
 			case OnePair:
-				message = fmt.Sprintf("You win with %s! The Ancient One had a lesser pair.", playerHandName)
+				message = fmt.Sprintf("You win with %s! %s had a lesser pair.", playerHandName, opponent)
 			case TwoPair:
-				message = fmt.Sprintf("You win with %s! The Ancient One had a lesser two pair.", playerHandName)
+				message = fmt.Sprintf("You win with %s! %s had a lesser two pair.", playerHandName, opponent)
 			default:
-				message = fmt.Sprintf("You win with %s! The Ancient One had %s.", playerHandName, opponentHandName)
+				message = fmt.Sprintf("You win with %s! %s had %s.", playerHandName, opponent, opponentHandName)
 			}
 		} else {
-			message = fmt.Sprintf("You win with %s! The Ancient One had %s.", playerHandName, opponentHandName)
+			message = fmt.Sprintf("You win with %s! %s had %s.", playerHandName, opponent, opponentHandName)
 		}
 	case ResultHand2Wins:
 		winner = "opponent"
-		if playerValue.Rank == opponentValue.Rank {
+		if sameRank {
 			switch playerValue.Rank {
 			case OnePair:
-				message = fmt.Sprintf("The Ancient One wins with %s! You had a lesser pair.", opponentHandName)
+				message = fmt.Sprintf("%s wins with %s! You had a lesser pair.", opponent, opponentHandName)
 			case TwoPair:
-				message = fmt.Sprintf("The Ancient One wins with %s! You had a lesser two pair.", opponentHandName)
+				message = fmt.Sprintf("%s wins with %s! You had a lesser two pair.", opponent, opponentHandName)
 			default:
-				message = fmt.Sprintf("The Ancient One wins with %s! You had %s.", opponentHandName, playerHandName)
+				message = fmt.Sprintf("%s wins with %s! You had %s.", opponent, opponentHandName, playerHandName)
 			}
 		} else {
-			message = fmt.Sprintf("The Ancient One wins with %s! You had %s.", opponentHandName, playerHandName)
+			message = fmt.Sprintf("%s wins with %s! You had %s.", opponent, opponentHandName, playerHandName)
 		}
 	default: // ResultTie
 		winner = "tie"
@@ -360,5 +565,7 @@ func CompareHandsForDisplay(playerHand, opponentHand []Card) HandComparisonResul
 		PlayerHandName:   playerHandName,
 		OpponentHandName: opponentHandName,
 		Message:          message,
+		PlayerBestFive:   playerBest,
+		OpponentBestFive: opponentBest,
 	}
 }
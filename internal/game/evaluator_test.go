@@ -1,6 +1,10 @@
 package game
 
-import "testing"
+import (
+	"slices"
+	"sort"
+	"testing"
+)
 
 func TestCompareHandsTie(t *testing.T) {
 	// Both hands have the same high card
@@ -422,3 +426,399 @@ func TestInvalidHandSize(t *testing.T) {
 		t.Errorf("Expected HighCard for invalid hand size")
 	}
 }
+
+// allHands calls f with every five-card hand, reusing one slice.
+func allHands(f func(hand Hand)) {
+	deck := orderedDeck()
+	hand := make(Hand, 5)
+	for a := 0; a < len(deck); a++ {
+		for b := a + 1; b < len(deck); b++ {
+			for c := b + 1; c < len(deck); c++ {
+				for d := c + 1; d < len(deck); d++ {
+					for e := d + 1; e < len(deck); e++ {
+						hand[0], hand[1], hand[2], hand[3], hand[4] = deck[a], deck[b], deck[c], deck[d], deck[e]
+						f(hand)
+					}
+				}
+			}
+		}
+	}
+}
+
+func sameValue(a, b HandValue) bool {
+	return a.Rank == b.Rank && a.Primary == b.Primary && a.Secondary == b.Secondary && slices.Equal(a.Kickers, b.Kickers)
+}
+
+func TestStrengthMatchesReferenceForEveryHand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("evaluates all 2,598,960 hands")
+	}
+
+	hands := 0
+	seen := make(map[HandStrength]bool)
+	allHands(func(hand Hand) {
+		hands++
+		s := Strength(hand)
+		seen[s] = true
+		if want := referenceEvaluate(hand); !sameValue(s.Value(), want) {
+			t.Fatalf("%v: strength %d is %+v, reference says %+v", hand, s, s.Value(), want)
+		}
+	})
+
+	if hands != 2598960 {
+		t.Errorf("expected 2598960 hands, got %d", hands)
+	}
+	if len(seen) != 7462 || seen[0] {
+		t.Errorf("expected 7462 distinct strengths from 1, got %d", len(seen))
+	}
+}
+
+func TestStrengthsAreOrderedLikeReference(t *testing.T) {
+	// Every strength's value must beat the one below it, so comparing
+	// strengths agrees with comparing values
+	for s := HandStrength(2); s < HandStrength(len(strengthValues)); s++ {
+		if compareValues((s-1).Value(), s.Value()) >= 0 {
+			t.Fatalf("strength %d (%+v) does not beat %d (%+v)", s, s.Value(), s-1, (s - 1).Value())
+		}
+	}
+}
+
+func TestStrengthOfInvalidHands(t *testing.T) {
+	hand := Hand{{Hearts, "ace"}, {Spades, "ace"}, {Clubs, "5"}, {Diamonds, "2"}, {Hearts, "3"}}
+	bad := append(Hand(nil), hand...)
+	bad[0].Rank = "eldritch"
+
+	if Strength(hand[:4]) != 0 || Strength(bad) != 0 {
+		t.Errorf("short hands and unknown ranks should have strength 0")
+	}
+	if CompareHands(bad, hand) != ResultHand2Wins {
+		t.Errorf("an invalid hand should lose to a valid one")
+	}
+}
+
+func TestBestFiveOfSeven(t *testing.T) {
+	// A pair of kings on the board, but five hearts make a flush
+	cards := []Card{
+		{Rank: "king", Suit: "spades"},
+		{Rank: "2", Suit: "hearts"},
+		{Rank: "king", Suit: "hearts"},
+		{Rank: "9", Suit: "hearts"},
+		{Rank: "4", Suit: "clubs"},
+		{Rank: "jack", Suit: "hearts"},
+		{Rank: "6", Suit: "hearts"},
+	}
+	s, used := BestFive(cards)
+	if s.Rank() != Flush {
+		t.Fatalf("expected a flush, got %s", GetHandName(s.Rank()))
+	}
+	if want := []int{1, 2, 3, 5, 6}; !slices.Equal(used, want) {
+		t.Errorf("expected the hearts at %v, got %v", want, used)
+	}
+	if EvaluateHand(cards).Rank != Flush {
+		t.Errorf("EvaluateHand should value seven cards by their best five")
+	}
+}
+
+func TestBestFiveOfSix(t *testing.T) {
+	// The wheel plus a six: the six-high straight plays
+	cards := []Card{
+		{Rank: "ace", Suit: "spades"},
+		{Rank: "2", Suit: "hearts"},
+		{Rank: "3", Suit: "clubs"},
+		{Rank: "4", Suit: "hearts"},
+		{Rank: "5", Suit: "diamonds"},
+		{Rank: "6", Suit: "hearts"},
+	}
+	s, used := BestFive(cards)
+	if v := s.Value(); v.Rank != Straight || v.Primary != 6 {
+		t.Errorf("expected a six-high straight, got %+v", v)
+	}
+	if want := []int{1, 2, 3, 4, 5}; !slices.Equal(used, want) {
+		t.Errorf("expected %v, got %v", want, used)
+	}
+}
+
+func TestBestFiveMatchesReference(t *testing.T) {
+	deck := orderedDeck()
+	for i := 0; i < 2000; i++ {
+		ShuffleDeck(&deck, NewRNG(uint64(i)))
+		cards := deck[:7]
+
+		// The best five by the original evaluator, trying every choice
+		var want HandValue
+		for _, skip := range rankSets(2, []int{0, 1, 2, 3, 4, 5, 6}) {
+			var hand Hand
+			for j, c := range cards {
+				if j != skip[0] && j != skip[1] {
+					hand = append(hand, c)
+				}
+			}
+			if v := referenceEvaluate(hand); compareValues(v, want) > 0 {
+				want = v
+			}
+		}
+
+		s, used := BestFive(cards)
+		if !sameValue(s.Value(), want) {
+			t.Fatalf("%v: best five is %+v, expected %+v", cards, s.Value(), want)
+		}
+		var hand Hand
+		for _, j := range used {
+			hand = append(hand, cards[j])
+		}
+		if Strength(hand) != s {
+			t.Fatalf("%v: cards %v do not make the reported hand", cards, used)
+		}
+	}
+}
+
+func TestCompareSevenCardHands(t *testing.T) {
+	board := []Card{
+		{Rank: "queen", Suit: "spades"},
+		{Rank: "queen", Suit: "hearts"},
+		{Rank: "7", Suit: "clubs"},
+		{Rank: "3", Suit: "diamonds"},
+		{Rank: "2", Suit: "spades"},
+	}
+	trips := append([]Card{{Rank: "queen", Suit: "clubs"}, {Rank: "4", Suit: "hearts"}}, board...)
+	twoPair := append([]Card{{Rank: "7", Suit: "hearts"}, {Rank: "ace", Suit: "clubs"}}, board...)
+
+	if CompareHands(trips, twoPair) != ResultHand1Wins {
+		t.Errorf("expected trip queens to beat queens and sevens")
+	}
+	result := CompareHandsForDisplay(trips, twoPair)
+	if result.PlayerHandName != "Three of a Kind" || result.OpponentHandName != "Two Pair" {
+		t.Errorf("unexpected hand names %q and %q", result.PlayerHandName, result.OpponentHandName)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(result.PlayerBestFive, want) {
+		t.Errorf("expected trips to play %v, got %v", want, result.PlayerBestFive)
+	}
+}
+
+var benchHands = func() []Hand {
+	deck := NewDeck(NewRNG(1))
+	var hands []Hand
+	for i := 0; i < 1000; i++ {
+		ShuffleDeck(&deck, NewRNG(uint64(i)))
+		hands = append(hands, append(Hand(nil), deck[:5]...))
+	}
+	return hands
+}()
+
+func BenchmarkStrength(b *testing.B) {
+	for i := 0; b.Loop(); i++ {
+		Strength(benchHands[i%len(benchHands)])
+	}
+}
+
+func BenchmarkEvaluateHand(b *testing.B) {
+	for i := 0; b.Loop(); i++ {
+		EvaluateHand(benchHands[i%len(benchHands)])
+	}
+}
+
+func BenchmarkReferenceEvaluate(b *testing.B) {
+	for i := 0; b.Loop(); i++ {
+		referenceEvaluate(benchHands[i%len(benchHands)])
+	}
+}
+
+func BenchmarkBestFiveOfSeven(b *testing.B) {
+	deck := orderedDeck()
+	ShuffleDeck(&deck, NewRNG(1))
+	for b.Loop() {
+		BestFive(deck[:7])
+	}
+}
+
+func BenchmarkCompareHands(b *testing.B) {
+	for i := 0; b.Loop(); i++ {
+		CompareHands(benchHands[i%len(benchHands)], benchHands[(i+1)%len(benchHands)])
+	}
+}
+
+func BenchmarkChooseDiscard(b *testing.B) {
+	for i := 0; b.Loop(); i++ {
+		DefaultAI.ChooseDiscard(benchHands[i%len(benchHands)], Rules{}, NewRNG(uint64(i)))
+	}
+}
+
+// referenceEvaluate is the original, straightforward evaluator that the
+// lookup tables must agree with.
+func referenceEvaluate(hand []Card) HandValue {
+	if len(hand) != 5 {
+		return HandValue{Rank: HighCard}
+	}
+
+	// Convert cards to values and suits
+	values := make([]int, len(hand))
+	suits := make([]string, len(hand))
+	for i, card := range hand {
+		values[i] = CardValue(card.Rank)
+		suits[i] = string(card.Suit)
+	}
+
+	// Sort values for easier analysis
+	sort.Ints(values)
+
+	// Count occurrences of each value
+	counts := make(map[int]int)
+	for _, val := range values {
+		counts[val]++
+	}
+
+	// Check for flush
+	isFlush := true
+	for i := 1; i < len(suits); i++ {
+		if suits[i] != suits[0] {
+			isFlush = false
+			break
+		}
+	}
+
+	// Check for straight
+	isStraight := true
+	for i := 1; i < len(values); i++ {
+		if values[i] != values[i-1]+1 {
+			isStraight = false
+			break
+		}
+	}
+
+	// Special case: A-2-3-4-5 straight (wheel)
+	if !isStraight && len(values) == 5 {
+		if values[0] == 2 && values[1] == 3 && values[2] == 4 && values[3] == 5 && values[4] == 14 {
+			isStraight = true
+			values = []int{1, 2, 3, 4, 5} // Treat ace as 1 for wheel
+		}
+	}
+
+	// Determine hand rank
+	var countGroups []int
+	for _, count := range counts {
+		countGroups = append(countGroups, count)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(countGroups)))
+
+	// Royal flush check
+	if isFlush && isStraight && values[4] == 14 && values[0] == 10 {
+		return HandValue{Rank: RoyalFlush, Primary: 14}
+	}
+
+	// Straight flush
+	if isFlush && isStraight {
+		return HandValue{Rank: StraightFlush, Primary: values[4]}
+	}
+
+	// Four of a kind
+	if len(countGroups) == 2 && countGroups[0] == 4 {
+		var fourKind, kicker int
+		for val, count := range counts {
+			if count == 4 {
+				fourKind = val
+			} else {
+				kicker = val
+			}
+		}
+		return HandValue{Rank: FourOfAKind, Primary: fourKind, Secondary: kicker}
+	}
+
+	// Full house
+	if len(countGroups) == 2 && countGroups[0] == 3 && countGroups[1] == 2 {
+		var threeKind, pair int
+		for val, count := range counts {
+			if count == 3 {
+				threeKind = val
+			} else {
+				pair = val
+			}
+		}
+		return HandValue{Rank: FullHouse, Primary: threeKind, Secondary: pair}
+	}
+
+	// Flush
+	if isFlush {
+		return HandValue{Rank: Flush, Kickers: reversed(values)}
+	}
+
+	// Straight
+	if isStraight {
+		return HandValue{Rank: Straight, Primary: values[4]}
+	}
+
+	// Three of a kind
+	if len(countGroups) == 3 && countGroups[0] == 3 {
+		var threeKind int
+		var kickers []int
+		for val, count := range counts {
+			if count == 3 {
+				threeKind = val
+			} else {
+				kickers = append(kickers, val)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
+		return HandValue{Rank: ThreeOfAKind, Primary: threeKind, Kickers: kickers}
+	}
+
+	// Two pair
+	if len(countGroups) == 3 && countGroups[0] == 2 && countGroups[1] == 2 {
+		var pairs []int
+		var kicker int
+		for val, count := range counts {
+			if count == 2 {
+				pairs = append(pairs, val)
+			} else {
+				kicker = val
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(pairs)))
+		return HandValue{Rank: TwoPair, Primary: pairs[0], Secondary: pairs[1], Kickers: []int{kicker}}
+	}
+
+	// One pair
+	if len(countGroups) == 4 && countGroups[0] == 2 {
+		var pair int
+		var kickers []int
+		for val, count := range counts {
+			if count == 2 {
+				pair = val
+			} else {
+				kickers = append(kickers, val)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
+		return HandValue{Rank: OnePair, Primary: pair, Kickers: kickers}
+	}
+
+	// High card
+	return HandValue{Rank: HighCard, Kickers: reversed(values)}
+}
+
+func reversed(s []int) []int {
+	r := slices.Clone(s)
+	slices.Reverse(r)
+	return r
+}
+
+func TestEvaluatePartialHands(t *testing.T) {
+	tests := []struct {
+		hand    Hand
+		rank    HandRank
+		primary int
+	}{
+		{Hand{{Hearts, "queen"}, {Spades, "queen"}}, OnePair, 12},
+		{Hand{{Hearts, "queen"}, {Spades, "7"}}, HighCard, 0},
+		{Hand{{Hearts, "4"}, {Spades, "4"}, {Clubs, "9"}, {Diamonds, "9"}}, TwoPair, 9},
+		{Hand{{Hearts, "4"}, {Spades, "4"}, {Clubs, "4"}}, ThreeOfAKind, 4},
+	}
+	for _, tt := range tests {
+		val := EvaluateHand(tt.hand)
+		if val.Rank != tt.rank || val.Primary != tt.primary {
+			t.Errorf("%v: got %s of %d, want %s of %d", tt.hand, GetHandName(val.Rank), val.Primary, GetHandName(tt.rank), tt.primary)
+		}
+	}
+	if val := EvaluateHand(Hand{{Hearts, "queen"}, {Spades, "7"}}); !slices.Equal(val.Kickers, []int{12, 7}) {
+		t.Errorf("expected high cards 12, 7, got %v", val.Kickers)
+	}
+}
@@ -2,8 +2,10 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,6 +43,19 @@ const (
 	PhaseComplete
 	PhaseGameOver
 	PhaseESP
+
+	// Hold'em betting rounds
+	PhasePreFlop
+	PhaseFlop
+	PhaseTurn
+	PhaseRiver
+
+	// Seven-card stud betting rounds
+	PhaseThirdStreet
+	PhaseFourthStreet
+	PhaseFifthStreet
+	PhaseSixthStreet
+	PhaseSeventhStreet
 )
 
 func (p GamePhase) String() string {
@@ -61,6 +76,24 @@ func (p GamePhase) String() string {
 		return "game_over"
 	case PhaseESP:
 		return "esp"
+	case PhasePreFlop:
+		return "preflop"
+	case PhaseFlop:
+		return "flop"
+	case PhaseTurn:
+		return "turn"
+	case PhaseRiver:
+		return "river"
+	case PhaseThirdStreet:
+		return "third_street"
+	case PhaseFourthStreet:
+		return "fourth_street"
+	case PhaseFifthStreet:
+		return "fifth_street"
+	case PhaseSixthStreet:
+		return "sixth_street"
+	case PhaseSeventhStreet:
+		return "seventh_street"
 	default:
 		return "unknown"
 	}
@@ -90,6 +123,24 @@ func (p *GamePhase) UnmarshalText(text []byte) error {
 		*p = PhaseGameOver
 	case "esp":
 		*p = PhaseESP
+	case "preflop":
+		*p = PhasePreFlop
+	case "flop":
+		*p = PhaseFlop
+	case "turn":
+		*p = PhaseTurn
+	case "river":
+		*p = PhaseRiver
+	case "third_street":
+		*p = PhaseThirdStreet
+	case "fourth_street":
+		*p = PhaseFourthStreet
+	case "fifth_street":
+		*p = PhaseFifthStreet
+	case "sixth_street":
+		*p = PhaseSixthStreet
+	case "seventh_street":
+		*p = PhaseSeventhStreet
 	default:
 		return fmt.Errorf("unknown game phase: %s", string(text))
 	}
@@ -101,12 +152,22 @@ type GameState struct {
 	Deck        Deck          `json:"deck"`
 	Players     []*Player     `json:"players"`
 	RoundStates []*RoundState `json:"round_states"` // Transient state per player (index-matched)
-	Pot         int           `json:"pot"`
-	TurnIndex   int           `json:"turn_index"` // Index of player whose turn it is
+	Pot         int           `json:"pot"`          // Total of all pots, for display
+	Pots        []Pot         `json:"pots"`         // Main pot first, then side pots
+	TurnIndex   int           `json:"turn_index"`   // Index of player whose turn it is
+	DealerIndex int           `json:"dealer_index"` // Seat holding the dealer button; action starts to its left
 	GamePhase   GamePhase     `json:"game_phase"`
+	Variant     Variant       `json:"variant,omitempty"` // Game being dealt; empty is five-card draw
+	Board       Hand          `json:"board"`             // Community cards in Hold'em
+	Rules       Rules         `json:"rules"`             // House rules, such as wild cards
+	DrawRound   int           `json:"draw_round"`        // Draws started so far this hand, in draw poker
+	Muck        Deck          `json:"muck,omitempty"`    // Discards and folded hands, reshuffled if the deck runs out
 
 	// Betting state
 	CurrentBet   int    `json:"current_bet"`   // Amount to call
+	Stake        int    `json:"stake"`         // The hand's ante or big blind, the smallest bet
+	LastRaise    int    `json:"last_raise"`    // Largest bet or raise this round, the least the next may be
+	Raises       int    `json:"raises"`        // Bets and raises this round, capped in fixed limit
 	LastAction   string `json:"last_action"`   // For UI display
 	ActivePlayer string `json:"active_player"` // Legacy string for UI ("player" or "opponent") - kept for compatibility/easier UI mapping for now
 	Winner       string `json:"winner"`        // Name of winner
@@ -114,6 +175,25 @@ type GameState struct {
 
 	// ESP Minigame state
 	ESP *ESPState `json:"esp,omitempty"`
+
+	// Hand history
+	HandNumber int     `json:"hand_number"` // Hands dealt so far in this game
+	Events     []Event `json:"events"`      // Append-only log of the current hand
+
+	// What the AI has learned about the humans it has played, by player ID
+	Stats map[string]*PlayerStats `json:"stats,omitempty"`
+
+	// Randomness. Each hand reseeds RNG from HandSeed(HandNumber), so a
+	// hand can be replayed from Seed and the recorded actions alone.
+	Seed    uint64 `json:"seed"`
+	RNG     *RNG   `json:"rng"`
+	stacked Deck   // Deck to deal the next hand from, see StackDeck
+}
+
+// Pot is the main pot or a side pot, with the seats that can win it.
+type Pot struct {
+	Amount   int   `json:"amount"`
+	Eligible []int `json:"eligible"`
 }
 
 // ESPState holds the state for the ESP training minigame
@@ -134,27 +214,67 @@ const (
 	AncientOneID = "a11ce101-0000-4000-8000-000000000666"
 )
 
+// Table size limits
+const (
+	MinSeats = 2
+	MaxSeats = 6
+)
+
+// aiNames are handed out to AI seats in order; the first is always the Ancient One.
+var aiNames = []string{
+	"The Ancient One",
+	"The Crawling Chaos",
+	"The Black Goat",
+	"The Dreamer in R'lyeh",
+	"The Key and the Gate",
+	"The Blind Idiot God",
+}
+
 type Player struct {
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	IsAI   bool   `json:"is_ai"`
-	Sanity int    `json:"sanity"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	IsAI        bool        `json:"is_ai"`
+	Sanity      int         `json:"sanity"`
+	Personality Personality `json:"personality,omitempty"` // AI strategy; empty plays DefaultAI
 }
+
+// phrase picks between second- and third-person wording for action messages,
+// since the local human is simply called "You".
+func (p *Player) phrase(you, other string) string {
+	if p.Name == "You" {
+		return you
+	}
+	return other
+}
+
 type RoundState struct {
-	Hand      Hand `json:"hand"`
-	Bet       int  `json:"bet"` // Amount put in this round
-	Folded    bool `json:"folded"`
-	Discarded bool `json:"discarded"` // Has performed discard
+	Hand       Hand   `json:"hand"`
+	FaceUp     []bool `json:"face_up,omitempty"` // In stud, which cards of Hand were dealt face up
+	Bet        int    `json:"bet"`               // Amount put in this round
+	Committed  int    `json:"committed"`         // Amount put in this hand, ante included
+	AllIn      bool   `json:"all_in"`            // Has no sanity left to bet
+	Folded     bool   `json:"folded"`
+	Discarded  bool   `json:"discarded"`   // Has drawn in the current draw round
+	Drew       int    `json:"drew"`        // Cards drawn in the latest draw, public once Discarded
+	Acted      bool   `json:"acted"`       // Has acted since the last bet or raise
+	SittingOut bool   `json:"sitting_out"` // Could not pay the ante; dealt out of this hand
 }
 
-func NewDeck() Deck {
+// NewDeck returns a full 52-card deck shuffled with rng.
+func NewDeck(rng *RNG) Deck {
+	d := orderedDeck()
+	ShuffleDeck(&d, rng)
+	return d
+}
+
+// orderedDeck returns a full 52-card deck in suit/rank order.
+func orderedDeck() Deck {
 	var d Deck
 	for _, s := range Suits {
 		for _, r := range Ranks {
 			d = append(d, Card{Suit: s, Rank: r})
 		}
 	}
-	rand.Shuffle(len(d), func(i, j int) { d[i], d[j] = d[j], d[i] })
 	return d
 }
 
@@ -167,118 +287,223 @@ func DealHand(deck *Deck, count int) Hand {
 	return hand
 }
 
-func ShuffleDeck(deck *Deck) {
-	rand.Shuffle(len(*deck), func(i, j int) { (*deck)[i], (*deck)[j] = (*deck)[j], (*deck)[i] })
+func ShuffleDeck(deck *Deck, rng *RNG) {
+	rng.Shuffle(len(*deck), func(i, j int) { (*deck)[i], (*deck)[j] = (*deck)[j], (*deck)[i] })
 }
 
-func ReplaceCards(deck *Deck, hand *Hand, indices []int) {
-	for _, i := range indices {
-		if i >= 0 && i < len(*hand) && len(*deck) > 0 {
-			(*hand)[i] = (*deck)[0]
-			*deck = (*deck)[1:]
-		}
-	}
-}
-
-func NewGame(playerID string) *GameState {
-	deck := NewDeck()
-
-	// Initialize Players (Identity)
+// NewHumanPlayer returns a human seat. An empty ID gets a fresh UUID.
+func NewHumanPlayer(playerID string) *Player {
 	if playerID == "" {
 		playerID = uuid.New().String()
 	}
-	human := &Player{
+	return &Player{
 		ID:     playerID,
 		Name:   "You",
 		IsAI:   false,
 		Sanity: 100,
 	}
-	ai := &Player{
-		ID:     AncientOneID,
-		Name:   "The Ancient One",
+}
+
+// NewAIPlayer returns the n-th AI seat for a table. The first is always the
+// Ancient One with its fixed ID; the rest are lesser horrors with fresh IDs.
+func NewAIPlayer(n int) *Player {
+	if n == 0 {
+		return &Player{
+			ID:     AncientOneID,
+			Name:   aiNames[0],
+			IsAI:   true,
+			Sanity: 100,
+		}
+	}
+	return &Player{
+		ID:     uuid.New().String(),
+		Name:   aiNames[n%len(aiNames)],
 		IsAI:   true,
 		Sanity: 100,
 	}
+}
+
+// NewGame starts the classic heads-up game: one human against the Ancient One.
+func NewGame(playerID string) *GameState {
+	g, _ := NewTable([]*Player{NewHumanPlayer(playerID), NewAIPlayer(0)})
+	return g
+}
+
+// NewTable seats the given players in order. The button starts on the last
+// seat so that seat 0 is first to act in the first hand.
+func NewTable(players []*Player) (*GameState, error) {
+	if len(players) < MinSeats || len(players) > MaxSeats {
+		return nil, fmt.Errorf("a table needs %d to %d seats, got %d", MinSeats, MaxSeats, len(players))
+	}
 
 	// Initialize Round States (Transient)
-	humanState := &RoundState{Hand: []Card{}}
-	aiState := &RoundState{Hand: []Card{}}
-
-	return &GameState{
-		Deck:         deck,
-		Players:      []*Player{human, ai},
-		RoundStates:  []*RoundState{humanState, aiState},
-		TurnIndex:    0, // Human starts
+	roundStates := make([]*RoundState, len(players))
+	for i := range roundStates {
+		roundStates[i] = &RoundState{Hand: []Card{}}
+	}
+
+	g := &GameState{
+		Players:      players,
+		RoundStates:  roundStates,
+		DealerIndex:  len(players) - 1,
 		GamePhase:    PhaseAnte,
 		Pot:          0,
 		CurrentBet:   0,
 		LastAction:   "Game started. Ante up!",
-		ActivePlayer: "player",
 		RevealOnFold: GlobalRevealOnFold,
 	}
+	g.SetSeed(NewSeed())
+	g.setTurn(g.nextSeat(g.DealerIndex, g.inHand))
+	return g, nil
 }
 
-// CollectAnte deducts ante and deals cards
+// CollectAnte deducts ante and deals cards. In Hold'em there is no ante:
+// amount is the big blind, and the small blind is half of it. In stud the
+// lowest card showing also brings it in for half the ante.
 func (g *GameState) CollectAnte(amount int) bool {
 	if g.GamePhase != PhaseAnte {
 		return false
 	}
-	// Check sanity
-	if g.Players[0].Sanity < amount {
+
+	// Humans who cannot cover the ante sit this hand out
+	var broke []*Player
+	seated := 0
+	for i, p := range g.Players {
+		rs := g.RoundStates[i]
+		rs.SittingOut = !p.IsAI && p.Sanity < amount
+		if rs.SittingOut {
+			broke = append(broke, p)
+		} else {
+			seated++
+		}
+	}
+	if seated < MinSeats || g.humansSeated() == 0 {
 		g.GamePhase = PhaseGameOver
-		g.LastAction = fmt.Sprintf("%s has insufficient sanity for ante. Game Over.", g.Players[0].Name)
+		if len(broke) > 0 {
+			g.LastAction = fmt.Sprintf("%s has insufficient sanity for ante. Game Over.", broke[0].Name)
+		} else {
+			g.LastAction = "Not enough players remain. Game Over."
+		}
 		return false
 	}
-	// AI Regeneration if bankrupt
-	if g.Players[1].Sanity < amount {
-		g.Players[1].Sanity = 100
-		g.LastAction = "The Ancient One regenerates its form!"
+
+	// A new hand begins with a fresh history, its own seed and a new deck
+	g.HandNumber++
+	g.Events = nil
+	for i, p := range g.Players {
+		e := Event{Type: EventSeat, Amount: p.Sanity, Detail: p.Name, AI: p.IsAI, Personality: p.Personality}
+		if s := g.Stats[p.ID]; s != nil {
+			// What the AI knew of them going in, so the hand can be replayed
+			known := *s
+			e.Stats = &known
+		}
+		g.record(i, e)
+	}
+	g.record(g.DealerIndex, Event{Type: EventButton})
+	rules := g.Rules
+	g.record(TableSeat, Event{Type: EventRules, Detail: string(g.variant()), Amount: amount, Rules: &rules})
+	g.RNG = NewRNG(g.HandSeed(g.HandNumber))
+	if g.stacked != nil {
+		g.Deck, g.stacked = g.stacked, nil
+	} else {
+		g.Deck = g.Rules.NewDeck(g.RNG)
 	}
 
-	// Deduct ante
-	for _, p := range g.Players {
-		p.Sanity -= amount
-		g.Pot += amount
+	// AI Regeneration if bankrupt
+	for i, p := range g.Players {
+		if p.IsAI && p.Sanity < amount {
+			p.Sanity = 100
+			g.record(i, Event{Type: EventRegenerate, Amount: p.Sanity})
+		}
 	}
-	g.LastAction = fmt.Sprintf("Ante paid: %d", amount)
 
-	// Deal cards
-	for i, rs := range g.RoundStates {
-		rs.Hand = DealHand(&g.Deck, 5)
+	// Deduct ante and deal cards; Hold'em deals two hole cards and no ante
+	g.Board = nil
+	g.Muck = nil
+	g.DrawRound = 0
+	g.Stake = amount
+	g.LastRaise, g.Raises = 0, 0
+	holdem := g.variant() == VariantHoldem
+	stud := g.variant() == VariantStud
+	for i := range g.Players {
+		rs := g.RoundStates[i]
+		rs.FaceUp = nil
 		rs.Bet = 0
-		rs.Folded = false
+		rs.Committed = 0
+		rs.AllIn = false
 		rs.Discarded = false
-		// Important: Ensure RoundStates aligns with Players
-		_ = i
+		rs.Drew = 0
+		rs.Acted = false
+		rs.Folded = rs.SittingOut
+		if rs.SittingOut {
+			rs.Hand = []Card{}
+			g.record(i, Event{Type: EventSitOut})
+			continue
+		}
+		if holdem {
+			rs.Hand = DealHand(&g.Deck, 2)
+			g.record(i, Event{Type: EventDeal, Count: len(rs.Hand)})
+			continue
+		}
+		g.pay(i, amount)
+		g.record(i, Event{Type: EventAnte, Amount: amount})
+		if stud {
+			g.dealStud(i, false, false, true)
+			continue
+		}
+		rs.Hand = DealHand(&g.Deck, 5)
+		g.record(i, Event{Type: EventDeal, Count: len(rs.Hand)})
+	}
+	if holdem {
+		g.postBlinds(amount)
+		return true
+	}
+	if stud {
+		g.bringIn(amount)
+		return true
 	}
+	g.LastAction = fmt.Sprintf("Ante paid: %d", amount)
 
-	// Transition to betting
-	g.GamePhase = PhasePreDrawBetting
+	// Transition to betting; action starts left of the button
+	g.enterPhase(PhasePreDrawBetting)
 	g.CurrentBet = 0
-
-	// Reset turn to 0 (Human)
-	g.TurnIndex = 0
-	g.ActivePlayer = "player" // Keep legacy sync
+	if g.bettors() < 2 {
+		g.NextPhase()
+		return true
+	}
+	g.setTurn(g.nextSeat(g.DealerIndex, g.toAct))
 	return true
 }
 
+// NewRound clears the table for the next hand and moves the dealer button
+// one seat to the left.
 func (g *GameState) NewRound() {
-	deck := NewDeck()
-	g.Deck = deck
+	// The deck is shuffled when the ante is collected
+	g.Deck = nil
+	g.Muck = nil
+	g.Board = nil
+	g.DrawRound = 0
 
 	for _, rs := range g.RoundStates {
 		rs.Hand = []Card{}
+		rs.FaceUp = nil
 		rs.Bet = 0
+		rs.Committed = 0
+		rs.AllIn = false
 		rs.Folded = false
 		rs.Discarded = false
+		rs.Drew = 0
+		rs.Acted = false
+		rs.SittingOut = false
 	}
 
 	g.Pot = 0
-	g.TurnIndex = 0
+	g.Pots = nil
+	g.DealerIndex = (g.DealerIndex + 1) % len(g.Players)
+	g.setTurn(g.nextSeat(g.DealerIndex, g.inHand))
 	g.GamePhase = PhaseAnte
 	g.CurrentBet = 0
 	g.LastAction = "New round started. Ante up!"
-	g.ActivePlayer = "player"
 	g.Winner = ""
 	g.RevealOnFold = GlobalRevealOnFold
 }
@@ -291,212 +516,368 @@ func init() {
 	}
 }
 
-// ... (existing code)
+// SeatOf returns the seat index of playerID, or -1 if they are not seated.
+func (g *GameState) SeatOf(playerID string) int {
+	for i, p := range g.Players {
+		if p.ID == playerID {
+			return i
+		}
+	}
+	return -1
+}
 
-func (g *GameState) PlayerAction(action string, amount int) (bool, string) {
-	// Security check: only allow actions for player 0 (human) via this method?
-	// The handler calls this. Let's assume this method is specifically for the Human Player (index 0).
-	player := g.Players[0]
-	playerState := g.RoundStates[0]
-	opponent := g.Players[1]
-	opponentState := g.RoundStates[1]
-
-	// Allow folding at any time
-	if action == "fold" && g.GamePhase != PhaseComplete {
-		g.GamePhase = PhaseComplete
-		g.Winner = opponent.Name
-		opponent.Sanity += g.Pot
-		g.Pot = 0
-		g.LastAction = fmt.Sprintf("You folded. %s wins.", opponent.Name)
-		playerState.Folded = true
-		return true, ""
+// HumanSeat returns the first seat held by a human, or -1 if there is none.
+// Single-player entry points like PlayerAction act on this seat.
+func (g *GameState) HumanSeat() int {
+	for i, p := range g.Players {
+		if !p.IsAI {
+			return i
+		}
 	}
+	return -1
+}
 
-	// Strictly enforce turn: Index 0
-	if g.TurnIndex != 0 || g.GamePhase == PhaseComplete {
-		return false, "It is not your turn."
+// humansSeated counts humans dealt into the current hand.
+func (g *GameState) humansSeated() int {
+	n := 0
+	for i, p := range g.Players {
+		if !p.IsAI && !g.RoundStates[i].SittingOut {
+			n++
+		}
 	}
+	return n
+}
 
-	switch action {
-	case "check":
-		if g.CurrentBet > playerState.Bet {
-			return false, "Cannot check when there is a bet to call."
+// inHand reports whether the seat still holds live cards this hand.
+func (g *GameState) inHand(seat int) bool {
+	return !g.RoundStates[seat].Folded
+}
+
+// canBet reports whether the seat is live and has sanity left to wager.
+func (g *GameState) canBet(seat int) bool {
+	rs := g.RoundStates[seat]
+	return !rs.Folded && !rs.AllIn
+}
+
+// bettors counts the seats that can still wager.
+func (g *GameState) bettors() int {
+	n := 0
+	for i := range g.Players {
+		if g.canBet(i) {
+			n++
 		}
-		g.LastAction = "You checked."
-		g.TurnIndex = 1
+	}
+	return n
+}
+
+// toAct reports whether the seat still owes an action this betting round.
+func (g *GameState) toAct(seat int) bool {
+	rs := g.RoundStates[seat]
+	return g.canBet(seat) && (!rs.Acted || rs.Bet < g.CurrentBet)
+}
+
+// toDiscard reports whether the seat still has to draw this hand.
+func (g *GameState) toDiscard(seat int) bool {
+	rs := g.RoundStates[seat]
+	return !rs.Folded && !rs.Discarded
+}
+
+// nextSeat returns the first seat after from, going clockwise, that
+// satisfies want, or -1 if none does.
+func (g *GameState) nextSeat(from int, want func(int) bool) int {
+	n := len(g.Players)
+	for step := 1; step <= n; step++ {
+		seat := (from + step) % n
+		if want(seat) {
+			return seat
+		}
+	}
+	return -1
+}
+
+// contenders returns the seats still holding live cards, in seat order.
+func (g *GameState) contenders() []int {
+	var seats []int
+	for i := range g.Players {
+		if g.inHand(i) {
+			seats = append(seats, i)
+		}
+	}
+	return seats
+}
+
+// setTurn hands the action to seat, keeping the legacy ActivePlayer in sync.
+func (g *GameState) setTurn(seat int) {
+	if seat < 0 {
+		return
+	}
+	g.TurnIndex = seat
+	if g.Players[seat].IsAI {
 		g.ActivePlayer = "opponent"
+	} else {
+		g.ActivePlayer = "player"
+	}
+}
+
+func (g *GameState) isBetting() bool {
+	switch g.GamePhase {
+	case PhasePreDrawBetting, PhasePostDrawBetting, PhasePreFlop, PhaseFlop, PhaseTurn, PhaseRiver,
+		PhaseThirdStreet, PhaseFourthStreet, PhaseFifthStreet, PhaseSixthStreet, PhaseSeventhStreet:
+		return true
+	}
+	return false
+}
+
+// handInProgress reports whether cards are live and folding makes sense.
+func (g *GameState) handInProgress() bool {
+	return g.isBetting() || g.GamePhase == PhaseDiscard
+}
+
+// PlayerAction applies a betting action for the table's human seat. It is
+// kept for single-human games; multi-human tables should use SeatAction.
+func (g *GameState) PlayerAction(action string, amount int) (bool, string) {
+	return g.SeatAction(g.HumanSeat(), action, amount)
+}
+
+// SeatAction applies a betting action ("check", "call", "bet", "raise",
+// "allin" or "fold") for the player at seat, if LegalActions allows it. For
+// bets and raises, amount is the size of the bet or the raise on top of the
+// call. Calling with less sanity than the bet puts the player all in for
+// what they have.
+func (g *GameState) SeatAction(seat int, action string, amount int) (bool, string) {
+	if seat < 0 || seat >= len(g.Players) {
+		return false, "You are not seated at this table."
+	}
+	if msg := g.illegal(seat, g.LegalActions(seat), action, amount); msg != "" {
+		return false, msg
+	}
+	player := g.Players[seat]
+	playerState := g.RoundStates[seat]
+
+	switch action {
+	case "fold":
+		// Allowed at any time during a hand
+		g.fold(seat)
+		return true, ""
+
+	case "check":
+		playerState.Acted = true
+		g.record(seat, Event{Type: EventCheck})
+		g.LastAction = player.phrase("You checked.", fmt.Sprintf("%s checks.", player.Name))
+		g.advanceBetting()
 		return true, ""
 
 	case "call":
-		toCall := g.CurrentBet - playerState.Bet
-		if toCall <= 0 {
-			return false, "Nothing to call, please Check."
-		}
-		if player.Sanity < toCall {
-			return false, "Not enough sanity to call."
+		toCall := min(g.CurrentBet-playerState.Bet, player.Sanity)
+		g.wager(seat, toCall)
+		playerState.Acted = true
+		g.record(seat, Event{Type: EventCall, Amount: toCall})
+		if playerState.AllIn {
+			g.LastAction = player.phrase(fmt.Sprintf("You call all in for %d.", toCall), fmt.Sprintf("%s calls all in for %d.", player.Name, toCall))
+		} else {
+			g.LastAction = player.phrase("You called.", fmt.Sprintf("%s calls.", player.Name))
 		}
-		player.Sanity -= toCall
-		g.Pot += toCall
-		playerState.Bet += toCall
-		g.LastAction = "You called."
+		g.advanceBetting()
+		return true, ""
 
-		// Round ends if action closes betting
-		if opponentState.Bet == playerState.Bet {
-			g.NextPhase()
-		} else {
-			g.TurnIndex = 1
-			g.ActivePlayer = "opponent"
+	case "allin":
+		shove := player.Sanity
+		g.wager(seat, shove)
+		if playerState.Bet > g.CurrentBet {
+			g.raiseTo(seat)
 		}
+		playerState.Acted = true
+		g.record(seat, Event{Type: EventAllIn, Amount: shove})
+		g.LastAction = player.phrase(fmt.Sprintf("You go all in with %d!", shove), fmt.Sprintf("%s goes all in with %d!", player.Name, shove))
+		g.advanceBetting()
 		return true, ""
 
 	case "bet", "raise":
-		if amount <= 0 {
-			return false, "Bet amount must be positive."
-		}
 		totalCost := (g.CurrentBet - playerState.Bet) + amount
-		if player.Sanity < totalCost {
-			return false, fmt.Sprintf("Not enough sanity. You need %d but have %d.", totalCost, player.Sanity)
+		g.wager(seat, totalCost)
+		g.raiseTo(seat)
+		playerState.Acted = true
+
+		if playerState.AllIn {
+			g.record(seat, Event{Type: EventAllIn, Amount: totalCost})
+		} else if action == "bet" {
+			g.record(seat, Event{Type: EventBet, Amount: totalCost})
+		} else {
+			g.record(seat, Event{Type: EventRaise, Amount: totalCost})
 		}
 
-		player.Sanity -= totalCost
-		g.Pot += totalCost
-		playerState.Bet += totalCost
-		g.CurrentBet = playerState.Bet
-
 		if action == "bet" {
-			g.LastAction = fmt.Sprintf("You bet %d.", amount)
+			g.LastAction = player.phrase(fmt.Sprintf("You bet %d.", amount), fmt.Sprintf("%s bets %d.", player.Name, amount))
 		} else {
-			g.LastAction = fmt.Sprintf("You raised by %d.", amount)
+			g.LastAction = player.phrase(fmt.Sprintf("You raised by %d.", amount), fmt.Sprintf("%s raises by %d.", player.Name, amount))
 		}
-		g.TurnIndex = 1
-		g.ActivePlayer = "opponent"
+		g.advanceBetting()
 		return true, ""
 	}
 
 	return false, "Invalid action."
 }
 
-func (g *GameState) OpponentTurn() {
-	if g.TurnIndex != 1 || g.GamePhase == PhaseComplete {
-		return
+// pay moves sanity from the seat into the pot, marking the seat all in once
+// it has nothing left.
+func (g *GameState) pay(seat, amount int) {
+	player := g.Players[seat]
+	rs := g.RoundStates[seat]
+	player.Sanity -= amount
+	rs.Committed += amount
+	g.Pot += amount
+	if player.Sanity <= 0 && !rs.Folded {
+		rs.AllIn = true
 	}
+	g.Pots = g.buildPots()
+}
 
-	player := g.Players[0]
-	// playerState := g.RoundStates[0] // Unused?
-	opponent := g.Players[1]
-	opponentState := g.RoundStates[1]
+// wager pays amount as part of the seat's bet in the current betting round.
+func (g *GameState) wager(seat, amount int) {
+	g.pay(seat, amount)
+	g.RoundStates[seat].Bet += amount
+}
 
-	// Use AI to decide action
-	ai := DefaultAI
-	// We need to support DecideAction possibly needing more context?
-	// For now pass Hand and GameState.
-	// NOTE: ai.DecideAction likely accesses g.Players[1].Bet - need to check AI!!
-	action, amount := ai.DecideAction(opponentState.Hand, g)
+// reopenBetting makes every seat act again after a bet or raise.
+func (g *GameState) reopenBetting() {
+	for _, rs := range g.RoundStates {
+		rs.Acted = false
+	}
+}
 
-	switch action {
-	case "check":
-		// Verify check is legal (no bet to call)
-		if g.CurrentBet > opponentState.Bet {
-			// Forced to call if AI made a mistake
-			toCall := g.CurrentBet - opponentState.Bet
-			if toCall > 0 {
-				// Fallback to call logic
-				if opponent.Sanity >= toCall {
-					opponent.Sanity -= toCall
-					g.Pot += toCall
-					opponentState.Bet += toCall
-					g.LastAction = fmt.Sprintf("%s calls.", opponent.Name)
-					g.NextPhase()
-				} else {
-					// Fold
-					g.GamePhase = PhaseComplete
-					g.Winner = player.Name
-					player.Sanity += g.Pot
-					g.Pot = 0
-					g.LastAction = fmt.Sprintf("%s folds (insufficient sanity).", opponent.Name)
-					opponentState.Folded = true
-				}
-				return
+// buildPots splits everything committed this hand into a main pot and side
+// pots. Each live commitment level caps a pot; a seat is eligible for every
+// pot up to its own commitment. Adjacent pots with the same eligible seats
+// are merged, so a pot with a single eligible seat is an uncalled bet.
+func (g *GameState) buildPots() []Pot {
+	var levels []int
+	for _, rs := range g.RoundStates {
+		if !rs.Folded && rs.Committed > 0 {
+			levels = append(levels, rs.Committed)
+		}
+	}
+	sort.Ints(levels)
+	levels = slices.Compact(levels)
+
+	var pots []Pot
+	prev, total := 0, 0
+	for _, level := range levels {
+		var pot Pot
+		for i, rs := range g.RoundStates {
+			pot.Amount += min(rs.Committed, level) - min(rs.Committed, prev)
+			if !rs.Folded && rs.Committed >= level {
+				pot.Eligible = append(pot.Eligible, i)
 			}
 		}
+		prev = level
+		total += pot.Amount
 
-		g.LastAction = fmt.Sprintf("%s checks.", opponent.Name)
-		if g.ActivePlayer == "opponent" {
-			// If opponent acted second/last in sequence, usually NextPhase?
-			// Simplistic turn handling: If P0 Checked, P1 Checks -> Next
-			g.NextPhase()
+		if n := len(pots); n > 0 && slices.Equal(pots[n-1].Eligible, pot.Eligible) {
+			pots[n-1].Amount += pot.Amount
 		} else {
-			// If P1 (Opponent) is acting, give turn back to P0
-			g.TurnIndex = 0
-			g.ActivePlayer = "player"
+			pots = append(pots, pot)
 		}
+	}
 
-	case "call":
-		toCall := g.CurrentBet - opponentState.Bet
-		if toCall > opponent.Sanity {
-			// Fold
-			g.GamePhase = PhaseComplete
-			g.Winner = player.Name
-			player.Sanity += g.Pot
-			g.Pot = 0
-			opponentState.Folded = true
-			g.LastAction = fmt.Sprintf("%s folds.", opponent.Name)
-			return
-		}
-		opponent.Sanity -= toCall
-		g.Pot += toCall
-		opponentState.Bet += toCall
-		g.LastAction = fmt.Sprintf("%s calls.", opponent.Name)
+	// Folded money above every live level still belongs in play
+	if n := len(pots); n > 0 && total < g.Pot {
+		pots[n-1].Amount += g.Pot - total
+	}
+	return pots
+}
+
+// fold mucks the seat's hand. If only one player is left they take the pot;
+// otherwise play continues with the next seat if it was the folder's turn.
+func (g *GameState) fold(seat int) {
+	player := g.Players[seat]
+	g.RoundStates[seat].Folded = true
+	g.Muck = append(g.Muck, g.RoundStates[seat].Hand...)
+	g.Pots = g.buildPots()
+	g.record(seat, Event{Type: EventFold})
+	g.LastAction = player.phrase("You folded.", fmt.Sprintf("%s folds.", player.Name))
+
+	if live := g.contenders(); len(live) == 1 {
+		winner := g.Players[live[0]]
+		winner.Sanity += g.Pot
+		g.record(live[0], Event{Type: EventAward, Amount: g.Pot})
+		g.Pot = 0
+		g.Pots = nil
+		g.Winner = winner.Name
+		g.GamePhase = PhaseComplete
+		g.recordStacks()
+		g.tallyStats()
+		g.LastAction += " " + winner.phrase("You win!", fmt.Sprintf("%s wins.", winner.Name))
+		return
+	}
+
+	if seat != g.TurnIndex {
+		return
+	}
+	if g.GamePhase == PhaseDiscard {
+		g.advanceDiscard()
+	} else {
+		g.advanceBetting()
+	}
+}
+
+// advanceBetting passes the action to the next seat that still owes one, or
+// closes the betting round once every live seat has acted and matched the
+// current bet.
+func (g *GameState) advanceBetting() {
+	next := g.nextSeat(g.TurnIndex, g.toAct)
+	if next < 0 {
 		g.NextPhase()
+		return
+	}
+	g.setTurn(next)
+}
 
-	case "bet", "raise":
-		totalCost := (g.CurrentBet - opponentState.Bet) + amount
-
-		if opponent.Sanity < totalCost {
-			// Fallback: Call if possible
-			toCall := g.CurrentBet - opponentState.Bet
-			if toCall > 0 && opponent.Sanity >= toCall {
-				opponent.Sanity -= toCall
-				g.Pot += toCall
-				opponentState.Bet += toCall
-				g.LastAction = fmt.Sprintf("%s calls.", opponent.Name)
-				g.NextPhase()
-			} else if toCall == 0 {
-				g.LastAction = fmt.Sprintf("%s checks.", opponent.Name)
-				g.TurnIndex = 0
-				g.ActivePlayer = "player"
-			} else {
-				// Fold
-				g.GamePhase = PhaseComplete
-				g.Winner = player.Name
-				player.Sanity += g.Pot
-				g.Pot = 0
-				opponentState.Folded = true
-				g.LastAction = fmt.Sprintf("%s folds.", opponent.Name)
-			}
+// OpponentTurn lets every AI seat act, in turn order, until a human has to
+// act or the hand moves past betting.
+func (g *GameState) OpponentTurn() {
+	// Bounded in case AIs keep re-raising each other
+	for i := 0; i < 100 && g.isBetting(); i++ {
+		seat := g.TurnIndex
+		if !g.Players[seat].IsAI {
 			return
 		}
+		g.aiAct(seat)
+	}
+}
 
-		opponent.Sanity -= totalCost
-		g.Pot += totalCost
-		opponentState.Bet += totalCost
-		g.CurrentBet = opponentState.Bet
+// aiAct asks the AI for a decision and coerces it into a legal action, so
+// the AI can never stall the table.
+func (g *GameState) aiAct(seat int) {
+	// Ask the seat's strategy for a decision
+	action, amount := g.strategy(seat).DecideAction(g.HandOf(seat), g)
 
-		if action == "bet" {
-			g.LastAction = fmt.Sprintf("%s bets %d.", opponent.Name, amount)
+	legal := g.LegalActions(seat)
+	passive := "call"
+	if legal.Check {
+		passive = "check"
+	}
+	switch action {
+	case "check", "call":
+		// Forced to call if AI made a mistake, and vice versa
+		action = passive
+	case "bet", "raise":
+		// Bet or raise, whichever is legal, sized to the table's limits,
+		// which may leave the seat all in; or just call/check if a raise is
+		// out of reach. Calls for more than it has go all in.
+		r := legal.Bet
+		action = "bet"
+		if r == nil {
+			r, action = legal.Raise, "raise"
+		}
+		if r != nil && amount > 0 {
+			amount = min(max(amount, r.Min), r.Max)
 		} else {
-			g.LastAction = fmt.Sprintf("%s raises by %d.", opponent.Name, amount)
+			action = passive
 		}
-		g.TurnIndex = 0
-		g.ActivePlayer = "player"
+	}
 
-	case "fold":
-		g.GamePhase = PhaseComplete
-		g.Winner = player.Name
-		player.Sanity += g.Pot
-		g.Pot = 0
-		opponentState.Folded = true
-		g.LastAction = fmt.Sprintf("%s folds. You win!", opponent.Name)
+	if ok, _ := g.SeatAction(seat, action, amount); !ok {
+		g.SeatAction(seat, "fold", 0)
 	}
 }
 
@@ -504,49 +885,180 @@ func (g *GameState) NextPhase() {
 	// Transition logic
 	switch g.GamePhase {
 	case PhasePreDrawBetting:
-		g.GamePhase = PhaseDiscard
-		g.TurnIndex = 0
-		g.ActivePlayer = "player"
-		g.LastAction = "Betting complete. Choose cards to discard."
-		// Reset bets for next round
-		g.CurrentBet = 0
-		for _, rs := range g.RoundStates {
-			rs.Bet = 0
-		}
+		g.startDraw()
 
 	case PhaseDiscard:
-		g.GamePhase = PhasePostDrawBetting
-		g.TurnIndex = 0
-		g.ActivePlayer = "player"
+		g.enterPhase(PhasePostDrawBetting)
 		g.LastAction = "Cards exchanged. Final betting round."
+		if g.DrawRound < g.Rules.draws() {
+			g.LastAction = fmt.Sprintf("Cards exchanged. Betting before draw %d of %d.", g.DrawRound+1, g.Rules.draws())
+		}
+		g.resetBets()
+		// Nobody left to bet against: straight to showdown
+		if g.bettors() < 2 {
+			g.NextPhase()
+			return
+		}
+		g.setTurn(g.nextSeat(g.DealerIndex, g.toAct))
+
+	case PhasePreFlop:
+		g.dealStreet(PhaseFlop, 3)
+
+	case PhaseFlop:
+		g.dealStreet(PhaseTurn, 1)
+
+	case PhaseTurn:
+		g.dealStreet(PhaseRiver, 1)
+
+	case PhaseThirdStreet:
+		g.dealStudStreet(PhaseFourthStreet, true)
+
+	case PhaseFourthStreet:
+		g.dealStudStreet(PhaseFifthStreet, true)
+
+	case PhaseFifthStreet:
+		g.dealStudStreet(PhaseSixthStreet, true)
+
+	case PhaseSixthStreet:
+		g.dealStudStreet(PhaseSeventhStreet, false)
 
 	case PhasePostDrawBetting:
-		g.GamePhase = PhaseShowdown
+		if g.DrawRound < g.Rules.draws() {
+			g.startDraw()
+			return
+		}
+		g.enterPhase(PhaseShowdown)
+		g.CompleteShowdown()
+
+	case PhaseRiver, PhaseSeventhStreet:
+		g.enterPhase(PhaseShowdown)
 		g.CompleteShowdown()
 	}
 }
 
+// startDraw opens the next draw round: everyone still in draws again, in
+// turn order from the left of the button.
+func (g *GameState) startDraw() {
+	g.DrawRound++
+	g.enterPhase(PhaseDiscard)
+	g.LastAction = "Betting complete. Choose cards to discard."
+	if g.Rules.draws() > 1 {
+		g.LastAction = fmt.Sprintf("Draw %d of %d. Choose cards to discard.", g.DrawRound, g.Rules.draws())
+	}
+	g.resetBets()
+	for _, rs := range g.RoundStates {
+		rs.Discarded = false
+		rs.Drew = 0
+	}
+	g.setTurn(g.nextSeat(g.DealerIndex, g.toDiscard))
+	g.advanceDiscard()
+}
+
+// enterPhase moves the hand to phase and logs the transition.
+func (g *GameState) enterPhase(phase GamePhase) {
+	g.GamePhase = phase
+	g.record(TableSeat, Event{Type: EventPhase, Detail: phase.String()})
+}
+
+// resetBets clears per-round betting state between betting rounds.
+func (g *GameState) resetBets() {
+	g.CurrentBet = 0
+	g.LastRaise, g.Raises = 0, 0
+	for _, rs := range g.RoundStates {
+		rs.Bet = 0
+		rs.Acted = false
+	}
+}
+
+// PerformDiscard exchanges cards for the table's human seat; AI seats draw
+// automatically when their turn comes up.
 func (g *GameState) PerformDiscard(indices []int) {
-	if g.GamePhase != PhaseDiscard {
-		return
+	g.SeatDiscard(g.HumanSeat(), indices)
+}
+
+// SeatDiscard exchanges the cards at indices for the player at seat. Draws
+// go in turn order, starting left of the button.
+func (g *GameState) SeatDiscard(seat int, indices []int) (bool, string) {
+	if seat < 0 || seat >= len(g.Players) {
+		return false, "You are not seated at this table."
+	}
+	if msg := g.illegalDiscard(seat, indices); msg != "" {
+		return false, msg
 	}
-	playerState := g.RoundStates[0]
-	// opponent := g.Players[1] // Unused in this func
-	opponentState := g.RoundStates[1]
 
-	// Player discards
-	ReplaceCards(&g.Deck, &playerState.Hand, indices)
+	player := g.Players[seat]
+	playerState := g.RoundStates[seat]
+	g.record(seat, Event{Type: EventDiscard, Count: len(indices), Indices: slices.Clone(indices)})
+	drawn := g.replaceCards(seat, indices)
 	playerState.Discarded = true
+	playerState.Drew = drawn
+	g.record(seat, Event{Type: EventDraw, Count: drawn})
+	g.LastAction = player.phrase(fmt.Sprintf("You drew %d.", len(indices)), fmt.Sprintf("%s draws %d.", player.Name, len(indices)))
 
-	// Opponent discards using AI
-	ai := DefaultAI
-	oppIndices := ai.ChooseDiscard(opponentState.Hand)
+	g.advanceDiscard()
+	return true, ""
+}
 
-	ReplaceCards(&g.Deck, &opponentState.Hand, oppIndices)
-	opponentState.Discarded = true
+// replaceCards swaps the cards of seat's hand at indices for cards off the
+// top of the deck and returns how many were replaced. The discards go to
+// the muck afterwards, so when the deck runs out they are not shuffled
+// back in to the player who threw them; only if the muck is empty too are
+// cards left unreplaced.
+func (g *GameState) replaceCards(seat int, indices []int) int {
+	hand := g.RoundStates[seat].Hand
+	var discards Hand
+	replaced := 0
+	for _, i := range indices {
+		if i < 0 || i >= len(hand) {
+			continue
+		}
+		if len(g.Deck) == 0 {
+			g.reshuffleMuck()
+		}
+		if len(g.Deck) == 0 {
+			break
+		}
+		discards = append(discards, hand[i])
+		hand[i] = g.Deck[0]
+		g.Deck = g.Deck[1:]
+		replaced++
+	}
+	g.Muck = append(g.Muck, discards...)
+	return replaced
+}
 
-	// If both done (which they are), next phase
-	g.NextPhase()
+// reshuffleMuck shuffles the muck to make a new deck. Live hands are never
+// in the muck, so they are never dealt twice.
+func (g *GameState) reshuffleMuck() {
+	if len(g.Muck) == 0 {
+		return
+	}
+	g.Deck, g.Muck = g.Muck, nil
+	ShuffleDeck(&g.Deck, g.rng())
+	g.record(TableSeat, Event{Type: EventReshuffle, Count: len(g.Deck)})
+	g.LastAction = "The deck is exhausted. The muck is shuffled into a new one."
+}
+
+// advanceDiscard moves the draw to the next seat, letting AI seats draw
+// straight away, and moves on to betting once everyone has drawn.
+func (g *GameState) advanceDiscard() {
+	for g.GamePhase == PhaseDiscard {
+		seat := g.TurnIndex
+		if !g.toDiscard(seat) {
+			seat = g.nextSeat(g.TurnIndex, g.toDiscard)
+		}
+		if seat < 0 {
+			g.NextPhase()
+			return
+		}
+		g.setTurn(seat)
+		if !g.Players[seat].IsAI {
+			return
+		}
+
+		// Opponent discards using its strategy
+		g.SeatDiscard(seat, g.strategy(seat).ChooseDiscard(g.RoundStates[seat].Hand, g.Rules, g.rng()))
+	}
 }
 
 func (g *GameState) CanDiscard() bool {
@@ -557,42 +1069,230 @@ func (g *GameState) CanShowdown() bool {
 	return g.GamePhase == PhaseShowdown || g.GamePhase == PhaseComplete
 }
 
-func (g *GameState) CompleteShowdown() {
-	// g.Showdown field meant "is showdown happening/visible"?
-	// We'll leave it for UI compatibility, but phase is Complete
-	g.GamePhase = PhaseComplete
+// ShowedDown reports whether the last hand is over and was decided by the
+// hands still in play, rather than won by everyone else folding.
+func (g *GameState) ShowedDown() bool {
+	return g.handOver() && !g.endedByFold()
+}
 
-	player := g.Players[0]
-	playerState := g.RoundStates[0]
-	opponent := g.Players[1]
-	opponentState := g.RoundStates[1]
+// ShowdownResult compares seat's hand, once shown down, with the best of
+// the others still in play: the hand that beat it, or the one it beat.
+// ok is false if seat was not in the showdown, or if the others' best high
+// and best low could belong to different seats, as in hi-lo multiway, where
+// LastAction tells it instead.
+func (g *GameState) ShowdownResult(seat int) (result HandComparisonResult, ok bool) {
+	contenders := g.contenders()
+	if !g.ShowedDown() || !slices.Contains(contenders, seat) || len(contenders) < 2 {
+		return result, false
+	}
+	if len(contenders) > 2 && g.Rules.ranking() == RankingHiLo {
+		return result, false
+	}
+	others := slices.DeleteFunc(slices.Clone(contenders), func(s int) bool { return s == seat })
+	them := g.bestHands(others)[0]
+	return g.Rules.CompareForDisplayAgainst(g.HandOf(seat), g.HandOf(them), g.Players[them].Name), true
+}
 
-	result := CompareHands(playerState.Hand, opponentState.Hand)
+// CanDeal reports whether the last hand is over, so the next can be dealt.
+func (g *GameState) CanDeal() bool {
+	return !g.handInProgress() && g.GamePhase != PhaseESP
+}
 
-	handRes := CompareHandsForDisplay(playerState.Hand, opponentState.Hand)
-	g.LastAction = handRes.Message
+// CompleteShowdown ranks every hand still in play and awards each pot to
+// the best hand eligible for it, splitting ties. In hi-lo the best low
+// eligible takes half of each pot, the odd chip going to the high. A hand
+// that is already over, whether shown down or won by a fold, is left as it
+// ended.
+func (g *GameState) CompleteShowdown() {
+	if g.handOver() && g.GamePhase != PhaseShowdown {
+		return
+	}
+	g.GamePhase = PhaseComplete
 
-	switch result {
-	case ResultHand1Wins:
-		player.Sanity += g.Pot
-		g.Winner = player.Name
-	case ResultHand2Wins:
-		opponent.Sanity += g.Pot
-		g.Winner = opponent.Name
-	default:
-		player.Sanity += g.Pot / 2
-		opponent.Sanity += g.Pot / 2
+	contenders := g.contenders()
+	if len(contenders) == 0 {
+		return
+	}
+	winners := g.bestHands(contenders)
+	for _, seat := range contenders {
+		hand := g.HandOf(seat)
+		e := Event{Type: EventShow, Cards: append(Hand(nil), hand...), Detail: g.Rules.HandName(hand)}
+		if len(hand) > 5 {
+			e.Indices = g.Rules.used(hand)
+		}
+		g.record(seat, e)
+	}
+
+	var notes []string
+	for i, pot := range g.Pots {
+		potWinners := g.bestHands(pot.Eligible)
+		lowWinners := g.bestLows(pot.Eligible)
+		low := 0
+		if len(lowWinners) > 0 {
+			low = pot.Amount / 2
+			g.awardPot(low, lowWinners)
+		}
+		g.awardPot(pot.Amount-low, potWinners)
+		switch {
+		case i == 0:
+			// The main pot is described by the showdown message itself
+		case len(pot.Eligible) == 1:
+			notes = append(notes, fmt.Sprintf("%d uncalled returned to %s.", pot.Amount, g.Players[pot.Eligible[0]].Name))
+		case low > 0:
+			notes = append(notes, fmt.Sprintf("Side pot of %d split: high to %s, low to %s.", pot.Amount, g.seatNames(potWinners), g.seatNames(lowWinners)))
+		default:
+			notes = append(notes, fmt.Sprintf("Side pot of %d to %s.", pot.Amount, g.seatNames(potWinners)))
+		}
+	}
+	if lows := g.bestLows(contenders); len(lows) > 0 && !slices.Equal(lows, winners) {
+		g.Winner = "tie" // High and low split the pot
+	} else if len(winners) == 1 {
+		g.Winner = g.Players[winners[0]].Name
+	} else {
 		g.Winner = "tie"
 	}
 	g.Pot = 0
+	g.Pots = nil
+	g.recordStacks()
+	g.tallyStats()
 
-	// Immediate game over if player is bankrupt
-	if player.Sanity <= 0 {
+	message := g.showdownMessage(contenders, winners)
+	if len(notes) > 0 {
+		message += " " + strings.Join(notes, " ")
+	}
+	g.LastAction = message
+
+	// Immediate game over once no human has sanity left
+	bankrupt := true
+	lost := "You lost everything."
+	for _, p := range g.Players {
+		if !p.IsAI && p.Sanity > 0 {
+			bankrupt = false
+		}
+		if !p.IsAI && p.Name != "You" {
+			lost = "Every mortal at the table has lost everything."
+		}
+	}
+	if bankrupt {
 		g.GamePhase = PhaseGameOver
-		g.LastAction = fmt.Sprintf("%s You lost everything. Game Over.", handRes.Message)
+		g.LastAction = fmt.Sprintf("%s %s Game Over.", message, lost)
 	}
 }
 
+// recordStacks logs every seat's sanity at the end of a hand.
+func (g *GameState) recordStacks() {
+	for i, p := range g.Players {
+		g.record(i, Event{Type: EventStack, Amount: p.Sanity})
+	}
+}
+
+// awardPot splits amount between winners. Odd chips go one at a time to the
+// winners closest to the left of the button.
+func (g *GameState) awardPot(amount int, winners []int) {
+	order := slices.Clone(winners)
+	sort.Slice(order, func(a, b int) bool {
+		return g.seatsFromButton(order[a]) < g.seatsFromButton(order[b])
+	})
+
+	share, odd := amount/len(order), amount%len(order)
+	for i, seat := range order {
+		won := share
+		if i < odd {
+			won++
+		}
+		g.Players[seat].Sanity += won
+		g.record(seat, Event{Type: EventAward, Amount: won})
+	}
+}
+
+// seatsFromButton is how far seat sits to the left of the button; the
+// first seat to act is 0.
+func (g *GameState) seatsFromButton(seat int) int {
+	n := len(g.Players)
+	return (seat - g.DealerIndex - 1 + n) % n
+}
+
+// seatNames joins the names of the given seats for messages.
+func (g *GameState) seatNames(seats []int) string {
+	names := make([]string, len(seats))
+	for i, seat := range seats {
+		names[i] = g.Players[seat].Name
+	}
+	return strings.Join(names, " and ")
+}
+
+// HandOf returns the cards seat makes its hand from: its own, plus the
+// board in Hold'em.
+func (g *GameState) HandOf(seat int) Hand {
+	hand := append(Hand(nil), g.RoundStates[seat].Hand...)
+	return append(hand, g.Board...)
+}
+
+// bestHands returns the seats among contenders holding the strongest hand.
+func (g *GameState) bestHands(contenders []int) []int {
+	best := []int{contenders[0]}
+	for _, seat := range contenders[1:] {
+		switch g.Rules.Compare(g.HandOf(seat), g.HandOf(best[0])) {
+		case ResultHand1Wins:
+			best = []int{seat}
+		case ResultTie:
+			best = append(best, seat)
+		}
+	}
+	return best
+}
+
+// bestLows returns the seats among contenders holding the best qualifying
+// low, in hi-lo; otherwise, or if no low qualifies, it returns nil.
+func (g *GameState) bestLows(contenders []int) []int {
+	if g.Rules.ranking() != RankingHiLo {
+		return nil
+	}
+	var best []int
+	var bestLow LowStrength
+	for _, seat := range contenders {
+		low, _ := g.Rules.BestLow(g.HandOf(seat))
+		switch {
+		case low == 0 || low < bestLow:
+		case low > bestLow:
+			best, bestLow = []int{seat}, low
+		default:
+			best = append(best, seat)
+		}
+	}
+	return best
+}
+
+// showdownMessage describes the showdown. Heads-up between the local human,
+// "You", and an AI keeps the classic "You win with..." wording, naming the
+// AI.
+func (g *GameState) showdownMessage(contenders, winners []int) string {
+	if len(contenders) == 2 {
+		you, them := contenders[0], contenders[1]
+		if g.Players[them].Name == "You" {
+			you, them = them, you
+		}
+		if g.Players[you].Name == "You" && g.Players[them].IsAI {
+			return g.Rules.CompareForDisplayAgainst(g.HandOf(you), g.HandOf(them), g.Players[them].Name).Message
+		}
+	}
+
+	if lows := g.bestLows(contenders); len(lows) > 0 && !slices.Equal(lows, winners) {
+		high, _ := g.Rules.BestFive(g.HandOf(winners[0]))
+		low, _ := g.Rules.BestLow(g.HandOf(lows[0]))
+		return fmt.Sprintf("High to %s with %s; low to %s with %s.",
+			g.seatNames(winners), GetHandName(high.Rank()), g.seatNames(lows), low.Name())
+	}
+
+	handName := g.Rules.HandName(g.HandOf(winners[0]))
+	if len(winners) == 1 {
+		w := g.Players[winners[0]]
+		return w.phrase(fmt.Sprintf("You win with %s!", handName), fmt.Sprintf("%s wins with %s!", w.Name, handName))
+	}
+
+	return fmt.Sprintf("Split pot! %s tie with %s.", g.seatNames(winners), handName)
+}
+
 // CanStartESP checks if ESP training is allowed in current phase
 func (g *GameState) CanStartESP() bool {
 	return g.GamePhase == PhaseComplete || g.GamePhase == PhaseAnte || g.GamePhase == PhaseGameOver
@@ -614,14 +1314,20 @@ var espThemeMessages = map[string]string{
 	"evens":  "Even patterns crystallize...",
 }
 
-// StartESP initializes the ESP minigame with themed cards
+// StartESP initializes the ESP minigame with themed cards for the table's
+// human seat, wherever it sits.
 func (g *GameState) StartESP() (bool, string) {
 	if !g.CanStartESP() {
 		return false, "The spirits are occupied. Complete your current hand first."
 	}
+	seat := g.HumanSeat()
+	if seat < 0 {
+		return false, "No mortal mind is here to train."
+	}
 
 	// Pick a random mystic theme
-	theme := espThemeNames[rand.Intn(len(espThemeNames))]
+	rng := g.rng()
+	theme := espThemeNames[rng.IntN(len(espThemeNames))]
 	allowedRanks := espThemes[theme]
 
 	// Build a deck with only allowed ranks
@@ -631,24 +1337,32 @@ func (g *GameState) StartESP() (bool, string) {
 			themedDeck = append(themedDeck, Card{Suit: s, Rank: r})
 		}
 	}
-	ShuffleDeck(&themedDeck)
+	ShuffleDeck(&themedDeck, rng)
 
 	// Deal two hands of 5 cards each from themed deck
 	hand1 := DealHand(&themedDeck, 5)
 	hand2 := DealHand(&themedDeck, 5)
 
 	// Guarantee at least one match by forcing a rank match
-	matchIdx1 := rand.Intn(5)
-	matchIdx2 := rand.Intn(5)
-
-	// Make hand2[matchIdx2] have the same rank as hand1[matchIdx1]
-	targetRank := hand1[matchIdx1].Rank
-	for i, card := range themedDeck {
-		if card.Rank == targetRank {
-			hand2[matchIdx2] = card
-			themedDeck = append(themedDeck[:i], themedDeck[i+1:]...)
+	matchIdx1 := rng.IntN(5)
+	matchIdx2 := rng.IntN(5)
+
+	// Make hand2[matchIdx2] have the same rank as hand1[matchIdx1]. If every
+	// card of that rank is dealt already, one in hand2 is the match; if hand1
+	// holds them all, its next card is matched instead. Only one rank can
+	// fill four of its five cards, so some card always can be
+	for range hand1 {
+		isTarget := func(c Card) bool { return c.Rank == hand1[matchIdx1].Rank }
+		if i := slices.IndexFunc(themedDeck, isTarget); i >= 0 {
+			hand2[matchIdx2] = themedDeck[i]
+			themedDeck = slices.Delete(themedDeck, i, i+1)
+			break
+		}
+		if i := slices.IndexFunc(hand2, isTarget); i >= 0 {
+			matchIdx2 = i
 			break
 		}
+		matchIdx1 = (matchIdx1 + 1) % len(hand1)
 	}
 
 	g.ESP = &ESPState{
@@ -661,6 +1375,7 @@ func (g *GameState) StartESP() (bool, string) {
 		StartTime:   time.Now().Unix(),
 	}
 	g.GamePhase = PhaseESP
+	g.record(seat, Event{Type: EventESPStart, Detail: theme})
 	g.LastAction = espThemeMessages[theme] + " Find the matching cards!"
 	return true, g.LastAction
 }
@@ -677,12 +1392,14 @@ func (g *GameState) GuessESP(idx1, idx2 int) (bool, string) {
 	}
 
 	g.ESP.Attempts++
+	seat := g.HumanSeat()
 
 	// Check if the ranks match
 	if g.ESP.Hand1[idx1].Rank == g.ESP.Hand2[idx2].Rank {
 		// Correct!
 		reward := 15
-		g.Players[0].Sanity += reward
+		g.Players[seat].Sanity += reward
+		g.record(seat, Event{Type: EventESPGuess, Amount: reward, Count: g.ESP.Attempts, Detail: "correct"})
 		g.LastAction = fmt.Sprintf("Your mind pierces the veil! +%d Sanity", reward)
 		g.GamePhase = PhaseComplete
 		g.ESP = nil
@@ -691,10 +1408,10 @@ func (g *GameState) GuessESP(idx1, idx2 int) (bool, string) {
 
 	// Wrong guess
 	penalty := 5
-	g.Players[0].Sanity -= penalty
-	g.ESP.Attempts++
+	g.Players[seat].Sanity -= penalty
+	g.record(seat, Event{Type: EventESPGuess, Amount: -penalty, Detail: "wrong"})
 
-	if g.Players[0].Sanity <= 0 {
+	if g.Players[seat].Sanity <= 0 {
 		g.GamePhase = PhaseGameOver
 		g.LastAction = "The visions consumed you. Game Over."
 		return false, g.LastAction
@@ -709,6 +1426,7 @@ func (g *GameState) ExitESP() {
 	if g.GamePhase == PhaseESP {
 		g.GamePhase = PhaseComplete
 		g.ESP = nil
+		g.record(g.HumanSeat(), Event{Type: EventESPExit})
 		g.LastAction = "You close your third eye."
 	}
 }
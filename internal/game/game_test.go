@@ -1,18 +1,20 @@
 package game
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
 func TestNewDeck(t *testing.T) {
-	deck := NewDeck()
+	deck := NewDeck(NewRNG(1))
 	if len(deck) != 52 {
 		t.Fatalf("expected 52 cards, got %d", len(deck))
 	}
 }
 
 func TestDealHand(t *testing.T) {
-	deck := NewDeck()
+	deck := NewDeck(NewRNG(1))
 	hand := DealHand(&deck, 5)
 	if len(hand) != 5 {
 		t.Errorf("expected 5 cards, got %d", len(hand))
@@ -101,3 +103,354 @@ func TestFullGameLoop(t *testing.T) {
 		t.Errorf("Expected Pot to be 0 (distributed), got %d", game.Pot)
 	}
 }
+
+// newHumanTable seats n humans so tests can drive every seat by hand.
+func newHumanTable(t *testing.T, n int) *GameState {
+	t.Helper()
+	players := make([]*Player, n)
+	for i := range players {
+		players[i] = NewHumanPlayer("")
+		players[i].Name = fmt.Sprintf("Seat %d", i)
+	}
+	g, err := NewTable(players)
+	if err != nil {
+		t.Fatalf("NewTable: %v", err)
+	}
+	return g
+}
+
+func TestNewTableSeatLimits(t *testing.T) {
+	if _, err := NewTable([]*Player{NewHumanPlayer("")}); err == nil {
+		t.Errorf("expected error for a single seat")
+	}
+	players := make([]*Player, MaxSeats+1)
+	for i := range players {
+		players[i] = NewAIPlayer(i)
+	}
+	if _, err := NewTable(players); err == nil {
+		t.Errorf("expected error for %d seats", MaxSeats+1)
+	}
+	if _, err := NewTable(players[:MaxSeats]); err != nil {
+		t.Errorf("unexpected error for %d seats: %v", MaxSeats, err)
+	}
+}
+
+func TestDealerButtonRotates(t *testing.T) {
+	g := newHumanTable(t, 4)
+	g.CollectAnte(10)
+	if g.DealerIndex != 3 || g.TurnIndex != 0 {
+		t.Fatalf("expected dealer 3 and seat 0 to act, got dealer %d turn %d", g.DealerIndex, g.TurnIndex)
+	}
+
+	for hand := 1; hand <= 4; hand++ {
+		g.NewRound()
+		g.CollectAnte(10)
+		wantDealer := (3 + hand) % 4
+		if g.DealerIndex != wantDealer {
+			t.Errorf("hand %d: expected dealer %d, got %d", hand, wantDealer, g.DealerIndex)
+		}
+		if want := (wantDealer + 1) % 4; g.TurnIndex != want {
+			t.Errorf("hand %d: expected seat %d to act first, got %d", hand, want, g.TurnIndex)
+		}
+	}
+}
+
+func TestMultiwayBettingRoundCloses(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+
+	steps := []struct {
+		seat   int
+		action string
+		amount int
+	}{
+		{0, "bet", 10},
+		{1, "call", 0},
+		{2, "raise", 10},
+		{0, "call", 0},
+	}
+	for _, s := range steps {
+		if ok, msg := g.SeatAction(s.seat, s.action, s.amount); !ok {
+			t.Fatalf("seat %d %s failed: %s", s.seat, s.action, msg)
+		}
+		if g.GamePhase != PhasePreDrawBetting {
+			t.Fatalf("betting closed early after seat %d %s", s.seat, s.action)
+		}
+	}
+
+	if ok, _ := g.SeatAction(2, "check", 0); ok {
+		t.Errorf("seat 2 should not be able to act out of turn")
+	}
+	if ok, msg := g.SeatAction(1, "call", 0); !ok {
+		t.Fatalf("final call failed: %s", msg)
+	}
+	if g.GamePhase != PhaseDiscard {
+		t.Errorf("expected PhaseDiscard once all seats matched, got %s", g.GamePhase)
+	}
+	if g.Pot != 30+3*20 {
+		t.Errorf("expected pot 90, got %d", g.Pot)
+	}
+}
+
+func TestMultiwayFoldAwardsLastPlayer(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+
+	g.SeatAction(0, "bet", 10)
+	g.SeatAction(1, "fold", 0)
+	if g.GamePhase != PhasePreDrawBetting || g.TurnIndex != 2 {
+		t.Fatalf("expected seat 2 to act after a fold, got %s turn %d", g.GamePhase, g.TurnIndex)
+	}
+	g.SeatAction(2, "fold", 0)
+
+	if g.GamePhase != PhaseComplete {
+		t.Fatalf("expected PhaseComplete, got %s", g.GamePhase)
+	}
+	if g.Winner != "Seat 0" {
+		t.Errorf("expected Seat 0 to win, got %s", g.Winner)
+	}
+	if g.Players[0].Sanity != 120 {
+		t.Errorf("expected Seat 0 to end with 120, got %d", g.Players[0].Sanity)
+	}
+}
+
+func TestMultiwayShowdownRanksAllHands(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+
+	g.RoundStates[0].Hand = Hand{{Hearts, "2"}, {Spades, "2"}, {Clubs, "7"}, {Diamonds, "9"}, {Hearts, "jack"}}
+	g.RoundStates[1].Hand = Hand{{Hearts, "king"}, {Spades, "king"}, {Clubs, "king"}, {Diamonds, "4"}, {Hearts, "5"}}
+	g.RoundStates[2].Hand = Hand{{Clubs, "ace"}, {Spades, "queen"}, {Clubs, "8"}, {Diamonds, "6"}, {Hearts, "3"}}
+	g.CompleteShowdown()
+
+	if g.Winner != "Seat 1" {
+		t.Errorf("expected Seat 1 to win with trips, got %s", g.Winner)
+	}
+	if g.Players[1].Sanity != 120 {
+		t.Errorf("expected Seat 1 to collect the pot, got %d", g.Players[1].Sanity)
+	}
+}
+
+func TestDiscardFollowsTurnOrder(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+	for seat := 0; seat < 3; seat++ {
+		g.SeatAction(seat, "check", 0)
+	}
+	if g.GamePhase != PhaseDiscard {
+		t.Fatalf("expected PhaseDiscard, got %s", g.GamePhase)
+	}
+
+	if ok, _ := g.SeatDiscard(1, []int{0}); ok {
+		t.Errorf("seat 1 should not draw before seat 0")
+	}
+	for seat := 0; seat < 3; seat++ {
+		if ok, msg := g.SeatDiscard(seat, []int{0}); !ok {
+			t.Fatalf("seat %d discard failed: %s", seat, msg)
+		}
+	}
+	if g.GamePhase != PhasePostDrawBetting || g.TurnIndex != 0 {
+		t.Errorf("expected post-draw betting starting at seat 0, got %s turn %d", g.GamePhase, g.TurnIndex)
+	}
+}
+
+func TestAIActsFirstAfterButtonMoves(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+	g.PlayerAction("fold", 0)
+
+	g.NewRound()
+	g.CollectAnte(10)
+	if g.TurnIndex != 1 {
+		t.Fatalf("expected the Ancient One to act first, got seat %d", g.TurnIndex)
+	}
+	if ok, _ := g.PlayerAction("check", 0); ok {
+		t.Errorf("human should not be able to act before the Ancient One")
+	}
+
+	g.OpponentTurn()
+	if g.GamePhase == PhasePreDrawBetting && g.TurnIndex != 0 {
+		t.Errorf("expected the action back on the human, got seat %d", g.TurnIndex)
+	}
+}
+
+var (
+	handTrips   = Hand{{Hearts, "king"}, {Spades, "king"}, {Clubs, "king"}, {Diamonds, "4"}, {Hearts, "5"}}
+	handPair    = Hand{{Hearts, "2"}, {Spades, "2"}, {Clubs, "7"}, {Diamonds, "9"}, {Hearts, "jack"}}
+	handHigh    = Hand{{Clubs, "ace"}, {Spades, "queen"}, {Clubs, "8"}, {Diamonds, "6"}, {Hearts, "3"}}
+	handHighToo = Hand{{Diamonds, "ace"}, {Hearts, "queen"}, {Diamonds, "8"}, {Spades, "6"}, {Clubs, "3"}}
+)
+
+func TestCallAllInForLess(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.Players[1].Sanity = 30
+	g.CollectAnte(10)
+
+	g.SeatAction(0, "bet", 50)
+	if ok, msg := g.SeatAction(1, "call", 0); !ok {
+		t.Fatalf("short call should go all in, got: %s", msg)
+	}
+	if !g.RoundStates[1].AllIn || g.Players[1].Sanity != 0 {
+		t.Fatalf("expected seat 1 all in, got sanity %d", g.Players[1].Sanity)
+	}
+	if len(g.Pots) != 2 || g.Pots[0].Amount != 60 || g.Pots[1].Amount != 30 {
+		t.Fatalf("expected main pot 60 and uncalled 30, got %+v", g.Pots)
+	}
+
+	// No one left to bet against, so the hand runs out after the draw
+	g.SeatDiscard(0, nil)
+	g.RoundStates[0].Hand = handPair
+	g.RoundStates[1].Hand = handTrips
+	g.SeatDiscard(1, nil)
+	if g.GamePhase != PhaseComplete {
+		t.Fatalf("expected the hand to complete, got %s", g.GamePhase)
+	}
+
+	if g.Players[1].Sanity != 60 {
+		t.Errorf("expected seat 1 to win the main pot of 60, got %d", g.Players[1].Sanity)
+	}
+	if g.Players[0].Sanity != 70 {
+		t.Errorf("expected seat 0 to get the uncalled 30 back (70), got %d", g.Players[0].Sanity)
+	}
+}
+
+func TestSidePotsAwardedSeparately(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.Players[0].Sanity = 30
+	g.Players[1].Sanity = 60
+	g.CollectAnte(10)
+
+	g.SeatAction(0, "allin", 0) // 20 more
+	g.SeatAction(1, "allin", 0) // 50 more
+	g.SeatAction(2, "call", 0)  // 50
+
+	want := []Pot{
+		{Amount: 90, Eligible: []int{0, 1, 2}},
+		{Amount: 60, Eligible: []int{1, 2}},
+	}
+	if len(g.Pots) != len(want) {
+		t.Fatalf("expected %d pots, got %+v", len(want), g.Pots)
+	}
+	for i, p := range want {
+		if g.Pots[i].Amount != p.Amount || fmt.Sprint(g.Pots[i].Eligible) != fmt.Sprint(p.Eligible) {
+			t.Errorf("pot %d: expected %+v, got %+v", i, p, g.Pots[i])
+		}
+	}
+
+	// Short stack has the best hand, middle stack beats the big stack
+	g.RoundStates[0].Hand = handTrips
+	g.RoundStates[1].Hand = handPair
+	g.RoundStates[2].Hand = handHigh
+	g.CompleteShowdown()
+
+	if g.Players[0].Sanity != 90 {
+		t.Errorf("expected seat 0 to win the main pot (90), got %d", g.Players[0].Sanity)
+	}
+	if g.Players[1].Sanity != 60 {
+		t.Errorf("expected seat 1 to win the side pot (60), got %d", g.Players[1].Sanity)
+	}
+	if g.Players[2].Sanity != 40 {
+		t.Errorf("expected seat 2 to win nothing (40), got %d", g.Players[2].Sanity)
+	}
+	if g.Pot != 0 || g.Pots != nil {
+		t.Errorf("expected pots to be emptied, got %d %+v", g.Pot, g.Pots)
+	}
+}
+
+func TestSplitPotOddChip(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(11)
+	g.SeatAction(0, "check", 0)
+	g.SeatAction(1, "check", 0)
+	g.SeatAction(2, "fold", 0) // 33 in the pot
+
+	g.RoundStates[0].Hand = handHigh
+	g.RoundStates[1].Hand = handHighToo
+	g.CompleteShowdown()
+
+	// Button is seat 2, so seat 0 is first to its left and gets the odd chip
+	if g.Players[0].Sanity != 89+17 || g.Players[1].Sanity != 89+16 {
+		t.Errorf("expected 106/105 split, got %d/%d", g.Players[0].Sanity, g.Players[1].Sanity)
+	}
+	if g.Winner != "tie" {
+		t.Errorf("expected tie, got %s", g.Winner)
+	}
+}
+
+func TestShowdownResultNamesTheHandThatWon(t *testing.T) {
+	g, err := NewTable([]*Player{NewHumanPlayer("p"), NewAIPlayer(0), NewAIPlayer(1)})
+	if err != nil {
+		t.Fatalf("NewTable: %v", err)
+	}
+	g.CollectAnte(10)
+	g.RoundStates[0].Hand = handPair
+	g.RoundStates[1].Hand = handHigh
+	g.RoundStates[2].Hand = handTrips
+	g.CompleteShowdown()
+
+	result, ok := g.ShowdownResult(0)
+	if !ok {
+		t.Fatal("expected a result for the human's shown down hand")
+	}
+	if result.Winner != "opponent" || !strings.HasPrefix(result.Message, "The Crawling Chaos wins with Three of a Kind!") {
+		t.Errorf("expected the lesser horror's trips to beat the pair, got %q (%s)", result.Message, result.Winner)
+	}
+	if _, ok := g.ShowdownResult(5); ok {
+		t.Errorf("expected no result for a seat not in the showdown")
+	}
+}
+
+func TestShowdownMessageNamesTheLesserHorror(t *testing.T) {
+	g, err := NewTable([]*Player{NewAIPlayer(1), NewHumanPlayer("p")})
+	if err != nil {
+		t.Fatalf("NewTable: %v", err)
+	}
+	g.CollectAnte(10)
+	g.RoundStates[0].Hand = handPair
+	g.RoundStates[1].Hand = handTrips
+	g.CompleteShowdown()
+
+	if want := "You win with Three of a Kind! The Crawling Chaos had One Pair."; g.LastAction != want {
+		t.Errorf("expected %q, got %q", want, g.LastAction)
+	}
+}
+
+func TestESPAlwaysHasItsMatch(t *testing.T) {
+	for seed := uint64(1); seed <= 2000; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		g.StartESP()
+		if g.ESP.Hand1[g.ESP.MatchIndex1].Rank != g.ESP.Hand2[g.ESP.MatchIndex2].Rank {
+			t.Fatalf("seed %d: %v and %v do not match at %d and %d", seed, g.ESP.Hand1, g.ESP.Hand2, g.ESP.MatchIndex1, g.ESP.MatchIndex2)
+		}
+	}
+}
+
+func TestESPTrainsTheHumanWhereverSeated(t *testing.T) {
+	g, err := NewTable([]*Player{NewAIPlayer(0), NewHumanPlayer("p")})
+	if err != nil {
+		t.Fatalf("NewTable: %v", err)
+	}
+	if ok, msg := g.StartESP(); !ok {
+		t.Fatalf("StartESP: %s", msg)
+	}
+	i, j := g.ESP.MatchIndex1, 0
+	for g.ESP.Hand2[j].Rank == g.ESP.Hand1[i].Rank {
+		j++
+	}
+	g.GuessESP(i, j)
+	for j = 0; g.ESP.Hand2[j].Rank != g.ESP.Hand1[i].Rank; j++ {
+	}
+	if ok, msg := g.GuessESP(i, j); !ok {
+		t.Fatalf("expected the match to be found: %s", msg)
+	}
+
+	if g.Players[1].Sanity != 100-5+15 || g.Players[0].Sanity != 100 {
+		t.Errorf("expected the human in seat 1 to be charged and credited, got %d and %d", g.Players[1].Sanity, g.Players[0].Sanity)
+	}
+	for _, e := range g.Events {
+		if e.Seat != 1 || e.PlayerID != "p" {
+			t.Errorf("expected %s to be the human's, got seat %d", e.Type, e.Seat)
+		}
+	}
+}
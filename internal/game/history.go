@@ -0,0 +1,104 @@
+package game
+
+import "time"
+
+// EventType identifies an entry in a hand's history.
+type EventType string
+
+const (
+	EventSeat       EventType = "seat"       // Seat held PlayerID with Amount sanity when the hand began, playing Personality if AI; Stats are what the AI knew of them
+	EventButton     EventType = "button"     // Seat held the dealer button
+	EventRules      EventType = "rules"      // The hand is a Detail variant under Rules, for stakes of Amount (the ante, or the big blind)
+	EventAnte       EventType = "ante"       // Seat paid Amount
+	EventBlind      EventType = "blind"      // Seat posted the Detail ("small"/"big"/"bring_in") forced bet of Amount
+	EventSitOut     EventType = "sit_out"    // Seat could not pay the ante
+	EventRegenerate EventType = "regenerate" // AI seat restored to Amount sanity
+	EventDeal       EventType = "deal"       // Seat was dealt Count cards, of which Cards face up
+	EventBoard      EventType = "board"      // Cards were dealt face up to the board
+	EventPhase      EventType = "phase"      // Table moved to phase Detail
+	EventCheck      EventType = "check"
+	EventCall       EventType = "call"   // Seat put in Amount
+	EventBet        EventType = "bet"    // Seat put in Amount
+	EventRaise      EventType = "raise"  // Seat put in Amount
+	EventAllIn      EventType = "all_in" // Seat put in Amount, its last sanity
+	EventFold       EventType = "fold"
+	EventDiscard    EventType = "discard"   // Seat threw away Count cards, at Indices
+	EventDraw       EventType = "draw"      // Seat received Count replacement cards
+	EventReshuffle  EventType = "reshuffle" // The deck ran out and the Count cards of the muck became a new one
+	EventShow       EventType = "show"      // Seat showed Cards, a Detail hand made from the ones at Indices if not all
+	EventAward      EventType = "award"     // Seat won Amount from a pot
+	EventStack      EventType = "stack"     // Seat ended the hand with Amount sanity
+	EventESPStart   EventType = "esp_start" // ESP round with theme Detail
+	EventESPGuess   EventType = "esp_guess" // Guess was Detail ("correct"/"wrong"), sanity changed by Amount; a correct one took Count guesses
+	EventESPExit    EventType = "esp_exit"
+)
+
+// TableSeat is used as Event.Seat for events that belong to the whole table.
+const TableSeat = -1
+
+// Event is one entry in the append-only history of a hand. Events only ever
+// carry information that was public when it happened; hole cards appear
+// only in EventShow at showdown.
+type Event struct {
+	Seq         int          `json:"seq"`
+	Type        EventType    `json:"type"`
+	Seat        int          `json:"seat"`
+	PlayerID    string       `json:"player_id,omitempty"`
+	Amount      int          `json:"amount,omitempty"`
+	Count       int          `json:"count,omitempty"`
+	Cards       Hand         `json:"cards,omitempty"`
+	Indices     []int        `json:"indices,omitempty"`
+	Detail      string       `json:"detail,omitempty"`
+	AI          bool         `json:"ai,omitempty"`
+	Personality Personality  `json:"personality,omitempty"`
+	Stats       *PlayerStats `json:"stats,omitempty"`
+	Rules       *Rules       `json:"rules,omitempty"`
+	Time        int64        `json:"time"` // Unix milliseconds
+}
+
+// HandRecord is the archived history of one hand of a game. Seed is the
+// game seed, filled in when a hand is exported for replay.
+type HandRecord struct {
+	GameID string  `json:"game_id"`
+	Number int     `json:"number"`
+	Seed   uint64  `json:"seed,omitempty"`
+	Events []Event `json:"events"`
+}
+
+// CurrentHand returns the history of the current (or most recently
+// finished) hand. Anything that happens between hands, like ESP training,
+// belongs to the hand before it.
+func (g *GameState) CurrentHand() *HandRecord {
+	return &HandRecord{
+		GameID: g.ID,
+		Number: g.HandNumber,
+		Events: append([]Event(nil), g.Events...),
+	}
+}
+
+// record appends an event for seat to the current hand.
+func (g *GameState) record(seat int, e Event) {
+	e.Seq = len(g.Events) + 1
+	e.Seat = seat
+	if seat >= 0 && seat < len(g.Players) {
+		e.PlayerID = g.Players[seat].ID
+	}
+	e.Time = time.Now().UnixMilli()
+	g.Events = append(g.Events, e)
+}
+
+// EventsFor returns the events of the current hand after seq, as viewerID
+// may see them: what the AI knew of other players is left out.
+func (g *GameState) EventsFor(viewerID string, seq int) []Event {
+	var events []Event
+	for _, e := range g.Events {
+		if e.Seq <= seq {
+			continue
+		}
+		if e.Stats != nil && (viewerID == "" || e.PlayerID != viewerID) {
+			e.Stats = nil
+		}
+		events = append(events, e)
+	}
+	return events
+}
@@ -0,0 +1,156 @@
+package game
+
+import "testing"
+
+// eventTypes lists the types of g's events for seat, in order.
+func eventTypes(g *GameState, seat int) []EventType {
+	var types []EventType
+	for _, e := range g.Events {
+		if e.Seat == seat {
+			types = append(types, e.Type)
+		}
+	}
+	return types
+}
+
+func TestHandHistoryRecordsHand(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	if g.HandNumber != 1 {
+		t.Fatalf("expected hand 1, got %d", g.HandNumber)
+	}
+
+	g.SeatAction(0, "bet", 10)
+	g.SeatAction(1, "raise", 10)
+	g.SeatAction(0, "call", 0)
+	g.SeatDiscard(0, []int{0, 1})
+	g.SeatDiscard(1, nil)
+	g.SeatAction(0, "check", 0)
+	g.SeatAction(1, "check", 0)
+
+	if g.GamePhase != PhaseComplete {
+		t.Fatalf("expected PhaseComplete, got %s", g.GamePhase)
+	}
+
+	want := []EventType{EventSeat, EventAnte, EventDeal, EventBet, EventCall, EventDiscard, EventDraw, EventCheck, EventShow}
+	got := eventTypes(g, 0)
+	if len(got) < len(want) {
+		t.Fatalf("expected at least %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("seat 0 events: expected %v, got %v", want, got)
+		}
+	}
+	if got[len(got)-1] != EventStack {
+		t.Errorf("expected the hand to end with seat 0's stack, got %v", got)
+	}
+
+	var awarded, phases int
+	for i, e := range g.Events {
+		if e.Seq != i+1 {
+			t.Errorf("event %d has seq %d", i, e.Seq)
+		}
+		switch e.Type {
+		case EventAward:
+			awarded += e.Amount
+		case EventPhase:
+			phases++
+		case EventRaise:
+			if e.Amount != 20 {
+				t.Errorf("expected raise to put in 20, got %d", e.Amount)
+			}
+		case EventDiscard:
+			if e.Seat == 0 && e.Count != 2 {
+				t.Errorf("expected seat 0 to discard 2, got %d", e.Count)
+			}
+		}
+		if e.Type != EventShow && len(e.Cards) > 0 {
+			t.Errorf("%s event should not carry cards", e.Type)
+		}
+	}
+	if awarded != 60 {
+		t.Errorf("expected awards to total the pot of 60, got %d", awarded)
+	}
+	if phases != 4 {
+		t.Errorf("expected 4 phase changes, got %d", phases)
+	}
+
+	// Showing again after the hand is over must not duplicate the log
+	n := len(g.Events)
+	g.CompleteShowdown()
+	if len(g.Events) != n {
+		t.Errorf("repeat showdown appended %d events", len(g.Events)-n)
+	}
+}
+
+func TestHandHistoryFoldHidesCards(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	g.SeatAction(0, "fold", 0)
+
+	for _, e := range g.Events {
+		if e.Type == EventShow {
+			t.Errorf("no hand should be shown when the pot is won by a fold")
+		}
+	}
+	got := eventTypes(g, 1)
+	if got[len(got)-2] != EventAward {
+		t.Errorf("expected seat 1 to be awarded the pot, got %v", got)
+	}
+}
+
+func TestHandHistoryStartsFreshEachHand(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+	g.PlayerAction("fold", 0)
+	g.StartESP()
+	g.ExitESP()
+
+	record := g.CurrentHand()
+	if record.Number != 1 {
+		t.Fatalf("expected hand 1, got %d", record.Number)
+	}
+	got := eventTypes(g, 0)
+	if got[len(got)-2] != EventESPStart || got[len(got)-1] != EventESPExit {
+		t.Errorf("ESP between hands should belong to the previous hand, got %v", got)
+	}
+
+	g.NewRound()
+	g.CollectAnte(10)
+	if g.HandNumber != 2 {
+		t.Errorf("expected hand 2, got %d", g.HandNumber)
+	}
+	if g.Events[0].Seq != 1 {
+		t.Errorf("expected a fresh log, first seq %d", g.Events[0].Seq)
+	}
+	for _, e := range g.Events {
+		if e.Type == EventFold || e.Type == EventESPStart {
+			t.Errorf("hand 2 log contains %s from hand 1", e.Type)
+		}
+	}
+}
+
+func TestEventsForHidesOthersStats(t *testing.T) {
+	g, err := NewTable([]*Player{NewHumanPlayer("a"), NewHumanPlayer("b")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Stats = map[string]*PlayerStats{"a": {Hands: 3}, "b": {Hands: 5}}
+	g.CollectAnte(10)
+
+	for _, e := range g.EventsFor("a", 0) {
+		if e.Type == EventSeat && (e.Stats != nil) != (e.PlayerID == "a") {
+			t.Errorf("seat %d: viewer a should see only their own stats, got %+v", e.Seat, e.Stats)
+		}
+	}
+	if g.Events[1].Stats == nil {
+		t.Errorf("redacting should not touch the log itself")
+	}
+
+	seq := len(g.Events)
+	g.SeatAction(g.TurnIndex, "check", 0)
+	if events := g.EventsFor("a", seq); len(events) != 1 || events[0].Type != EventCheck {
+		t.Errorf("expected only the check after seq %d, got %v", seq, events)
+	}
+}
@@ -0,0 +1,45 @@
+package game
+
+import "fmt"
+
+// postBlinds posts the blinds and opens the betting before the flop: the
+// small blind sits left of the button and the big blind left of that,
+// except heads-up, where the button posts the small blind. Action starts
+// left of the big blind, who acts last and may still raise.
+func (g *GameState) postBlinds(bigBlind int) {
+	g.enterPhase(PhasePreFlop)
+	small := g.nextSeat(g.DealerIndex, g.inHand)
+	if len(g.contenders()) == 2 && g.inHand(g.DealerIndex) {
+		small = g.DealerIndex
+	}
+	big := g.nextSeat(small, g.inHand)
+	g.postBlind(small, bigBlind/2, "small")
+	g.postBlind(big, bigBlind, "big")
+	g.CurrentBet = bigBlind
+	g.LastRaise, g.Raises = bigBlind, 1 // The big blind is the round's first bet
+	g.LastAction = fmt.Sprintf("Blinds posted: %d/%d", bigBlind/2, bigBlind)
+
+	g.startBetting(g.nextSeat(big, g.inHand))
+}
+
+// postBlind makes seat put in a blind of amount, or all it has if less.
+func (g *GameState) postBlind(seat, amount int, which string) {
+	amount = min(amount, g.Players[seat].Sanity)
+	g.wager(seat, amount)
+	g.record(seat, Event{Type: EventBlind, Amount: amount, Detail: which})
+}
+
+// dealStreet burns a card and deals n more to the board, opening the
+// betting round phase. Betting starts left of the button.
+func (g *GameState) dealStreet(phase GamePhase, n int) {
+	g.enterPhase(phase)
+	g.resetBets()
+	if len(g.Deck) > 0 {
+		g.Deck = g.Deck[1:]
+	}
+	cards := DealHand(&g.Deck, n)
+	g.Board = append(g.Board, cards...)
+	g.record(TableSeat, Event{Type: EventBoard, Cards: append(Hand(nil), cards...)})
+	g.LastAction = fmt.Sprintf("The %s: %s.", phase, describeCards(cards))
+	g.startBetting(g.nextSeat(g.DealerIndex, g.inHand))
+}
@@ -0,0 +1,210 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// newHoldemTable seats n humans for a game of Hold'em.
+func newHoldemTable(t *testing.T, n int) *GameState {
+	t.Helper()
+	g := newHumanTable(t, n)
+	if err := g.SetVariant(VariantHoldem); err != nil {
+		t.Fatalf("SetVariant: %v", err)
+	}
+	return g
+}
+
+// act makes the seat whose turn it is take action, failing the test if it
+// cannot.
+func act(t *testing.T, g *GameState, action string, amount int) {
+	t.Helper()
+	if ok, msg := g.SeatAction(g.TurnIndex, action, amount); !ok {
+		t.Fatalf("seat %d cannot %s in %s: %s", g.TurnIndex, action, g.GamePhase, msg)
+	}
+}
+
+func TestHoldemPhasesRoundTrip(t *testing.T) {
+	for _, p := range []GamePhase{PhasePreFlop, PhaseFlop, PhaseTurn, PhaseRiver} {
+		data, err := json.Marshal(p)
+		if err != nil {
+			t.Fatalf("marshal %v: %v", p, err)
+		}
+		var back GamePhase
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatalf("unmarshal %s: %v", data, err)
+		}
+		if back != p {
+			t.Errorf("%s came back as %s", data, back)
+		}
+	}
+}
+
+func TestHoldemHeadsUpBlinds(t *testing.T) {
+	g := newHoldemTable(t, 2)
+	g.CollectAnte(10)
+
+	// Heads-up the button (seat 1) posts the small blind and acts first
+	if g.GamePhase != PhasePreFlop {
+		t.Fatalf("expected preflop, got %s", g.GamePhase)
+	}
+	for i, rs := range g.RoundStates {
+		if len(rs.Hand) != 2 {
+			t.Errorf("seat %d dealt %d cards, want 2", i, len(rs.Hand))
+		}
+	}
+	if g.RoundStates[1].Bet != 5 || g.RoundStates[0].Bet != 10 {
+		t.Errorf("expected blinds 5 (button) and 10, got %d and %d", g.RoundStates[1].Bet, g.RoundStates[0].Bet)
+	}
+	if g.Pot != 15 || g.CurrentBet != 10 || g.TurnIndex != 1 {
+		t.Errorf("expected pot 15, bet 10 and the button to act, got %d, %d, seat %d", g.Pot, g.CurrentBet, g.TurnIndex)
+	}
+
+	// The big blind has the option once the small blind completes
+	act(t, g, "call", 0)
+	if g.GamePhase != PhasePreFlop || g.TurnIndex != 0 {
+		t.Fatalf("expected the big blind to have the option, got %s seat %d", g.GamePhase, g.TurnIndex)
+	}
+	act(t, g, "raise", 10)
+	act(t, g, "call", 0)
+
+	// After the flop the big blind acts first
+	if g.GamePhase != PhaseFlop || len(g.Board) != 3 || g.TurnIndex != 0 {
+		t.Errorf("expected the flop with seat 0 to act, got %s, %d cards, seat %d", g.GamePhase, len(g.Board), g.TurnIndex)
+	}
+}
+
+func TestHoldemPlaysFourBettingRounds(t *testing.T) {
+	g := newHoldemTable(t, 3)
+	g.CollectAnte(10)
+
+	// Button on seat 2: blinds on seats 0 and 1, seat 2 first to act
+	if g.RoundStates[0].Bet != 5 || g.RoundStates[1].Bet != 10 || g.TurnIndex != 2 {
+		t.Fatalf("expected blinds on seats 0 and 1 and seat 2 to act, got %d/%d seat %d", g.RoundStates[0].Bet, g.RoundStates[1].Bet, g.TurnIndex)
+	}
+	act(t, g, "call", 0)
+	act(t, g, "call", 0)
+	act(t, g, "check", 0)
+
+	streets := []struct {
+		phase GamePhase
+		board int
+	}{{PhaseFlop, 3}, {PhaseTurn, 4}, {PhaseRiver, 5}}
+	for _, s := range streets {
+		if g.GamePhase != s.phase || len(g.Board) != s.board {
+			t.Fatalf("expected %s with %d board cards, got %s with %d", s.phase, s.board, g.GamePhase, len(g.Board))
+		}
+		if g.TurnIndex != 0 {
+			t.Errorf("%s: expected seat 0 to act first, got %d", s.phase, g.TurnIndex)
+		}
+		for range 3 {
+			act(t, g, "check", 0)
+		}
+	}
+
+	if g.GamePhase != PhaseComplete {
+		t.Fatalf("expected the hand to be complete, got %s", g.GamePhase)
+	}
+	total := 0
+	for _, p := range g.Players {
+		total += p.Sanity
+	}
+	if total != 300 {
+		t.Errorf("expected sanity to be conserved, got %d", total)
+	}
+	if len(g.Deck) != 52-6-3-5 {
+		t.Errorf("expected a card burned before each street, %d left in the deck", len(g.Deck))
+	}
+}
+
+func TestHoldemShowdownUsesBoard(t *testing.T) {
+	g := newHoldemTable(t, 2)
+	g.StackDeck(Deck{
+		{Hearts, "2"}, {Clubs, "7"}, // Seat 0
+		{Hearts, "ace"}, {Diamonds, "king"}, // Seat 1
+		{Clubs, "3"}, {Spades, "king"}, {Clubs, "king"}, {Diamonds, "2"}, // Burn, flop
+		{Clubs, "4"}, {Spades, "2"}, // Burn, turn
+		{Clubs, "5"}, {Hearts, "9"}, // Burn, river
+	})
+	g.CollectAnte(10)
+	act(t, g, "call", 0)
+	for g.GamePhase != PhaseComplete {
+		act(t, g, "check", 0)
+	}
+
+	// Twos full of kings loses to kings full of twos
+	if g.Winner != "Seat 1" {
+		t.Errorf("expected Seat 1 to win, got %q: %s", g.Winner, g.LastAction)
+	}
+	for _, e := range g.Events {
+		if e.Type == EventShow && (len(e.Cards) != 7 || len(e.Indices) != 5) {
+			t.Errorf("seat %d showed %d cards using %v, want 7 using 5", e.Seat, len(e.Cards), e.Indices)
+		}
+	}
+}
+
+func TestHoldemRunsOutBoardWhenAllIn(t *testing.T) {
+	g := newHoldemTable(t, 2)
+	g.CollectAnte(10)
+	act(t, g, "allin", 0)
+	act(t, g, "call", 0)
+
+	if g.GamePhase != PhaseComplete || len(g.Board) != 5 {
+		t.Errorf("expected the board run out to showdown, got %s with %d cards", g.GamePhase, len(g.Board))
+	}
+}
+
+func TestSetVariantOnlyBetweenHands(t *testing.T) {
+	g := NewGame("p")
+	if err := g.SetVariant("stud-poker-from-beyond"); err == nil {
+		t.Errorf("expected an unknown variant to be refused")
+	}
+	g.CollectAnte(10)
+	if err := g.SetVariant(VariantHoldem); err == nil {
+		t.Errorf("expected a change mid-hand to be refused")
+	}
+}
+
+func TestHoldemViewShowsBoard(t *testing.T) {
+	g := newHoldemTable(t, 2)
+	g.CollectAnte(10)
+	act(t, g, "call", 0)
+	act(t, g, "check", 0)
+
+	v := g.View(g.Players[0].ID)
+	if v.Variant != VariantHoldem || len(v.Board) != 3 {
+		t.Errorf("expected a Hold'em view with the flop, got %q with %d cards", v.Variant, len(v.Board))
+	}
+	if v.RoundStates[1].Hand != nil || v.RoundStates[1].HandSize != 2 {
+		t.Errorf("expected the other hole cards hidden but counted")
+	}
+}
+
+func TestReplayHoldem(t *testing.T) {
+	for seed := uint64(1); seed <= 5; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		g.SetVariant(VariantHoldem)
+		playScripted(g)
+
+		if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+			t.Fatalf("seed %d: replay failed: %v", seed, err)
+		}
+	}
+}
+
+func TestBoardEquity(t *testing.T) {
+	rng := NewRNG(1)
+
+	// The nuts on the river cannot lose
+	nuts := Hand{{Spades, "ace"}, {Spades, "king"}, {Spades, "queen"}, {Spades, "jack"}, {Spades, "10"}, {Hearts, "2"}, {Clubs, "7"}}
+	if eq := boardEquity(nuts, 5, 2, Rules{}, 200, rng); eq != 1 {
+		t.Errorf("expected a royal flush to win every time, got %.2f", eq)
+	}
+
+	// Pocket aces are a big favourite heads-up before the flop
+	aces := Hand{{Spades, "ace"}, {Hearts, "ace"}}
+	if eq := boardEquity(aces, 0, 1, Rules{}, 2000, rng); eq < 0.8 || eq > 0.9 {
+		t.Errorf("expected pocket aces near 85%% heads-up, got %.2f", eq)
+	}
+}
@@ -0,0 +1,429 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Ranking is how a table ranks hands at showdown. The zero value is
+// RankingHigh.
+type Ranking string
+
+const (
+	RankingHigh         Ranking = "high"           // The best poker hand wins
+	RankingDeuceToSeven Ranking = "deuce_to_seven" // Lowball: the worst poker hand wins, aces high
+	RankingAceToFive    Ranking = "ace_to_five"    // Lowball: aces low, straights and flushes ignored
+	RankingHiLo         Ranking = "hilo"           // High and an ace-to-five low of eight or better split the pot
+)
+
+// ParseRanking checks that s names a ranking; empty is RankingHigh.
+func ParseRanking(s string) (Ranking, error) {
+	switch r := Ranking(s); r {
+	case "", RankingHigh:
+		return RankingHigh, nil
+	case RankingDeuceToSeven, RankingAceToFive, RankingHiLo:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown ranking %q", s)
+}
+
+// ranking returns the table's ranking, defaulting to high.
+func (r Rules) ranking() Ranking {
+	if r.Ranking == "" {
+		return RankingHigh
+	}
+	return r.Ranking
+}
+
+// lowball reports whether the lowest hand takes the whole pot.
+func (r Rules) lowball() bool {
+	return r.Ranking == RankingDeuceToSeven || r.Ranking == RankingAceToFive
+}
+
+// high returns the rules for ranking the high half of a hi-lo pot.
+func (r Rules) high() Rules {
+	r.Ranking = RankingHigh
+	return r
+}
+
+// LowStrength is a five-card hand's place as a low hand: higher is a better
+// (lower) hand and equal strengths tie. Zero is not a hand. Unlike
+// HandStrength it is not dense: it is lowMax less the hand's HandValue read
+// as a number in base 15.
+type LowStrength int
+
+// lowMax is one more than the largest HandValue as a number in base 15:
+// ranks up to five of a kind, then Primary, Secondary and five kickers.
+const lowMax = LowStrength(11 * 15 * 15 * 15 * 15 * 15 * 15 * 15)
+
+// lowStrength encodes v, which ranks hands the wrong way up for a low.
+func lowStrength(v HandValue) LowStrength {
+	key := int(v.Rank)*15 + v.Primary
+	key = key*15 + v.Secondary
+	for i := range 5 {
+		key *= 15
+		if i < len(v.Kickers) {
+			key += v.Kickers[i]
+		}
+	}
+	return lowMax - LowStrength(key)
+}
+
+// Value decodes the HandValue the low was made from. In ace-to-five the ace
+// counts as 1.
+func (s LowStrength) Value() HandValue {
+	key := int(lowMax - s)
+	var digits [7]int
+	for i := 6; i >= 0; i-- {
+		digits[i] = key % 15
+		key /= 15
+	}
+	v := HandValue{Rank: HandRank(key), Primary: digits[0], Secondary: digits[1]}
+	for _, k := range digits[2:] {
+		if k > 0 {
+			v.Kickers = append(v.Kickers, k)
+		}
+	}
+	return v
+}
+
+// DeuceToSeven scores a five-card hand as a deuce-to-seven low: ranked as
+// a high hand and turned upside down, except that the ace is always high,
+// so A-2-3-4-5 is not a straight. The best is 7-5-4-3-2 of mixed suits.
+func DeuceToSeven(hand []Card) LowStrength {
+	s := Strength(hand)
+	if s == 0 {
+		return 0
+	}
+	v := strengthValues[s]
+	if (v.Rank == Straight || v.Rank == StraightFlush) && v.Primary == 5 {
+		v = HandValue{Rank: HighCard, Kickers: []int{14, 5, 4, 3, 2}}
+		if s.Rank() == StraightFlush {
+			v.Rank = Flush
+		}
+	}
+	return lowStrength(v)
+}
+
+// AceToFive scores a five-card hand as an ace-to-five low: aces are low and
+// straights and flushes do not count, so the best is A-2-3-4-5 and any
+// unpaired hand beats any pair.
+func AceToFive(hand []Card) LowStrength {
+	if len(hand) != 5 {
+		return 0
+	}
+	var counts [14]int
+	for _, c := range hand {
+		if rankIndex(c.Rank) < 0 {
+			return 0
+		}
+		v := CardValue(c.Rank)
+		if v == 14 {
+			v = 1
+		}
+		counts[v]++
+	}
+	// Values grouped by how many of each, bigger groups first, then high
+	// to low, as in HandValue
+	var groups []int
+	for n := 4; n >= 1; n-- {
+		for v := 13; v >= 1; v-- {
+			if counts[v] == n {
+				groups = append(groups, v)
+			}
+		}
+	}
+	v := HandValue{Rank: HighCard, Kickers: groups}
+	switch {
+	case counts[groups[0]] == 4:
+		v = HandValue{Rank: FourOfAKind, Primary: groups[0], Secondary: groups[1]}
+	case counts[groups[0]] == 3 && counts[groups[1]] == 2:
+		v = HandValue{Rank: FullHouse, Primary: groups[0], Secondary: groups[1]}
+	case counts[groups[0]] == 3:
+		v = HandValue{Rank: ThreeOfAKind, Primary: groups[0], Kickers: groups[1:]}
+	case counts[groups[1]] == 2:
+		v = HandValue{Rank: TwoPair, Primary: groups[0], Secondary: groups[1], Kickers: groups[2:]}
+	case counts[groups[0]] == 2:
+		v = HandValue{Rank: OnePair, Primary: groups[0], Kickers: groups[1:]}
+	}
+	return lowStrength(v)
+}
+
+// CompareLowHands compares two hands as lows under a lowball ranking, or
+// the low half of a hi-lo pot, where a hand must be eight or better to
+// count. Hands of more than five cards play their best low five.
+func CompareLowHands(hand1, hand2 []Card, ranking Ranking) ComparisonResult {
+	return Rules{Ranking: ranking}.CompareLow(hand1, hand2)
+}
+
+// CompareLow is CompareLowHands under these rules.
+func (r Rules) CompareLow(hand1, hand2 []Card) ComparisonResult {
+	s1, _ := r.BestLow(hand1)
+	s2, _ := r.BestLow(hand2)
+	switch {
+	case s1 > s2:
+		return ResultHand1Wins
+	case s2 > s1:
+		return ResultHand2Wins
+	}
+	return ResultTie
+}
+
+// lowOf scores a five-card hand as a low under these rules, each wild card
+// taking whichever value not already in the hand makes the best low.
+func (r Rules) lowOf(hand []Card) LowStrength {
+	score := AceToFive
+	if r.Ranking == RankingDeuceToSeven {
+		score = DeuceToSeven
+	}
+	if !r.wilds() || !slices.ContainsFunc(hand, r.Wild) {
+		return score(hand)
+	}
+
+	var naturals Hand
+	for _, c := range hand {
+		if !r.Wild(c) {
+			naturals = append(naturals, c)
+		}
+	}
+	var free []Rank
+	for _, rank := range Ranks {
+		if !slices.ContainsFunc(naturals, func(c Card) bool { return c.Rank == rank }) {
+			free = append(free, rank)
+		}
+	}
+	// Wild cards never help a flush, so they take a suit that breaks one
+	suit := Suits[0]
+	if len(naturals) > 0 && naturals[0].Suit == suit {
+		suit = Suits[1]
+	}
+
+	var best LowStrength
+	filled := slices.Clone(naturals)
+	var try func(from, wilds int)
+	try = func(from, wilds int) {
+		if wilds == 0 {
+			best = max(best, score(filled))
+			return
+		}
+		for i := from; i < len(free); i++ {
+			s := suit
+			if len(naturals) == 0 && wilds%2 == 0 {
+				s = Suits[2] // Five wild cards make no flush either
+			}
+			filled = append(filled, Card{Suit: s, Rank: free[i]})
+			try(i+1, wilds-1)
+			filled = filled[:len(filled)-1]
+		}
+	}
+	try(0, len(hand)-len(naturals))
+	return best
+}
+
+// BestLow finds the best low five among cards, returning its strength and
+// their indices in cards. In hi-lo a low must be eight or better to count:
+// without one it returns 0, nil.
+func (r Rules) BestLow(cards []Card) (LowStrength, []int) {
+	s, used := bestFive(cards, r.lowOf)
+	if r.ranking() == RankingHiLo && !s.eightOrBetter() {
+		return 0, nil
+	}
+	return s, used
+}
+
+// eightOrBetter reports whether a low qualifies for half a hi-lo pot: five
+// different cards, none higher than an eight.
+func (s LowStrength) eightOrBetter() bool {
+	v := s.Value()
+	return s > 0 && v.Rank == HighCard && len(v.Kickers) == 5 && v.Kickers[0] <= 8
+}
+
+// compareHiLoForDisplay is CompareForDisplayAgainst for hi-lo: the high
+// half as usual, then who takes the low half, if either hand qualifies for
+// it.
+func (r Rules) compareHiLoForDisplay(playerHand, opponentHand []Card, opponent string) HandComparisonResult {
+	result := r.high().CompareForDisplayAgainst(playerHand, opponentHand, opponent)
+	result.PlayerHandName, result.OpponentHandName = r.HandName(playerHand), r.HandName(opponentHand)
+
+	playerLow, _ := r.BestLow(playerHand)
+	opponentLow, _ := r.BestLow(opponentHand)
+	lowWinner := "tie"
+	switch {
+	case playerLow == 0 && opponentLow == 0:
+		result.Message += " Neither hand makes an eight-or-better low."
+		return result
+	case playerLow > opponentLow:
+		lowWinner = "player"
+		result.Message += fmt.Sprintf(" You take the low with %s.", playerLow.Name())
+	case opponentLow > playerLow:
+		lowWinner = "opponent"
+		result.Message += fmt.Sprintf(" %s takes the low with %s.", opponent, opponentLow.Name())
+	default:
+		result.Message += fmt.Sprintf(" The low is split: both have %s.", playerLow.Name())
+	}
+	if lowWinner != result.Winner {
+		result.Winner = "tie"
+	}
+	return result
+}
+
+// valueNames names card values for low hands; aces count as 1 or 14.
+var valueNames = [15]string{"", "Ace", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Jack", "Queen", "King", "Ace"}
+
+// Name describes a low: by its top two cards, as in "Seven-Five Low", if it
+// is a plain high-card hand, otherwise by what spoils it, such as "One Pair".
+func (s LowStrength) Name() string {
+	if s == 0 {
+		return "No Low"
+	}
+	v := s.Value()
+	if v.Rank != HighCard || len(v.Kickers) < 2 {
+		return GetHandName(v.Rank)
+	}
+	return fmt.Sprintf("%s-%s Low", valueNames[v.Kickers[0]], valueNames[v.Kickers[1]])
+}
+
+// HandName names the hand cards make under these rules: the low in
+// lowball, and in hi-lo the high along with any qualifying low.
+func (r Rules) HandName(cards []Card) string {
+	if r.lowball() {
+		s, _ := r.BestLow(cards)
+		return s.Name()
+	}
+	s, _ := r.BestFive(cards)
+	name := GetHandName(s.Rank())
+	if r.ranking() == RankingHiLo {
+		if low, _ := r.BestLow(cards); low > 0 {
+			name += " and a " + low.Name()
+		}
+	}
+	return name
+}
+
+// used returns the indices of the five cards a hand of more than five
+// plays: its best low five in lowball, otherwise its best high five.
+func (r Rules) used(cards []Card) []int {
+	var used []int
+	if r.lowball() {
+		_, used = r.BestLow(cards)
+	} else {
+		_, used = r.BestFive(cards)
+	}
+	return used
+}
+
+// score is how good a five-card hand is under these rules, for averaging
+// over simulated draws. In hi-lo a qualifying low is worth about as much
+// as the best high hand.
+func (r Rules) score(hand []Card) float64 {
+	if r.lowball() {
+		return r.lowOf(hand).score()
+	}
+	score := r.Strength(hand).score() / strengthScores[len(strengthScores)-1]
+	if r.ranking() == RankingHiLo {
+		if low, _ := r.BestLow(hand); low > 0 {
+			score += float64(low) / float64(lowMax)
+		}
+	}
+	return score
+}
+
+// score is a low's worth for averaging over draws, on a scale like
+// ScoreHand's: nothing for a pair or worse, otherwise more the lower its
+// cards, the top card counting most.
+func (s LowStrength) score() float64 {
+	v := s.Value()
+	if s == 0 || v.Rank != HighCard {
+		return 0
+	}
+	score, weight := 0.0, 1.0
+	for _, k := range v.Kickers {
+		score += float64(15-k) * weight
+		weight /= 15
+	}
+	return score
+}
+
+// weak reports whether a hand shown down is nothing, so betting it was a
+// bluff: high card for a high hand, and in lowball a pair or worse, or
+// nothing better than ten low. In hi-lo it takes nothing for either half.
+func (r Rules) weak(hand []Card) bool {
+	if r.lowball() {
+		low, _ := r.BestLow(hand)
+		v := low.Value()
+		return low == 0 || v.Rank != HighCard || v.Kickers[0] > 10
+	}
+	if r.ranking() == RankingHiLo {
+		if low, _ := r.BestLow(hand); low > 0 {
+			return false
+		}
+	}
+	return r.Evaluate(hand).Rank == HighCard
+}
+
+// grade values a hand on the high hand scale, so strategies written for
+// high hands play lows sensibly: a seven low or better is as good as a
+// full house, an eight low as trips, a nine low as two pair and a ten low
+// as a pair of aces. A low spoiled by a pair or worse is high card.
+func (r Rules) grade(hand []Card) HandValue {
+	if !r.lowball() {
+		return r.Evaluate(hand)
+	}
+	low, _ := r.BestLow(hand)
+	v := low.Value()
+	if low == 0 || v.Rank != HighCard {
+		return HandValue{Rank: HighCard}
+	}
+	switch top := v.Kickers[0]; {
+	case top <= 7:
+		return HandValue{Rank: FullHouse, Primary: 14}
+	case top == 8:
+		return HandValue{Rank: ThreeOfAKind, Primary: 14}
+	case top == 9:
+		return HandValue{Rank: TwoPair, Primary: 14}
+	case top == 10:
+		return HandValue{Rank: OnePair, Primary: 14}
+	}
+	return HandValue{Rank: HighCard}
+}
+
+// lowDraw is the textbook lowball draw: stand pat on an eight low or
+// better, otherwise keep the wild cards and one of each value up to eight
+// (in deuce-to-seven the ace is high, so it goes) and draw the rest.
+func lowDraw(hand Hand, rules Rules) []int {
+	low, _ := rules.BestLow(hand)
+	if v := low.Value(); low > 0 && v.Rank == HighCard && v.Kickers[0] <= 8 {
+		return nil
+	}
+	var kept []Rank
+	var discards []int
+	for i, c := range hand {
+		v := CardValue(c.Rank)
+		if v == 14 && rules.Ranking != RankingDeuceToSeven {
+			v = 1
+		}
+		switch {
+		case rules.Wild(c):
+		case v <= 8 && !slices.Contains(kept, c.Rank):
+			kept = append(kept, c.Rank)
+		default:
+			discards = append(discards, i)
+		}
+	}
+	return discards
+}
+
+// lowCards counts the different values up to eight in hand, aces low.
+func lowCards(hand Hand) int {
+	var seen []int
+	for _, c := range hand {
+		v := CardValue(c.Rank)
+		if v == 14 {
+			v = 1
+		}
+		if v <= 8 && !slices.Contains(seen, v) {
+			seen = append(seen, v)
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,265 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+var (
+	deuceToSeven = Rules{Ranking: RankingDeuceToSeven}
+	aceToFive    = Rules{Ranking: RankingAceToFive}
+	hiLo         = Rules{Ranking: RankingHiLo}
+)
+
+// Hands for the lowball tests, named for their lows
+var (
+	sevenFive   = Hand{{Hearts, "7"}, {Spades, "5"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "2"}}
+	eightSix    = Hand{{Clubs, "8"}, {Diamonds, "6"}, {Hearts, "4"}, {Spades, "3"}, {Clubs, "2"}}
+	wheel       = Hand{{Spades, "ace"}, {Spades, "2"}, {Spades, "3"}, {Spades, "4"}, {Spades, "5"}}
+	sixStraight = Hand{{Hearts, "6"}, {Spades, "5"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "2"}}
+	nineLow     = Hand{{Hearts, "9"}, {Spades, "7"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "2"}}
+	pairOfTwos  = Hand{{Hearts, "2"}, {Spades, "2"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "5"}}
+	kingHigh    = Hand{{Hearts, "king"}, {Spades, "jack"}, {Clubs, "9"}, {Diamonds, "6"}, {Hearts, "3"}}
+)
+
+func TestDeuceToSeven(t *testing.T) {
+	tests := []struct {
+		name   string
+		better Hand
+		worse  Hand
+	}{
+		{"lower top card", sevenFive, eightSix},
+		{"straights count against", eightSix, sixStraight},
+		{"the ace is high, so the wheel is no straight and no good", kingHigh, wheel},
+		{"any unpaired hand beats a pair", kingHigh, pairOfTwos},
+	}
+	for _, tt := range tests {
+		if got := CompareLowHands(tt.better, tt.worse, RankingDeuceToSeven); got != ResultHand1Wins {
+			t.Errorf("%s: %v should beat %v, got %d", tt.name, tt.better, tt.worse, got)
+		}
+	}
+	if v := DeuceToSeven(wheel).Value(); v.Rank != Flush || v.Kickers[0] != 14 {
+		t.Errorf("expected the suited wheel to be an ace-high flush, got %s %v", GetHandName(v.Rank), v.Kickers)
+	}
+}
+
+func TestAceToFive(t *testing.T) {
+	tests := []struct {
+		name   string
+		better Hand
+		worse  Hand
+	}{
+		{"the wheel is the nuts, suited or not", wheel, sevenFive},
+		{"straights do not count", sixStraight, sevenFive},
+		{"lower top card", sevenFive, eightSix},
+		{"any unpaired hand beats a pair", kingHigh, pairOfTwos},
+	}
+	for _, tt := range tests {
+		if got := CompareLowHands(tt.better, tt.worse, RankingAceToFive); got != ResultHand1Wins {
+			t.Errorf("%s: %v should beat %v, got %d", tt.name, tt.better, tt.worse, got)
+		}
+	}
+	if CompareLowHands(sevenFive, sevenFive, RankingAceToFive) != ResultTie {
+		t.Errorf("expected identical lows to tie")
+	}
+}
+
+func TestLowNames(t *testing.T) {
+	tests := []struct {
+		rules Rules
+		hand  Hand
+		want  string
+	}{
+		{deuceToSeven, sevenFive, "Seven-Five Low"},
+		{deuceToSeven, sixStraight, "Straight"},
+		{aceToFive, wheel, "Five-Four Low"},
+		{aceToFive, pairOfTwos, "One Pair"},
+		{hiLo, sixStraight, "Straight and a Six-Five Low"},
+		{hiLo, nineLow, "High Card"},
+		{Rules{}, sevenFive, "High Card"},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.HandName(tt.hand); got != tt.want {
+			t.Errorf("%s %v: got %q, want %q", tt.rules.ranking(), tt.hand, got, tt.want)
+		}
+	}
+}
+
+func TestHiLoEightOrBetter(t *testing.T) {
+	if low, _ := hiLo.BestLow(nineLow); low != 0 {
+		t.Errorf("a nine low should not qualify, got %s", low.Name())
+	}
+	if low, _ := hiLo.BestLow(eightSix); low == 0 {
+		t.Errorf("an eight low should qualify")
+	}
+	if hiLo.CompareLow(nineLow, pairOfTwos) != ResultTie {
+		t.Errorf("expected hands without a low to tie for it")
+	}
+
+	// The best low of seven cards need not be the best high five
+	seven := Hand{{Hearts, "ace"}, {Hearts, "king"}, {Hearts, "queen"}, {Hearts, "jack"}, {Hearts, "10"}, {Spades, "2"}, {Clubs, "3"}}
+	if low, _ := hiLo.BestLow(seven); low != 0 {
+		t.Errorf("%v has only three low cards, got %s", seven, low.Name())
+	}
+	board := Hand{{Clubs, "4"}, {Diamonds, "7"}, {Spades, "5"}, {Hearts, "king"}, {Hearts, "queen"}}
+	low, used := hiLo.BestLow(append(Hand{{Hearts, "ace"}, {Spades, "2"}}, board...))
+	if low.Name() != "Seven-Five Low" || len(used) != 5 {
+		t.Errorf("expected a seven-five low using five cards, got %s using %v", low.Name(), used)
+	}
+}
+
+func TestWildLows(t *testing.T) {
+	jokerLow := Hand{{Hearts, "8"}, {Spades, "5"}, {Clubs, "4"}, {Diamonds, "3"}, {"red", Joker}}
+	rules := Rules{Jokers: true, Ranking: RankingAceToFive}
+	if got := rules.HandName(jokerLow); got != "Eight-Five Low" {
+		t.Errorf("expected the joker to play as an ace, got %s", got)
+	}
+
+	// In deuce-to-seven the joker must not complete the straight
+	straightDraw := Hand{{Hearts, "6"}, {Spades, "5"}, {Clubs, "4"}, {Diamonds, "3"}, {"red", Joker}}
+	rules.Ranking = RankingDeuceToSeven
+	if got := rules.HandName(straightDraw); got != "Eight-Six Low" {
+		t.Errorf("expected the joker to play as an eight, got %s", got)
+	}
+}
+
+func TestLowballDraw(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		hand  Hand
+		want  []int
+	}{
+		{"pat eight", aceToFive, eightSix, nil},
+		{"pair and a king", aceToFive, Hand{{Hearts, "2"}, {Spades, "2"}, {Clubs, "4"}, {Diamonds, "king"}, {Hearts, "5"}}, []int{1, 3}},
+		{"ace is low", aceToFive, Hand{{Hearts, "ace"}, {Spades, "9"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "5"}}, []int{1}},
+		{"ace is high", deuceToSeven, Hand{{Hearts, "ace"}, {Spades, "7"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "5"}}, []int{0}},
+		{"hi-lo with four low cards", hiLo, Hand{{Hearts, "ace"}, {Spades, "king"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "5"}}, []int{1}},
+		{"hi-lo with a pair", hiLo, Hand{{Hearts, "king"}, {Spades, "king"}, {Clubs, "4"}, {Diamonds, "9"}, {Hearts, "jack"}}, []int{2, 3, 4}},
+	}
+	for _, tt := range tests {
+		if got := typicalDraw(tt.hand, tt.rules); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// The AI throws its high cards in lowball
+	if got := DefaultAI.ChooseDiscard(Hand{{Hearts, "king"}, {Spades, "queen"}, {Clubs, "4"}, {Diamonds, "3"}, {Hearts, "2"}}, deuceToSeven, NewRNG(1)); fmt.Sprint(got) != "[0 1]" {
+		t.Errorf("expected the AI to draw two to 4-3-2, got %v", got)
+	}
+}
+
+// playShowdown deals a stacked heads-up hand of draw under rules and checks
+// it down without drawing.
+func playShowdown(t *testing.T, rules Rules, deck Deck) *GameState {
+	t.Helper()
+	g := newHumanTable(t, 2)
+	if err := g.SetRules(rules); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	g.StackDeck(deck)
+	g.CollectAnte(10)
+	for g.GamePhase != PhaseComplete {
+		if g.GamePhase == PhaseDiscard {
+			g.SeatDiscard(g.TurnIndex, nil)
+			continue
+		}
+		act(t, g, "check", 0)
+	}
+	return g
+}
+
+func TestLowballShowdown(t *testing.T) {
+	g := playShowdown(t, deuceToSeven, append(append(Deck{}, kingHigh...), sevenFive...))
+	if g.Winner != "Seat 1" {
+		t.Errorf("expected the seven low to win, got %q: %s", g.Winner, g.LastAction)
+	}
+	for _, e := range g.Events {
+		if e.Type == EventShow && e.Seat == 1 && e.Detail != "Seven-Five Low" {
+			t.Errorf("expected the low named in the show event, got %q", e.Detail)
+		}
+	}
+}
+
+func TestHiLoSplitsPot(t *testing.T) {
+	flush := Hand{{Clubs, "king"}, {Clubs, "jack"}, {Clubs, "9"}, {Clubs, "6"}, {Clubs, "3"}}
+	g := playShowdown(t, hiLo, append(append(Deck{}, flush...), eightSix...))
+
+	// Antes of 10 each: the flush takes the high half, the eight the low
+	if g.Players[0].Sanity != 100 || g.Players[1].Sanity != 100 {
+		t.Errorf("expected the pot split evenly, got %d and %d", g.Players[0].Sanity, g.Players[1].Sanity)
+	}
+	if g.Winner != "tie" {
+		t.Errorf("expected no single winner, got %q", g.Winner)
+	}
+
+	// Without a qualifying low the high scoops
+	g = playShowdown(t, hiLo, append(append(Deck{}, flush...), nineLow...))
+	if g.Players[0].Sanity != 110 || g.Winner != "Seat 0" {
+		t.Errorf("expected the flush to scoop, got %d for %q: %s", g.Players[0].Sanity, g.Winner, g.LastAction)
+	}
+}
+
+func TestHiLoOddChipGoesHigh(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.SetRules(hiLo)
+	g.Pots = nil
+	for _, p := range g.Players {
+		p.Sanity = 0
+	}
+	flush := Hand{{Clubs, "king"}, {Clubs, "jack"}, {Clubs, "9"}, {Clubs, "6"}, {Clubs, "3"}}
+	g.RoundStates[0].Hand = flush
+	g.RoundStates[1].Hand = eightSix
+	g.RoundStates[2].Hand = kingHigh
+	g.Pots = []Pot{{Amount: 31, Eligible: []int{0, 1, 2}}}
+	g.GamePhase = PhaseShowdown
+	g.CompleteShowdown()
+
+	if g.Players[0].Sanity != 16 || g.Players[1].Sanity != 15 {
+		t.Errorf("expected 16 to the high and 15 to the low, got %d and %d", g.Players[0].Sanity, g.Players[1].Sanity)
+	}
+}
+
+func TestHiLoDisplay(t *testing.T) {
+	flush := Hand{{Clubs, "king"}, {Clubs, "jack"}, {Clubs, "9"}, {Clubs, "6"}, {Clubs, "3"}}
+	result := hiLo.CompareForDisplay(flush, eightSix)
+	want := "You win with Flush! The Ancient One had High Card. The Ancient One takes the low with Eight-Six Low."
+	if result.Message != want || result.Winner != "tie" {
+		t.Errorf("got %q (%s)", result.Message, result.Winner)
+	}
+	if result := deuceToSeven.CompareForDisplay(sevenFive, eightSix); result.Message != "You win with Seven-Five Low! The Ancient One had Eight-Six Low." {
+		t.Errorf("got %q", result.Message)
+	}
+}
+
+func TestHiLoEquitySplits(t *testing.T) {
+	flush := Hand{{Clubs, "king"}, {Clubs, "jack"}, {Clubs, "9"}, {Clubs, "6"}, {Clubs, "3"}}
+	if got := potShare(flush, []Hand{eightSix}, hiLo); got != 0.5 {
+		t.Errorf("expected half the pot for the high, got %.2f", got)
+	}
+	if got := potShare(flush, []Hand{nineLow}, hiLo); got != 1 {
+		t.Errorf("expected the whole pot without a low against it, got %.2f", got)
+	}
+}
+
+func TestReplayLowball(t *testing.T) {
+	for _, rules := range []Rules{deuceToSeven, aceToFive, hiLo} {
+		g := NewGame("p")
+		g.SetSeed(3)
+		g.SetRules(rules)
+		playScripted(g)
+
+		data, err := json.Marshal(g.CurrentHand())
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var hand HandRecord
+		if err := json.Unmarshal(data, &hand); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if _, err := Replay(&hand, g.Seed, nil); err != nil {
+			t.Errorf("%s: replay failed: %v", rules.ranking(), err)
+		}
+	}
+}
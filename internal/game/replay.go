@@ -0,0 +1,187 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Replay re-deals a recorded hand from the game seed and re-executes it
+// action by action. Human actions are taken from the record; AI seats
+// decide again with the same RNG, so any difference from what was recorded
+// means the engine (or the record) is not what it claims to be. step, if
+// not nil, is called with the game after every recorded event is matched.
+// Replay stops at the first ESP event, since ESP happens between hands.
+func Replay(rec *HandRecord, seed uint64, step func(e Event, g *GameState)) (*GameState, error) {
+	g, err := replayTable(rec, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	events := handEvents(rec.Events)
+	for i := 0; i < len(events); {
+		want := events[i]
+
+		// The engine already produced this event: it must match
+		if i < len(g.Events) {
+			if err := sameEvent(g.Events[i], want); err != nil {
+				return g, fmt.Errorf("event %d: %w", want.Seq, err)
+			}
+			if step != nil {
+				step(want, g)
+			}
+			i++
+			continue
+		}
+
+		// Otherwise it is a decision we have to make the engine take
+		if err := g.replayAction(want); err != nil {
+			return g, fmt.Errorf("event %d: %w", want.Seq, err)
+		}
+		if len(g.Events) <= i {
+			return g, fmt.Errorf("event %d: %s by seat %d produced nothing", want.Seq, want.Type, want.Seat)
+		}
+	}
+
+	if len(g.Events) > len(events) {
+		extra := g.Events[len(events)]
+		return g, fmt.Errorf("replay went further than the record: unexpected %s by seat %d", extra.Type, extra.Seat)
+	}
+	return g, nil
+}
+
+// replayTable seats the players recorded at the start of the hand and
+// deals it.
+func replayTable(rec *HandRecord, seed uint64) (*GameState, error) {
+	var players []*Player
+	stats := make(map[string]*PlayerStats)
+	dealer, ante := -1, 0
+	var variant Variant
+	var rules Rules
+	for _, e := range rec.Events {
+		switch e.Type {
+		case EventSeat:
+			players = append(players, &Player{ID: e.PlayerID, Name: e.Detail, IsAI: e.AI, Sanity: e.Amount, Personality: e.Personality})
+			if e.Stats != nil {
+				known := *e.Stats
+				stats[e.PlayerID] = &known
+			}
+		case EventButton:
+			dealer = e.Seat
+		case EventRules:
+			variant, ante = Variant(e.Detail), e.Amount
+			if e.Rules != nil {
+				rules = *e.Rules
+			}
+		case EventAnte:
+			// Hands recorded before the rules were logged
+			if ante == 0 {
+				ante = e.Amount
+			}
+		}
+	}
+	if len(players) == 0 || dealer < 0 {
+		return nil, fmt.Errorf("hand %d has no starting seats recorded", rec.Number)
+	}
+
+	g, err := NewTable(players)
+	if err != nil {
+		return nil, err
+	}
+	g.ID = rec.GameID
+	g.Stats = stats
+	g.Variant = variant
+	g.Rules = rules
+	g.SetSeed(seed)
+	g.HandNumber = rec.Number - 1
+	g.DealerIndex = dealer
+	g.CollectAnte(ante)
+	return g, nil
+}
+
+// replayAction makes the seat of a recorded action take it again.
+func (g *GameState) replayAction(e Event) error {
+	if e.Seat < 0 || e.Seat >= len(g.Players) {
+		return fmt.Errorf("expected the engine to produce %s", e.Type)
+	}
+
+	if g.Players[e.Seat].IsAI {
+		if !g.isBetting() || g.TurnIndex != e.Seat {
+			return fmt.Errorf("recorded %s by AI seat %d, but it is not its turn", e.Type, e.Seat)
+		}
+		g.aiAct(e.Seat)
+		return nil
+	}
+
+	toCall := g.CurrentBet - g.RoundStates[e.Seat].Bet
+	var ok bool
+	var msg string
+	switch e.Type {
+	case EventCheck:
+		ok, msg = g.SeatAction(e.Seat, "check", 0)
+	case EventCall:
+		ok, msg = g.SeatAction(e.Seat, "call", 0)
+	case EventBet, EventRaise:
+		// Whichever is legal: hands from before bets and raises were told
+		// apart recorded bets over the big blind
+		verb := "bet"
+		if g.CurrentBet > 0 {
+			verb = "raise"
+		}
+		ok, msg = g.SeatAction(e.Seat, verb, e.Amount-toCall)
+	case EventAllIn:
+		ok, msg = g.SeatAction(e.Seat, "allin", 0)
+	case EventFold:
+		ok, msg = g.SeatAction(e.Seat, "fold", 0)
+	case EventDiscard:
+		ok, msg = g.SeatDiscard(e.Seat, e.Indices)
+	default:
+		return fmt.Errorf("expected the engine to produce %s for seat %d", e.Type, e.Seat)
+	}
+	if !ok {
+		return fmt.Errorf("seat %d cannot %s: %s", e.Seat, e.Type, msg)
+	}
+	return nil
+}
+
+// handEvents trims a record to the hand itself, dropping ESP training that
+// followed it.
+func handEvents(events []Event) []Event {
+	for i, e := range events {
+		switch e.Type {
+		case EventESPStart, EventESPGuess, EventESPExit:
+			return events[:i]
+		}
+	}
+	return events
+}
+
+// sameEvent compares two events, ignoring when they happened.
+func sameEvent(got, want Event) error {
+	if got.Type != want.Type || got.Seat != want.Seat || got.PlayerID != want.PlayerID ||
+		got.Amount != want.Amount || got.Count != want.Count || got.Detail != want.Detail ||
+		got.AI != want.AI || got.Personality != want.Personality ||
+		(got.Stats == nil) != (want.Stats == nil) || (got.Stats != nil && *got.Stats != *want.Stats) ||
+		(got.Rules == nil) != (want.Rules == nil) || (got.Rules != nil && *got.Rules != *want.Rules) ||
+		!slices.Equal(got.Cards, want.Cards) || !slices.Equal(got.Indices, want.Indices) {
+		return fmt.Errorf("replayed %s, recorded %s", describeEvent(got), describeEvent(want))
+	}
+	return nil
+}
+
+// describeEvent renders an event for diagnostics.
+func describeEvent(e Event) string {
+	s := fmt.Sprintf("%s seat=%d", e.Type, e.Seat)
+	if e.Amount != 0 {
+		s += fmt.Sprintf(" amount=%d", e.Amount)
+	}
+	if e.Count != 0 {
+		s += fmt.Sprintf(" count=%d", e.Count)
+	}
+	if len(e.Cards) > 0 {
+		s += fmt.Sprintf(" cards=%v", e.Cards)
+	}
+	if e.Detail != "" {
+		s += fmt.Sprintf(" %q", e.Detail)
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplayReproducesHand(t *testing.T) {
+	for seed := uint64(1); seed <= 10; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		playScripted(g)
+		// A second hand so the button and hand number have moved
+		g.NewRound()
+		playScripted(g)
+
+		rec := g.CurrentHand()
+		replayed, err := Replay(rec, g.Seed, nil)
+		if err != nil {
+			t.Fatalf("seed %d: replay failed: %v", seed, err)
+		}
+		for i, p := range g.Players {
+			if replayed.Players[i].Sanity != p.Sanity {
+				t.Errorf("seed %d: seat %d ended with %d in replay, %d recorded", seed, i, replayed.Players[i].Sanity, p.Sanity)
+			}
+		}
+	}
+}
+
+func TestReplayStepsThroughEveryEvent(t *testing.T) {
+	g := NewGame("p")
+	g.SetSeed(3)
+	playScripted(g)
+	g.StartESP()
+	g.ExitESP()
+
+	var seen int
+	_, err := Replay(g.CurrentHand(), g.Seed, func(e Event, _ *GameState) {
+		seen++
+		if e.Seq != seen {
+			t.Errorf("expected step %d, got seq %d", seen, e.Seq)
+		}
+	})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if want := len(handEvents(g.Events)); seen != want {
+		t.Errorf("expected %d steps (ESP excluded), got %d", want, seen)
+	}
+}
+
+func TestReplayDetectsTampering(t *testing.T) {
+	g := NewGame("p")
+	g.SetSeed(5)
+	playScripted(g)
+
+	// Claim the hand was dealt from a different seed
+	if _, err := Replay(g.CurrentHand(), 6, nil); err == nil {
+		t.Errorf("expected replay with the wrong seed to fail")
+	}
+
+	// Doctor an award
+	rec := g.CurrentHand()
+	for i, e := range rec.Events {
+		if e.Type == EventStack {
+			rec.Events[i].Amount += 50
+			break
+		}
+	}
+	_, err := Replay(rec, g.Seed, nil)
+	if err == nil || !strings.Contains(err.Error(), "stack") {
+		t.Errorf("expected a stack mismatch, got %v", err)
+	}
+}
+
+func TestReplayUsesRecordedPersonality(t *testing.T) {
+	for _, info := range Personalities {
+		g := NewGame("p")
+		g.SetSeed(9)
+		g.SetPersonality(1, info.ID)
+		playScripted(g)
+
+		if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+			t.Errorf("%s: replay failed: %v", info.ID, err)
+		}
+	}
+}
+
+func TestPersonalityWaitsForTheNextHand(t *testing.T) {
+	g := NewGame("p")
+	g.SetSeed(9)
+	g.CollectAnte(10)
+	if err := g.SetPersonality(1, PersonalityManiac); err == nil {
+		t.Errorf("expected the personality to be refused during a hand")
+	}
+	for i := 0; i < 20 && g.handInProgress(); i++ {
+		if ok, _ := g.PlayerAction("check", 0); !ok {
+			g.PlayerAction("call", 0)
+		}
+		g.PerformDiscard(nil)
+		g.OpponentTurn()
+	}
+	if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+		t.Errorf("replay failed: %v", err)
+	}
+
+	if err := g.SetPersonality(1, PersonalityManiac); err != nil {
+		t.Fatalf("expected the personality to change between hands: %v", err)
+	}
+	g.NewRound()
+	playScripted(g)
+	if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+		t.Errorf("replay of the next hand failed: %v", err)
+	}
+	if g.Players[1].Personality != PersonalityManiac {
+		t.Errorf("expected the maniac to play the next hand")
+	}
+}
+
+func TestReplayUsesRecordedStats(t *testing.T) {
+	g := NewGame("p")
+	g.SetSeed(11)
+	g.Stats = map[string]*PlayerStats{"p": {Hands: 50, FacedBets: 40, FoldsToBets: 38}}
+	playScripted(g)
+
+	if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+		t.Errorf("replay failed: %v", err)
+	}
+}
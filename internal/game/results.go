@@ -0,0 +1,139 @@
+package game
+
+// Result is how the last hand, and any ESP training after it, went for one
+// human player, as their profile and the leaderboards count it.
+type Result struct {
+	PlayerID   string       `json:"player_id"`
+	Played     bool         `json:"played"`                // Dealt in, rather than only training ESP
+	Net        int          `json:"net"`                   // Sanity won over the hand, or lost if negative
+	Pot        int          `json:"pot"`                   // Sanity awarded from the pots
+	Best       HandStrength `json:"best,omitempty"`        // The strongest high hand shown down, 0 if none
+	BestCards  Hand         `json:"best_cards,omitempty"`  // ... and the cards it was made from
+	Mad        bool         `json:"mad,omitempty"`         // Lost the last of their sanity
+	Broke      bool         `json:"broke,omitempty"`       // Left without the sanity to ante again, ending the game
+	Ancient    bool         `json:"ancient,omitempty"`     // The Ancient One sat at the table
+	ESPRounds  int          `json:"esp_rounds,omitempty"`  // ESP rounds started
+	ESPSolved  int          `json:"esp_solved,omitempty"`  // ... and solved
+	ESPFastest int64        `json:"esp_fastest,omitempty"` // Milliseconds of the quickest solve, 0 if none
+	ESPGuesses int          `json:"esp_guesses,omitempty"` // ... and the guesses it took
+	Finished   int64        `json:"finished"`              // Unix milliseconds of the last event counted
+}
+
+// Won reports whether the player came out of the hand ahead.
+func (r Result) Won() bool {
+	return r.Net > 0
+}
+
+// Results projects the events of the last hand into a result for every
+// human who played it or trained ESP after it, in seat order. While a hand
+// is being played there are none yet. The best hand is ranked high, under
+// the hand's wild cards, whatever the game; so a lowball player's best
+// hand is the strongest they were stuck with.
+func (g *GameState) Results() []Result {
+	if g.handInProgress() || len(g.Events) == 0 {
+		return nil
+	}
+
+	var (
+		results []*Result
+		byID    = make(map[string]*Result)
+		start   = make(map[string]int) // Sanity at the start of the hand
+		espFrom = make(map[string]int64)
+		ancient bool
+		rules   Rules
+	)
+	result := func(id string) *Result {
+		r := byID[id]
+		if r == nil {
+			r = &Result{PlayerID: id}
+			byID[id] = r
+			results = append(results, r)
+		}
+		return r
+	}
+
+	// ESP sanity is counted back from where the player is now
+	espSanity := make(map[string]int)
+	for _, e := range g.Events {
+		if e.Type == EventESPGuess {
+			espSanity[e.PlayerID] -= e.Amount
+		}
+	}
+	for id := range espSanity {
+		if seat := g.SeatOf(id); seat >= 0 {
+			espSanity[id] += g.Players[seat].Sanity
+		}
+	}
+
+	for _, e := range g.Events {
+		if e.Type == EventRules && e.Rules != nil {
+			rules = *e.Rules
+		}
+		if e.Type == EventSeat {
+			if e.PlayerID == AncientOneID {
+				ancient = true
+			}
+			if !e.AI {
+				result(e.PlayerID)
+				start[e.PlayerID] = e.Amount
+			}
+			continue
+		}
+		r := byID[e.PlayerID]
+		if r == nil && e.Type != EventESPStart {
+			continue // The AI's, or the table's
+		}
+
+		switch e.Type {
+		case EventDeal:
+			r.Played = true
+		case EventAward:
+			r.Pot += e.Amount
+		case EventStack:
+			r.Net = e.Amount - start[e.PlayerID]
+			if e.Amount <= 0 && start[e.PlayerID] > 0 {
+				r.Mad = true
+			}
+		case EventShow:
+			if s, _ := rules.BestFive(e.Cards); s > r.Best {
+				r.Best, r.BestCards = s, append(Hand(nil), e.Cards...)
+			}
+		case EventESPStart:
+			r = result(e.PlayerID)
+			r.ESPRounds++
+			espFrom[e.PlayerID] = e.Time
+		case EventESPGuess:
+			before := espSanity[e.PlayerID]
+			espSanity[e.PlayerID] += e.Amount
+			if before > 0 && espSanity[e.PlayerID] <= 0 {
+				r.Mad = true
+			}
+			if e.Detail != "correct" {
+				break
+			}
+			r.ESPSolved++
+			if took := e.Time - espFrom[e.PlayerID]; r.ESPFastest == 0 || took < r.ESPFastest {
+				r.ESPFastest, r.ESPGuesses = max(took, 1), e.Count
+			}
+		}
+		r.Finished = e.Time
+	}
+
+	// A game that ends at the next ante ends with this hand
+	if g.GamePhase == PhaseGameOver {
+		for id, r := range byID {
+			if seat := g.SeatOf(id); seat >= 0 && g.RoundStates[seat].SittingOut {
+				r.Broke = true
+			}
+		}
+	}
+
+	var out []Result
+	for _, r := range results {
+		r.Ancient = ancient
+		if r.Played || r.ESPRounds > 0 {
+			out = append(out, *r)
+		}
+	}
+	return out
+}
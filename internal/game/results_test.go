@@ -0,0 +1,86 @@
+package game
+
+import "testing"
+
+func TestResultsOfAShowdown(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	g.SeatAction(0, "check", 0)
+	if g.Results() != nil {
+		t.Errorf("expected no results while the hand is played")
+	}
+	g.SeatAction(1, "check", 0)
+	g.SeatDiscard(0, nil)
+	g.SeatDiscard(1, nil)
+	g.RoundStates[0].Hand = handHigh
+	g.RoundStates[1].Hand = handPair
+	g.SeatAction(0, "bet", 20)
+	g.SeatAction(1, "call", 0)
+
+	results := g.Results()
+	if len(results) != 2 {
+		t.Fatalf("expected a result per seat, got %+v", results)
+	}
+	loser, winner := results[0], results[1]
+	if !loser.Played || loser.Won() || loser.Net != -30 || loser.Pot != 0 {
+		t.Errorf("unexpected loser %+v", loser)
+	}
+	if !winner.Played || !winner.Won() || winner.Net != 30 || winner.Pot != 60 {
+		t.Errorf("unexpected winner %+v", winner)
+	}
+	if winner.Best.Rank() != OnePair || loser.Best.Rank() != HighCard || len(winner.BestCards) != 5 {
+		t.Errorf("expected the hands shown, got %v and %v", winner.Best.Rank(), loser.Best.Rank())
+	}
+	if winner.PlayerID != g.Players[1].ID || winner.Finished == 0 || winner.Mad || winner.Ancient {
+		t.Errorf("unexpected winner %+v", winner)
+	}
+}
+
+func TestResultsCountESPAndMadness(t *testing.T) {
+	g := NewGame("p")
+	g.CollectAnte(10)
+	g.PlayerAction("fold", 0)
+
+	wrong := func() int {
+		i := (g.ESP.MatchIndex2 + 1) % 5
+		for g.ESP.Hand2[i].Rank == g.ESP.Hand1[g.ESP.MatchIndex1].Rank {
+			i = (i + 1) % 5
+		}
+		return i
+	}
+	g.StartESP()
+	g.GuessESP(g.ESP.MatchIndex1, wrong())
+	g.GuessESP(g.ESP.MatchIndex1, g.ESP.MatchIndex2)
+	g.StartESP()
+	g.Players[0].Sanity = 5
+	g.GuessESP(g.ESP.MatchIndex1, wrong())
+
+	results := g.Results()
+	if len(results) != 1 {
+		t.Fatalf("expected only the human's result, got %+v", results)
+	}
+	r := results[0]
+	if !r.Played || !r.Ancient || r.ESPRounds != 2 || r.ESPSolved != 1 || r.ESPFastest <= 0 || r.ESPGuesses != 2 {
+		t.Errorf("unexpected ESP tally %+v", r)
+	}
+	if !r.Mad || g.GamePhase != PhaseGameOver {
+		t.Errorf("expected the visions to drive them mad, got %+v in %s", r, g.GamePhase)
+	}
+}
+
+func TestResultsOfTheLastAnte(t *testing.T) {
+	g := NewGame("p")
+	g.Players[0].Sanity = 15
+	g.CollectAnte(10)
+	g.PlayerAction("fold", 0)
+	if r := g.Results(); len(r) != 1 || r[0].Broke {
+		t.Fatalf("expected the game to go on, got %+v", r)
+	}
+
+	g.NewRound()
+	g.CollectAnte(10)
+	r := g.Results()
+	if g.GamePhase != PhaseGameOver || len(r) != 1 || !r[0].Broke || r[0].Mad || r[0].Net != -10 {
+		t.Errorf("expected the hand to end the game, got %+v in %s", r, g.GamePhase)
+	}
+}
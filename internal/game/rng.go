@@ -0,0 +1,88 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+)
+
+// RNG is the game's source of randomness. It wraps a PCG generator whose
+// exact state is saved with the game, so a reloaded game carries on with
+// the same sequence and a hand can be replayed bit-for-bit from its seed.
+type RNG struct {
+	*rand.Rand
+	src *rand.PCG
+}
+
+// NewRNG returns a generator seeded with seed.
+func NewRNG(seed uint64) *RNG {
+	src := rand.NewPCG(seed, seed^0xda3e39cb94b95bdb)
+	return &RNG{Rand: rand.New(src), src: src}
+}
+
+// NewSeed picks a fresh random seed for a game.
+func NewSeed() uint64 {
+	return rand.Uint64()
+}
+
+// MarshalJSON saves the generator state.
+func (r *RNG) MarshalJSON() ([]byte, error) {
+	state, err := r.src.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(state)
+}
+
+// UnmarshalJSON restores the generator state saved by MarshalJSON.
+func (r *RNG) UnmarshalJSON(data []byte) error {
+	var state []byte
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	src := &rand.PCG{}
+	if err := src.UnmarshalBinary(state); err != nil {
+		return err
+	}
+	r.src = src
+	r.Rand = rand.New(src)
+	return nil
+}
+
+// handSeed derives the seed of hand n from the game seed (SplitMix64), so
+// every hand can be reproduced on its own.
+func handSeed(seed uint64, n int) uint64 {
+	z := seed + uint64(n)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// SetSeed replaces the game seed. Every hand dealt from now on is derived
+// from it; tools and tests use this to get reproducible games.
+func (g *GameState) SetSeed(seed uint64) {
+	g.Seed = seed
+	g.RNG = NewRNG(seed)
+}
+
+// HandSeed returns the seed hand n of this game is dealt from.
+func (g *GameState) HandSeed(n int) uint64 {
+	return handSeed(g.Seed, n)
+}
+
+// StackDeck makes the next hand be dealt from deck, top card first, instead
+// of a shuffled deck. It is meant for tests and tools; it is not saved.
+func (g *GameState) StackDeck(deck Deck) {
+	g.stacked = append(Deck(nil), deck...)
+}
+
+// rng returns the game's generator, creating one for games saved before
+// seeds were recorded.
+func (g *GameState) rng() *RNG {
+	if g.RNG == nil {
+		if g.Seed == 0 {
+			g.Seed = NewSeed()
+		}
+		g.RNG = NewRNG(g.Seed)
+	}
+	return g.RNG
+}
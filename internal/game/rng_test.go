@@ -0,0 +1,100 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// playScripted runs one heads-up hand against the Ancient One where the
+// human always checks or calls and draws two cards, returning a transcript.
+func playScripted(g *GameState) string {
+	g.CollectAnte(10)
+	for i := 0; i < 20 && g.GamePhase != PhaseComplete && g.GamePhase != PhaseGameOver; i++ {
+		switch {
+		case g.GamePhase == PhaseDiscard:
+			g.PerformDiscard([]int{0, 1})
+		case g.TurnIndex == 0:
+			if ok, _ := g.PlayerAction("check", 0); !ok {
+				g.PlayerAction("call", 0)
+			}
+		}
+		g.OpponentTurn()
+	}
+	return fmt.Sprint(g.RoundStates[0].Hand, g.RoundStates[1].Hand, g.Players[0].Sanity, g.Players[1].Sanity, g.LastAction)
+}
+
+func TestSameSeedSameGame(t *testing.T) {
+	for seed := uint64(1); seed <= 5; seed++ {
+		a, b := NewGame("p"), NewGame("p")
+		a.SetSeed(seed)
+		b.SetSeed(seed)
+		if ta, tb := playScripted(a), playScripted(b); ta != tb {
+			t.Errorf("seed %d diverged:\n%s\n%s", seed, ta, tb)
+		}
+	}
+}
+
+func TestHandsAreIndependentOfWhatCameBefore(t *testing.T) {
+	a, b := NewGame("p"), NewGame("p")
+	a.SetSeed(42)
+	b.SetSeed(42)
+
+	// Only a plays ESP between hands; the next hand must not notice
+	playScripted(a)
+	playScripted(b)
+	a.StartESP()
+	a.ExitESP()
+	a.NewRound()
+	b.NewRound()
+
+	if ta, tb := playScripted(a), playScripted(b); ta != tb {
+		t.Errorf("hand 2 diverged after ESP:\n%s\n%s", ta, tb)
+	}
+}
+
+func TestRNGSurvivesSaveAndLoad(t *testing.T) {
+	g := NewGame("p")
+	g.SetSeed(7)
+	g.CollectAnte(10)
+	g.RNG.Uint64() // Advance past the seed state
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var loaded GameState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if want, got := g.RNG.Uint64(), loaded.RNG.Uint64(); want != got {
+			t.Fatalf("draw %d: expected %d after reload, got %d", i, want, got)
+		}
+	}
+}
+
+func TestStackDeck(t *testing.T) {
+	g := newHumanTable(t, 2)
+	deck := orderedDeck()
+	g.StackDeck(deck)
+	g.CollectAnte(10)
+
+	for i := 0; i < 5; i++ {
+		if g.RoundStates[0].Hand[i] != deck[i] || g.RoundStates[1].Hand[i] != deck[5+i] {
+			t.Fatalf("hands not dealt from the stacked deck: %v %v", g.RoundStates[0].Hand, g.RoundStates[1].Hand)
+		}
+	}
+	if len(g.Deck) != 42 {
+		t.Errorf("expected 42 cards left, got %d", len(g.Deck))
+	}
+
+	// The stack is used once; the next hand is shuffled again
+	g.PlayerAction("fold", 0)
+	g.NewRound()
+	g.CollectAnte(10)
+	if fmt.Sprint(g.RoundStates[1].Hand) == fmt.Sprint(deck[:5]) {
+		t.Errorf("second hand should not reuse the stacked deck")
+	}
+}
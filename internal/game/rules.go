@@ -0,0 +1,158 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rules are the house rules a table plays by, on top of its Variant. The
+// zero value is plain poker.
+type Rules struct {
+	Jokers     bool    `json:"jokers,omitempty"`      // Two jokers in the deck, both wild
+	DeucesWild bool    `json:"deuces_wild,omitempty"` // Every two is wild
+	Ranking    Ranking `json:"ranking,omitempty"`     // How hands rank at showdown; high if empty
+	Draws      int     `json:"draws,omitempty"`       // Draw rounds in draw poker, each followed by betting; one if zero
+	Betting    Betting `json:"betting,omitempty"`     // Betting structure; no limit if empty
+	SmallBet   int     `json:"small_bet,omitempty"`   // Fixed limit: bet size in early rounds; the stake if zero
+	BigBet     int     `json:"big_bet,omitempty"`     // ... and in later rounds; twice the small bet if zero
+	RaiseCap   int     `json:"raise_cap,omitempty"`   // ... and how many bets and raises a round allows; 4 if zero
+}
+
+// MaxDraws is the most draw rounds a hand of draw poker can have, as in
+// triple draw.
+const MaxDraws = 3
+
+// draws returns the number of draw rounds, defaulting to one.
+func (r Rules) draws() int {
+	return max(1, r.Draws)
+}
+
+// Joker is the rank of the two jokers; their suits are their colours.
+const Joker Rank = "joker"
+
+// jokers are added to the deck when the rules call for them.
+var jokers = []Card{{Suit: "black", Rank: Joker}, {Suit: "red", Rank: Joker}}
+
+// Wild reports whether c stands in for any card under these rules. Jokers
+// are only ever dealt when they are wild.
+func (r Rules) Wild(c Card) bool {
+	return c.Rank == Joker || (r.DeucesWild && c.Rank == "2")
+}
+
+// wilds reports whether any card can be wild.
+func (r Rules) wilds() bool {
+	return r.Jokers || r.DeucesWild
+}
+
+// Name describes the rules for players, e.g. "Eldritch Wilds" or
+// "Deuce-to-Seven Lowball; Fixed Limit".
+func (r Rules) Name() string {
+	var wilds string
+	switch {
+	case r.Jokers && r.DeucesWild:
+		wilds = "Eldritch Wilds: jokers and deuces wild"
+	case r.Jokers:
+		wilds = "Eldritch Wilds: jokers wild"
+	case r.DeucesWild:
+		wilds = "Eldritch Wilds: deuces wild"
+	}
+	var ranking string
+	switch r.draws() {
+	case 2:
+		ranking = "Double Draw "
+	case 3:
+		ranking = "Triple Draw "
+	}
+	switch r.ranking() {
+	case RankingDeuceToSeven:
+		ranking += "Deuce-to-Seven Lowball"
+	case RankingAceToFive:
+		ranking += "Ace-to-Five Lowball"
+	case RankingHiLo:
+		ranking += "Hi-Lo Split, Eight or Better"
+	default:
+		ranking = strings.TrimSpace(ranking)
+	}
+	var parts []string
+	for _, part := range []string{ranking, wilds, r.betting().name()} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "; ")
+	}
+	return "No wild cards"
+}
+
+// NewDeck returns the deck these rules deal from, shuffled with rng.
+func (r Rules) NewDeck(rng *RNG) Deck {
+	d := r.fullDeck()
+	ShuffleDeck(&d, rng)
+	return d
+}
+
+// fullDeck is the unshuffled deck: the 52 cards, and the jokers if used.
+func (r Rules) fullDeck() Deck {
+	d := orderedDeck()
+	if r.Jokers {
+		d = append(d, jokers...)
+	}
+	return d
+}
+
+// unknownCards returns the cards of the deck that are not among exclusions.
+func (r Rules) unknownCards(exclusions []Card) Deck {
+	var unknown Deck
+	for _, c := range r.fullDeck() {
+		if !slices.Contains(exclusions, c) {
+			unknown = append(unknown, c)
+		}
+	}
+	// Order does not matter; simulations shuffle their own copy.
+	return unknown
+}
+
+// ParseRules reads rules from a comma-separated list of the wild cards in
+// play, "jokers" and "deuces". An empty list or "none" is plain poker.
+func ParseRules(s string) (Rules, error) {
+	var r Rules
+	for _, w := range strings.Split(s, ",") {
+		switch strings.TrimSpace(w) {
+		case "", "none":
+		case "jokers":
+			r.Jokers = true
+		case "deuces":
+			r.DeucesWild = true
+		default:
+			return Rules{}, fmt.Errorf("unknown wild cards %q", w)
+		}
+	}
+	return r, nil
+}
+
+// SetRules changes the house rules. Like the variant, they take effect
+// from the next hand and cannot change during one.
+func (g *GameState) SetRules(r Rules) error {
+	if g.handInProgress() {
+		return fmt.Errorf("cannot change the rules during a hand")
+	}
+	if _, err := ParseRanking(string(r.Ranking)); err != nil {
+		return err
+	}
+	if r.Draws < 0 || r.Draws > MaxDraws {
+		return fmt.Errorf("draw poker has from 1 to %d draws, not %d", MaxDraws, r.Draws)
+	}
+	if _, err := ParseBetting(string(r.Betting)); err != nil {
+		return err
+	}
+	if r.SmallBet < 0 || r.BigBet < 0 || r.RaiseCap < 0 {
+		return fmt.Errorf("bet sizes and the raise cap cannot be negative")
+	}
+	if r.BigBet > 0 && r.BigBet < r.SmallBet {
+		return fmt.Errorf("the big bet cannot be smaller than the small bet")
+	}
+	g.Rules = r
+	return nil
+}
@@ -0,0 +1,34 @@
+package game
+
+import "fmt"
+
+// Reseat replaces the players at the table between hands, after NewRound,
+// as players join and leave. The game's numbering, seed, rules and what the
+// AI has learned carry on. The button stays with its player if they are
+// still seated, and otherwise with whoever now holds that seat.
+func (g *GameState) Reseat(players []*Player) error {
+	if g.GamePhase != PhaseAnte {
+		return fmt.Errorf("seats can only change between hands")
+	}
+	if len(players) < MinSeats || len(players) > MaxSeats {
+		return fmt.Errorf("a table needs %d to %d seats, got %d", MinSeats, MaxSeats, len(players))
+	}
+
+	dealer := min(g.DealerIndex, len(players)-1)
+	if g.DealerIndex >= 0 && g.DealerIndex < len(g.Players) {
+		for i, p := range players {
+			if p.ID == g.Players[g.DealerIndex].ID {
+				dealer = i
+			}
+		}
+	}
+
+	g.Players = players
+	g.RoundStates = make([]*RoundState, len(players))
+	for i := range g.RoundStates {
+		g.RoundStates[i] = &RoundState{Hand: []Card{}}
+	}
+	g.DealerIndex = dealer
+	g.setTurn(g.nextSeat(g.DealerIndex, g.inHand))
+	return nil
+}
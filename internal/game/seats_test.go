@@ -0,0 +1,94 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReseatKeepsButtonWithItsPlayer(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+	g.NewRound()
+	button := g.Players[g.DealerIndex]
+	hand := g.HandNumber
+
+	newcomer := NewHumanPlayer("")
+	newcomer.Name = "Newcomer"
+	players := []*Player{newcomer}
+	for _, p := range g.Players {
+		if p != g.Players[1] {
+			players = append(players, p)
+		}
+	}
+	if err := g.Reseat(players); err != nil {
+		t.Fatalf("Reseat: %v", err)
+	}
+	if g.Players[g.DealerIndex] != button {
+		t.Errorf("expected the button to stay with %s, got %s", button.Name, g.Players[g.DealerIndex].Name)
+	}
+	if len(g.RoundStates) != len(players) {
+		t.Errorf("expected %d round states, got %d", len(players), len(g.RoundStates))
+	}
+
+	if !g.CollectAnte(10) {
+		t.Fatalf("expected the reseated table to deal: %s", g.LastAction)
+	}
+	if g.HandNumber != hand+1 {
+		t.Errorf("expected hand %d, got %d", hand+1, g.HandNumber)
+	}
+	if g.Players[0].Sanity != 90 {
+		t.Errorf("expected the newcomer to ante, got %d", g.Players[0].Sanity)
+	}
+}
+
+func TestReseatBetweenHandsOnly(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	if err := g.Reseat(g.Players); err == nil {
+		t.Errorf("expected seats to be fixed during a hand")
+	}
+	g.NewRound()
+	if err := g.Reseat(g.Players[:1]); err == nil {
+		t.Errorf("expected a single seat to be refused")
+	}
+}
+
+func TestReseatMovesButtonWhenItsPlayerLeaves(t *testing.T) {
+	g := newHumanTable(t, 4)
+	g.NewRound() // Button on seat 0
+	if err := g.Reseat(g.Players[1:]); err != nil {
+		t.Fatalf("Reseat: %v", err)
+	}
+	if g.DealerIndex != 0 || g.Players[0].Name != "Seat 1" {
+		t.Errorf("expected the button to pass to the next player, got seat %d", g.DealerIndex)
+	}
+}
+
+func TestCanDealOnceTheHandIsOver(t *testing.T) {
+	g := newHumanTable(t, 2)
+	if !g.CanDeal() {
+		t.Errorf("expected a new table to deal")
+	}
+	g.CollectAnte(10)
+	if g.CanDeal() {
+		t.Errorf("expected no deal during a hand")
+	}
+	act(t, g, "fold", 0)
+	if !g.CanDeal() {
+		t.Errorf("expected a deal once the hand is over, in %s", g.GamePhase)
+	}
+}
+
+func TestShowdownNamesPlayersAtATable(t *testing.T) {
+	g, _ := NewTable([]*Player{{ID: "a", Name: "Armitage", Sanity: 10}, NewAIPlayer(0)})
+	g.CollectAnte(10) // All in on the ante, so only the draw is left
+	for i := 0; i < 5 && g.CanDiscard(); i++ {
+		g.SeatDiscard(g.TurnIndex, nil)
+	}
+	if !g.CanDeal() {
+		t.Fatalf("expected the hand to be over, in %s", g.GamePhase)
+	}
+	if strings.Contains(g.LastAction, "You") {
+		t.Errorf("expected the player to be named, got %q", g.LastAction)
+	}
+}
@@ -0,0 +1,172 @@
+package game
+
+// PlayerStats are a player's tendencies, tallied from the event logs of the
+// hands they finished. They are keyed on Player.ID and outlive any one game.
+type PlayerStats struct {
+	Hands          int `json:"hands"`           // Hands dealt in
+	Voluntary      int `json:"voluntary"`       // Hands where they put sanity in on the first betting round
+	FacedBets      int `json:"faced_bets"`      // Betting rounds where they faced a bet
+	FoldsToBets    int `json:"folds_to_bets"`   // ... and folded to it
+	AggroShowdowns int `json:"aggro_showdowns"` // Showdowns after betting or raising the last round
+	Bluffs         int `json:"bluffs"`          // ... with no pair
+	Draws          int `json:"draws"`           // Draws taken
+	CardsDrawn     int `json:"cards_drawn"`     // Cards received in those draws
+}
+
+// Until a player has some history, their rates lean on what a typical
+// player does. Each prior counts as this many observations.
+const statsPriorWeight = 10
+
+// Typical rates for a player we know nothing about.
+const (
+	typicalVPIP      = 0.5
+	typicalFoldToBet = 0.4
+	typicalBluff     = 0.15
+	typicalDrawCount = 2.5
+)
+
+// The first and last betting rounds of each variant, by phase name.
+var (
+	firstRounds = map[string]bool{
+		PhasePreDrawBetting.String(): true,
+		PhasePreFlop.String():        true,
+		PhaseThirdStreet.String():    true,
+	}
+	lastRounds = map[string]bool{
+		PhasePostDrawBetting.String(): true,
+		PhaseRiver.String():           true,
+		PhaseSeventhStreet.String():   true,
+	}
+)
+
+// rate blends n observations of which k hit with the prior p.
+func rate(k, n int, p float64) float64 {
+	return (float64(k) + p*statsPriorWeight) / float64(n+statsPriorWeight)
+}
+
+// VPIP is how often they voluntarily put sanity in the pot on the first
+// betting round: before the draw, the flop, or fourth street.
+func (s *PlayerStats) VPIP() float64 {
+	return rate(s.Voluntary, s.Hands, typicalVPIP)
+}
+
+// FoldToBet is how often they fold when someone bets into them.
+func (s *PlayerStats) FoldToBet() float64 {
+	return rate(s.FoldsToBets, s.FacedBets, typicalFoldToBet)
+}
+
+// BluffRate is how often a bet or raise on the last round turned out at
+// showdown to be made with nothing.
+func (s *PlayerStats) BluffRate() float64 {
+	return rate(s.Bluffs, s.AggroShowdowns, typicalBluff)
+}
+
+// AverageDraw is how many cards they usually take.
+func (s *PlayerStats) AverageDraw() float64 {
+	return (float64(s.CardsDrawn) + typicalDrawCount*statsPriorWeight) / float64(s.Draws+statsPriorWeight)
+}
+
+// addHand tallies the events of one finished hand for seat.
+func (s *PlayerStats) addHand(events []Event, seat int) {
+	var (
+		phase     string
+		put       = map[int]int{} // Sanity each seat put in this round
+		high      int             // Most anyone has put in this round
+		faced     bool            // seat faced a bet this round
+		aggressor bool            // seat bet or raised the last round
+		dealt     bool
+		voluntary bool
+		rules     Rules
+	)
+	for _, e := range events {
+		if e.Type == EventRules && e.Rules != nil {
+			rules = *e.Rules
+		}
+		if e.Type == EventPhase {
+			phase = e.Detail
+			clear(put)
+			high, faced = 0, false
+			if lastRounds[phase] {
+				aggressor = false
+			}
+			continue
+		}
+
+		switch e.Type {
+		case EventCheck, EventCall, EventBet, EventRaise, EventAllIn, EventFold:
+			if e.Seat == seat {
+				if high > put[seat] && !faced {
+					faced = true
+					s.FacedBets++
+					if e.Type == EventFold {
+						s.FoldsToBets++
+					}
+				}
+				if e.Amount > 0 && firstRounds[phase] {
+					voluntary = true
+				}
+				if e.Type == EventBet || e.Type == EventRaise || e.Type == EventAllIn {
+					aggressor = true
+				}
+			}
+			put[e.Seat] += e.Amount
+			high = max(high, put[e.Seat])
+		case EventBlind:
+			// Forced, so not voluntary, but it is a bet to face
+			put[e.Seat] += e.Amount
+			high = max(high, put[e.Seat])
+		}
+
+		if e.Seat != seat {
+			continue
+		}
+		switch e.Type {
+		case EventDeal:
+			dealt = true
+		case EventDraw:
+			s.Draws++
+			s.CardsDrawn += e.Count
+		case EventShow:
+			if aggressor {
+				s.AggroShowdowns++
+				if rules.weak(e.Cards) {
+					s.Bluffs++
+				}
+			}
+		}
+	}
+
+	if dealt {
+		s.Hands++
+		if voluntary {
+			s.Voluntary++
+		}
+	}
+}
+
+// tallyStats adds the hand just finished to the stats of every human seat.
+// The AI only studies humans.
+func (g *GameState) tallyStats() {
+	for i, p := range g.Players {
+		if p.IsAI {
+			continue
+		}
+		if g.Stats == nil {
+			g.Stats = make(map[string]*PlayerStats)
+		}
+		s := g.Stats[p.ID]
+		if s == nil {
+			s = &PlayerStats{}
+			g.Stats[p.ID] = s
+		}
+		s.addHand(g.Events, i)
+	}
+}
+
+// StatsFor returns what the table knows of a player, which may be nothing.
+func (g *GameState) StatsFor(playerID string) *PlayerStats {
+	if s := g.Stats[playerID]; s != nil {
+		return s
+	}
+	return &PlayerStats{}
+}
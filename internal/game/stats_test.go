@@ -0,0 +1,120 @@
+package game
+
+import "testing"
+
+func TestStatsFoldToBet(t *testing.T) {
+	g := newHumanTable(t, 3)
+	g.CollectAnte(10)
+	g.SeatAction(0, "check", 0)
+	g.SeatAction(1, "bet", 10)
+	g.SeatAction(2, "fold", 0)
+	g.SeatAction(0, "fold", 0)
+
+	if g.GamePhase != PhaseComplete {
+		t.Fatalf("expected the hand to be over, got %s", g.GamePhase)
+	}
+	for seat, want := range []PlayerStats{
+		{Hands: 1, FacedBets: 1, FoldsToBets: 1},
+		{Hands: 1, Voluntary: 1},
+		{Hands: 1, FacedBets: 1, FoldsToBets: 1},
+	} {
+		if got := *g.StatsFor(g.Players[seat].ID); got != want {
+			t.Errorf("seat %d: expected %+v, got %+v", seat, want, got)
+		}
+	}
+}
+
+func TestStatsBluffAtShowdown(t *testing.T) {
+	g := newHumanTable(t, 2)
+	g.CollectAnte(10)
+	g.SeatAction(0, "check", 0)
+	g.SeatAction(1, "check", 0)
+	g.SeatDiscard(0, []int{0, 1, 2})
+	g.SeatDiscard(1, nil)
+
+	g.RoundStates[0].Hand = handHigh
+	g.RoundStates[1].Hand = handPair
+	g.SeatAction(0, "bet", 20)
+	g.SeatAction(1, "call", 0)
+
+	if g.GamePhase != PhaseComplete {
+		t.Fatalf("expected showdown to complete the hand, got %s", g.GamePhase)
+	}
+	bluffer := g.StatsFor(g.Players[0].ID)
+	if *bluffer != (PlayerStats{Hands: 1, AggroShowdowns: 1, Bluffs: 1, Draws: 1, CardsDrawn: 3}) {
+		t.Errorf("unexpected bluffer stats %+v", *bluffer)
+	}
+	caller := g.StatsFor(g.Players[1].ID)
+	if *caller != (PlayerStats{Hands: 1, FacedBets: 1, Draws: 1}) {
+		t.Errorf("unexpected caller stats %+v", *caller)
+	}
+}
+
+func TestStatsSkipAIAndAccumulate(t *testing.T) {
+	g := NewGame("regular")
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			g.NewRound()
+		}
+		g.CollectAnte(10)
+		g.OpponentTurn()
+		g.PlayerAction("fold", 0)
+	}
+
+	if _, ok := g.Stats[AncientOneID]; ok {
+		t.Errorf("the AI should not keep stats on itself")
+	}
+	if s := g.StatsFor("regular"); s.Hands != 3 || s.Voluntary != 0 {
+		t.Errorf("expected 3 hands folded without paying, got %+v", *s)
+	}
+}
+
+func TestRatesStartAtTypicalValues(t *testing.T) {
+	var s PlayerStats
+	if s.FoldToBet() != typicalFoldToBet || s.BluffRate() != typicalBluff || s.VPIP() != typicalVPIP {
+		t.Errorf("an unknown player should look typical")
+	}
+
+	s = PlayerStats{FacedBets: 90, FoldsToBets: 90}
+	if s.FoldToBet() < 0.9 {
+		t.Errorf("a habitual folder should have a high fold rate, got %.2f", s.FoldToBet())
+	}
+}
+
+func TestAIBulliesHabitualFolders(t *testing.T) {
+	bluffs := func(stats *PlayerStats) int {
+		n := 0
+		for seed := uint64(1); seed <= 200; seed++ {
+			g := afterDraw(handHigh, 3)
+			g.Stats = map[string]*PlayerStats{g.Players[0].ID: stats}
+			g.SetSeed(seed)
+			if action, _ := DefaultAI.DecideAction(handHigh, g); action == "bet" {
+				n++
+			}
+		}
+		return n
+	}
+
+	unknown := bluffs(&PlayerStats{})
+	folder := bluffs(&PlayerStats{FacedBets: 100, FoldsToBets: 95})
+	if folder <= 2*unknown {
+		t.Errorf("expected far more bluffs against a folder: %d vs %d against an unknown", folder, unknown)
+	}
+}
+
+func TestAICallsDownBluffers(t *testing.T) {
+	honest := &PlayerStats{AggroShowdowns: 100}
+	bluffer := &PlayerStats{AggroShowdowns: 100, Bluffs: 60}
+
+	g := afterDraw(handHigh, 3)
+	g.Events = append(g.Events, Event{Type: EventBet, Seat: 0, Amount: 20})
+
+	g.Stats = map[string]*PlayerStats{g.Players[0].ID: honest}
+	if r := g.bettorBluffRate(); r > typicalBluff {
+		t.Errorf("an honest bettor should bluff less than typical, got %.2f", r)
+	}
+	g.Stats = map[string]*PlayerStats{g.Players[0].ID: bluffer}
+	if r := g.bettorBluffRate(); r < 0.5 {
+		t.Errorf("a known bluffer should be read as one, got %.2f", r)
+	}
+}
@@ -0,0 +1,180 @@
+package game
+
+import "fmt"
+
+// Strategy is how an AI seat plays. DecideAction is asked for a betting
+// decision when it is the seat's turn, with every card the seat makes its
+// hand from (in Hold'em, the board too), and returns "check", "call",
+// "bet", "raise" or "fold" with the amount to put in beyond the call; the
+// table coerces anything illegal into a legal action. ChooseDiscard returns
+// the indices of the cards to throw away.
+type Strategy interface {
+	DecideAction(hand Hand, gameState *GameState) (string, int)
+	ChooseDiscard(hand Hand, rules Rules, rng *RNG) []int
+}
+
+// Personality names one of the built-in strategies. It is what gets saved
+// with a seat, so strategies themselves hold no per-game state.
+type Personality string
+
+const (
+	PersonalityAncient Personality = "ancient" // DefaultAI, the Monte Carlo drawer
+	PersonalitySleeper Personality = "sleeper" // Tight-passive
+	PersonalityManiac  Personality = "maniac"  // Loose-aggressive bluffer
+	PersonalityOracle  Personality = "oracle"  // Pot-odds player
+)
+
+// PersonalityInfo describes a personality for players choosing an opponent.
+type PersonalityInfo struct {
+	ID          Personality `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+}
+
+// Personalities lists the built-in strategies, default first.
+var Personalities = []PersonalityInfo{
+	{PersonalityAncient, "The Ancient One", "Weighs every draw against a hundred possible futures."},
+	{PersonalitySleeper, "The Sleeper", "Dreams through most hands. When it wakes, run."},
+	{PersonalityManiac, "The Maniac", "Bets, raises and bluffs with whatever madness it holds."},
+	{PersonalityOracle, "The Oracle", "Calls exactly when the pot pays for it, and never otherwise."},
+}
+
+// StrategyFor returns the strategy for a personality; unknown or empty
+// personalities get DefaultAI.
+func StrategyFor(p Personality) Strategy {
+	switch p {
+	case PersonalitySleeper:
+		return Sleeper{AIConfig: DefaultAI}
+	case PersonalityManiac:
+		return Maniac{AIConfig: DefaultAI}
+	case PersonalityOracle:
+		return Oracle{AIConfig: DefaultAI, Simulations: 300}
+	}
+	return DefaultAI
+}
+
+// validPersonality reports whether p names a built-in strategy.
+func validPersonality(p Personality) bool {
+	for _, info := range Personalities {
+		if info.ID == p {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPersonality changes which strategy an AI seat plays. A hand is replayed
+// with the personalities it was dealt with, so it cannot be changed while
+// one is being played.
+func (g *GameState) SetPersonality(seat int, p Personality) error {
+	if seat < 0 || seat >= len(g.Players) {
+		return fmt.Errorf("no seat %d at this table", seat)
+	}
+	if !g.Players[seat].IsAI {
+		return fmt.Errorf("seat %d is not an AI", seat)
+	}
+	if !validPersonality(p) {
+		return fmt.Errorf("unknown personality %q", p)
+	}
+	if g.handInProgress() {
+		return fmt.Errorf("cannot change personality during a hand")
+	}
+	g.Players[seat].Personality = p
+	return nil
+}
+
+// strategy returns the strategy seat plays with.
+func (g *GameState) strategy(seat int) Strategy {
+	return StrategyFor(g.Players[seat].Personality)
+}
+
+// Sleeper is tight-passive: it checks whenever it can, calls only with
+// solid hands and a good price, and bets only when it holds a monster.
+type Sleeper struct {
+	AIConfig
+}
+
+func (s Sleeper) DecideAction(hand Hand, gameState *GameState) (string, int) {
+	val := gameState.Rules.grade(hand)
+	toCall := gameState.CurrentBet - gameState.RoundStates[gameState.TurnIndex].Bet
+
+	if toCall == 0 {
+		if val.Rank >= FullHouse {
+			return "bet", 10
+		}
+		return "check", 0
+	}
+
+	strong := val.Rank >= TwoPair || (val.Rank == OnePair && val.Primary >= 11)
+	cheap := float64(toCall)/float64(gameState.Pot+toCall) < 0.3
+	if val.Rank >= ThreeOfAKind || (strong && cheap) {
+		return "call", 0
+	}
+	return "fold", 0
+}
+
+// Maniac is loose-aggressive: it bets and raises far more than its cards
+// justify, sized at half to all of the pot, and rarely lets go.
+type Maniac struct {
+	AIConfig
+}
+
+func (m Maniac) DecideAction(hand Hand, gameState *GameState) (string, int) {
+	rng := gameState.rng()
+	val := gameState.Rules.grade(hand)
+	toCall := gameState.CurrentBet - gameState.RoundStates[gameState.TurnIndex].Bet
+	size := gameState.Pot/2 + rng.IntN(gameState.Pot/2+1)
+	if size < 5 {
+		size = 5
+	}
+
+	if toCall == 0 {
+		if rng.Float64() < 0.75 {
+			return "bet", size
+		}
+		return "check", 0
+	}
+
+	if rng.Float64() < 0.35 {
+		return "raise", size
+	}
+	if val.Rank == HighCard && rng.Float64() < 0.2 {
+		return "fold", 0
+	}
+	return "call", 0
+}
+
+// Oracle plays the pot odds: it estimates its share of the pot against the
+// hands everyone still in could hold, calls only when that share beats the
+// price, and bets for value in proportion to its edge. It never bluffs.
+type Oracle struct {
+	AIConfig
+	Simulations int
+}
+
+func (o Oracle) DecideAction(hand Hand, gameState *GameState) (string, int) {
+	seat := gameState.TurnIndex
+	toCall := gameState.CurrentBet - gameState.RoundStates[seat].Bet
+	pot := gameState.Pot
+	equity := gameState.equity(seat, hand, o.Simulations)
+
+	// A fair share of the pot is 1/n; only bet above it
+	fair := 1 / float64(len(gameState.contenders()))
+	edge := (equity - fair) / (1 - fair)
+
+	if toCall == 0 {
+		if edge > 0.2 {
+			return "bet", max(1, int(edge*float64(pot)))
+		}
+		return "check", 0
+	}
+
+	potOdds := float64(toCall) / float64(pot+toCall)
+	if equity <= potOdds {
+		return "fold", 0
+	}
+	if edge > 0.5 {
+		return "raise", max(1, int(edge*float64(pot+toCall)))
+	}
+	return "call", 0
+}
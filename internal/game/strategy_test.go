@@ -0,0 +1,129 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// decideFacing puts seat 1 of a heads-up game on turn facing a bet of toCall
+// into a pot of pot, holding hand.
+func decideFacing(s Strategy, hand Hand, pot, toCall int) (string, int) {
+	g := NewGame("p")
+	g.SetSeed(1)
+	g.CollectAnte(10)
+	g.RoundStates[1].Hand = hand
+	g.Pot = pot
+	g.CurrentBet = toCall
+	g.setTurn(1)
+	return s.DecideAction(hand, g)
+}
+
+func TestStrategyForPersonality(t *testing.T) {
+	cases := map[Personality]Strategy{
+		"":                 DefaultAI,
+		"nonsense":         DefaultAI,
+		PersonalityAncient: DefaultAI,
+		PersonalitySleeper: Sleeper{},
+		PersonalityManiac:  Maniac{},
+		PersonalityOracle:  Oracle{},
+	}
+	for p, want := range cases {
+		if got := StrategyFor(p); fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want) {
+			t.Errorf("%q: expected %T, got %T", p, want, got)
+		}
+	}
+}
+
+func TestSetPersonality(t *testing.T) {
+	g := NewGame("p")
+	if err := g.SetPersonality(1, PersonalityManiac); err != nil {
+		t.Fatalf("SetPersonality failed: %v", err)
+	}
+	if err := g.SetPersonality(0, PersonalityManiac); err == nil {
+		t.Errorf("a human seat should not take a personality")
+	}
+	if err := g.SetPersonality(1, "cthulhu"); err == nil {
+		t.Errorf("unknown personalities should be rejected")
+	}
+	if err := g.SetPersonality(7, PersonalityOracle); err == nil {
+		t.Errorf("missing seats should be rejected")
+	}
+
+	// The choice is saved with the game
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded GameState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.strategy(1).(Maniac); !ok {
+		t.Errorf("expected the maniac after reload, got %T", loaded.strategy(1))
+	}
+}
+
+func TestSleeperIsTightPassive(t *testing.T) {
+	s := StrategyFor(PersonalitySleeper)
+	if action, _ := decideFacing(s, handTrips, 20, 0); action != "check" {
+		t.Errorf("sleeper should check trips, got %s", action)
+	}
+	if action, _ := decideFacing(s, handHigh, 20, 10); action != "fold" {
+		t.Errorf("sleeper should fold high card to a bet, got %s", action)
+	}
+	if action, _ := decideFacing(s, handTrips, 20, 50); action != "call" {
+		t.Errorf("sleeper should call with trips, got %s", action)
+	}
+}
+
+func TestManiacKeepsBetting(t *testing.T) {
+	s := StrategyFor(PersonalityManiac)
+	bets := 0
+	for i := 0; i < 50; i++ {
+		g := NewGame("p")
+		g.SetSeed(uint64(i))
+		g.CollectAnte(10)
+		g.setTurn(1)
+		if action, amount := s.DecideAction(handHigh, g); action == "bet" {
+			bets++
+			if amount < g.Pot/2 {
+				t.Errorf("maniac bet %d into a pot of %d", amount, g.Pot)
+			}
+		}
+	}
+	if bets < 25 {
+		t.Errorf("maniac should bet most unopened pots, bet %d of 50", bets)
+	}
+}
+
+func TestOracleFollowsPotOdds(t *testing.T) {
+	s := StrategyFor(PersonalityOracle)
+	royal := Hand{{Spades, "ace"}, {Spades, "king"}, {Spades, "queen"}, {Spades, "jack"}, {Spades, "10"}}
+
+	if action, _ := decideFacing(s, handHigh, 20, 80); action != "fold" {
+		t.Errorf("oracle should fold a weak hand to a big bet, got %s", action)
+	}
+	if action, _ := decideFacing(s, royal, 20, 80); action != "raise" {
+		t.Errorf("oracle should raise the nuts, got %s", action)
+	}
+	if action, amount := decideFacing(s, royal, 20, 0); action != "bet" || amount <= 0 {
+		t.Errorf("oracle should bet the nuts, got %s %d", action, amount)
+	}
+}
+
+func TestEveryPersonalityFinishesHands(t *testing.T) {
+	for _, info := range Personalities {
+		for seed := uint64(1); seed <= 5; seed++ {
+			g := NewGame("p")
+			g.SetSeed(seed)
+			for seat := 1; seat < len(g.Players); seat++ {
+				g.SetPersonality(seat, info.ID)
+			}
+			playScripted(g)
+			if g.GamePhase != PhaseComplete && g.GamePhase != PhaseGameOver {
+				t.Errorf("%s, seed %d: hand stalled in %s", info.ID, seed, g.GamePhase)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// dealStud deals seat one card for each of up, face up where it is true.
+// Face-up cards are public, so the deal event shows them.
+func (g *GameState) dealStud(seat int, up ...bool) {
+	rs := g.RoundStates[seat]
+	cards := DealHand(&g.Deck, len(up))
+	var shown Hand
+	for i, c := range cards {
+		rs.Hand = append(rs.Hand, c)
+		rs.FaceUp = append(rs.FaceUp, up[i])
+		if up[i] {
+			shown = append(shown, c)
+		}
+	}
+	g.record(seat, Event{Type: EventDeal, Count: len(cards), Cards: shown})
+}
+
+// Showing returns the cards seat has face up, in the order dealt.
+func (rs *RoundState) Showing() Hand {
+	var up Hand
+	for i, c := range rs.Hand {
+		if i < len(rs.FaceUp) && rs.FaceUp[i] {
+			up = append(up, c)
+		}
+	}
+	return up
+}
+
+// bringIn opens third street: the lowest card showing is forced to bring it
+// in for half the ante, and the action continues to its left. Everyone
+// calling the bring-in closes the round, so it counts as having acted.
+func (g *GameState) bringIn(ante int) {
+	g.enterPhase(PhaseThirdStreet)
+	low := -1
+	for _, seat := range g.contenders() {
+		if low < 0 || g.lowerCard(g.RoundStates[seat].Showing()[0], g.RoundStates[low].Showing()[0]) {
+			low = seat
+		}
+	}
+	bring := max(1, ante/2)
+	g.postBlind(low, bring, "bring_in")
+	g.RoundStates[low].Acted = true
+	g.CurrentBet = bring
+	g.LastAction = fmt.Sprintf("%s brings it in for %d.", g.Players[low].Name, g.RoundStates[low].Bet)
+	g.startBetting(g.nextSeat(low, g.inHand))
+}
+
+// lowerCard orders cards for the bring-in: by rank, aces and then wild
+// cards high, then by suit from clubs up to spades.
+func (g *GameState) lowerCard(a, b Card) bool {
+	value := func(c Card) int {
+		if g.Rules.Wild(c) {
+			return 15
+		}
+		return CardValue(c.Rank)
+	}
+	if va, vb := value(a), value(b); va != vb {
+		return va < vb
+	}
+	return slices.Index(Suits, a.Suit) > slices.Index(Suits, b.Suit)
+}
+
+// dealStudStreet deals every seat still in one more card, face up or (on
+// seventh street) face down, and opens the betting round phase with the
+// best hand showing.
+func (g *GameState) dealStudStreet(phase GamePhase, up bool) {
+	g.enterPhase(phase)
+	g.resetBets()
+	for _, seat := range g.contenders() {
+		g.dealStud(seat, up)
+	}
+	first := g.bestShowing()
+	g.LastAction = fmt.Sprintf("%s: %s to act.", phaseTitle(phase), g.Players[first].Name)
+	g.startBetting(first)
+}
+
+// bestShowing returns the seat with the best hand showing, ties going to
+// the first of them left of the button. Only pairs and sets count: too few
+// cards are showing for straights and flushes.
+func (g *GameState) bestShowing() int {
+	best := -1
+	var bestValue HandValue
+	for step := 1; step <= len(g.Players); step++ {
+		seat := (g.DealerIndex + step) % len(g.Players)
+		if !g.inHand(seat) {
+			continue
+		}
+		v := g.Rules.partialValue(g.RoundStates[seat].Showing())
+		if best < 0 || compareValues(v, bestValue) > 0 {
+			best, bestValue = seat, v
+		}
+	}
+	return best
+}
+
+// phaseTitle names a stud street for messages.
+func phaseTitle(phase GamePhase) string {
+	switch phase {
+	case PhaseFourthStreet:
+		return "Fourth street"
+	case PhaseFifthStreet:
+		return "Fifth street"
+	case PhaseSixthStreet:
+		return "Sixth street"
+	case PhaseSeventhStreet:
+		return "Seventh street"
+	}
+	return phase.String()
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// newStudTable seats n humans for a game of seven-card stud, dealt from
+// deck if it is not nil.
+func newStudTable(t *testing.T, n int, deck Deck) *GameState {
+	t.Helper()
+	g := newHumanTable(t, n)
+	if err := g.SetVariant(VariantStud); err != nil {
+		t.Fatalf("SetVariant: %v", err)
+	}
+	if deck != nil {
+		g.StackDeck(deck)
+	}
+	g.CollectAnte(10)
+	return g
+}
+
+// studDeck stacks a deck for a three-handed hand of stud. Third street
+// shows the king of hearts, the deuce of clubs and the deuce of spades;
+// fourth street pairs seat 1's deuce.
+func studDeck() Deck {
+	deck := Deck{
+		{Hearts, "4"}, {Hearts, "5"}, {Hearts, "king"}, // Seat 0
+		{Spades, "9"}, {Diamonds, "jack"}, {Clubs, "2"}, // Seat 1
+		{Clubs, "ace"}, {Diamonds, "ace"}, {Spades, "2"}, // Seat 2
+		{Diamonds, "3"}, {Diamonds, "2"}, {Hearts, "9"}, // Fourth street
+	}
+	// The rest of the deck, in order, for the later streets
+	for _, c := range orderedDeck() {
+		if !slices.Contains(deck, c) {
+			deck = append(deck, c)
+		}
+	}
+	return deck
+}
+
+func TestStudPhasesRoundTrip(t *testing.T) {
+	for _, p := range []GamePhase{PhaseThirdStreet, PhaseFourthStreet, PhaseFifthStreet, PhaseSixthStreet, PhaseSeventhStreet} {
+		data, _ := json.Marshal(p)
+		var back GamePhase
+		if err := json.Unmarshal(data, &back); err != nil || back != p {
+			t.Errorf("%s came back as %s (%v)", data, back, err)
+		}
+	}
+}
+
+func TestStudBringIn(t *testing.T) {
+	g := newStudTable(t, 3, studDeck())
+
+	if g.GamePhase != PhaseThirdStreet {
+		t.Fatalf("expected third street, got %s", g.GamePhase)
+	}
+	for i, rs := range g.RoundStates {
+		if len(rs.Hand) != 3 || !slices.Equal(rs.FaceUp, []bool{false, false, true}) {
+			t.Errorf("seat %d dealt %d cards face up %v, want two down and one up", i, len(rs.Hand), rs.FaceUp)
+		}
+	}
+
+	// The deuce of clubs is lowest, suits breaking the tie
+	if g.RoundStates[1].Bet != 5 || g.CurrentBet != 5 || g.TurnIndex != 2 {
+		t.Fatalf("expected seat 1 to bring it in for 5 and seat 2 to act, got bet %d/%d seat %d", g.RoundStates[1].Bet, g.CurrentBet, g.TurnIndex)
+	}
+
+	// Calling the bring-in around closes the round without another action
+	act(t, g, "call", 0)
+	act(t, g, "call", 0)
+	if g.GamePhase != PhaseFourthStreet {
+		t.Fatalf("expected fourth street once the bring-in was called, got %s", g.GamePhase)
+	}
+}
+
+func TestStudBestShowingActsFirst(t *testing.T) {
+	g := newStudTable(t, 3, studDeck())
+	act(t, g, "call", 0)
+	act(t, g, "call", 0)
+
+	// Seat 1 shows a pair of deuces, beating seat 0's king high
+	if g.TurnIndex != 1 {
+		t.Errorf("expected the pair showing to act first on fourth street, got seat %d", g.TurnIndex)
+	}
+	if best := g.bestShowing(); best != 1 {
+		t.Errorf("expected seat 1 to show the best hand, got %d", best)
+	}
+}
+
+func TestStudPlaysFiveBettingRounds(t *testing.T) {
+	g := newStudTable(t, 3, nil)
+	var rounds []string
+	for i := 0; i < 50 && g.GamePhase != PhaseComplete; i++ {
+		if n := len(rounds); n == 0 || rounds[n-1] != g.GamePhase.String() {
+			rounds = append(rounds, g.GamePhase.String())
+		}
+		if ok, _ := g.SeatAction(g.TurnIndex, "check", 0); !ok {
+			act(t, g, "call", 0)
+		}
+	}
+
+	want := []string{"third_street", "fourth_street", "fifth_street", "sixth_street", "seventh_street"}
+	if !slices.Equal(rounds, want) {
+		t.Errorf("expected betting rounds %v, got %v", want, rounds)
+	}
+	for i, rs := range g.RoundStates {
+		if !slices.Equal(rs.FaceUp, []bool{false, false, true, true, true, true, false}) {
+			t.Errorf("seat %d ended with face up %v, want three down and four up", i, rs.FaceUp)
+		}
+	}
+	for _, e := range g.Events {
+		if e.Type == EventShow && (len(e.Cards) != 7 || len(e.Indices) != 5) {
+			t.Errorf("seat %d showed %d cards using %v, want 7 using 5", e.Seat, len(e.Cards), e.Indices)
+		}
+	}
+}
+
+func TestStudViewShowsUpCards(t *testing.T) {
+	g := newStudTable(t, 3, studDeck())
+	act(t, g, "call", 0)
+	act(t, g, "call", 0)
+
+	v := g.View(g.Players[0].ID)
+	other := v.RoundStates[1]
+	if other.Hand != nil {
+		t.Errorf("expected seat 1's down cards to stay hidden")
+	}
+	want := Hand{{Clubs, "2"}, {Diamonds, "2"}}
+	if !slices.Equal(other.Showing, want) || other.HandSize != 4 {
+		t.Errorf("expected seat 1 to show %v of 4 cards, got %v of %d", want, other.Showing, other.HandSize)
+	}
+	if !slices.Equal(other.FaceUp, []bool{false, false, true, true}) {
+		t.Errorf("expected seat 1's face up pattern, got %v", other.FaceUp)
+	}
+}
+
+func TestReplayStud(t *testing.T) {
+	for seed := uint64(1); seed <= 5; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		g.SetVariant(VariantStud)
+		playScripted(g)
+
+		if _, err := Replay(g.CurrentHand(), g.Seed, nil); err != nil {
+			t.Fatalf("seed %d: replay failed: %v", seed, err)
+		}
+	}
+}
+
+func TestStudEquityReadsUpCards(t *testing.T) {
+	rng := NewRNG(1)
+	kings := Hand{{Hearts, "king"}, {Spades, "king"}, {Clubs, "7"}, {Diamonds, "4"}}
+
+	// The same pair of kings against four rags and against four aces
+	rags := Hand{{Hearts, "2"}, {Clubs, "5"}, {Diamonds, "8"}, {Spades, "jack"}}
+	aces := Hand{{Hearts, "ace"}, {Clubs, "ace"}, {Diamonds, "ace"}, {Spades, "ace"}}
+	weak := studEquity(kings, []Hand{rags}, Rules{}, 500, rng)
+	quads := studEquity(kings, []Hand{aces}, Rules{}, 500, rng)
+	if weak < 0.6 {
+		t.Errorf("expected kings to be ahead of rags showing, got %.2f", weak)
+	}
+	if quads != 0 {
+		t.Errorf("expected kings to never beat four aces showing, got %.2f", quads)
+	}
+}
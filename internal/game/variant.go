@@ -0,0 +1,62 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variant is the poker game a table deals.
+type Variant string
+
+const (
+	VariantDraw   Variant = "draw"   // Five-card draw, the default
+	VariantHoldem Variant = "holdem" // Texas Hold'em
+	VariantStud   Variant = "stud"   // Seven-card stud
+)
+
+// variant is the game being dealt, defaulting to draw.
+func (g *GameState) variant() Variant {
+	if g.Variant == "" {
+		return VariantDraw
+	}
+	return g.Variant
+}
+
+// SetVariant changes the game the table deals. It takes effect from the
+// next hand, so it cannot be changed while one is being played.
+func (g *GameState) SetVariant(v Variant) error {
+	switch v {
+	case VariantDraw, VariantHoldem, VariantStud:
+	default:
+		return fmt.Errorf("unknown variant %q", v)
+	}
+	if g.handInProgress() {
+		return fmt.Errorf("cannot change the game during a hand")
+	}
+	g.Variant = v
+	return nil
+}
+
+// startBetting opens a betting round with the action on first, or the next
+// seat after it that owes one. With nobody left to bet against once the
+// forced bets are matched or all in, the hand runs out to showdown.
+func (g *GameState) startBetting(first int) {
+	next := first
+	if next < 0 || !g.toAct(next) {
+		next = g.nextSeat(first, g.toAct)
+	}
+	if next < 0 || (g.bettors() < 2 && g.RoundStates[next].Bet >= g.CurrentBet) {
+		g.NextPhase()
+		return
+	}
+	g.setTurn(next)
+}
+
+// describeCards names cards for messages, e.g. "king of hearts, 2 of clubs".
+func describeCards(cards []Card) string {
+	names := make([]string, len(cards))
+	for i, c := range cards {
+		names[i] = fmt.Sprintf("%s of %s", c.Rank, c.Suit)
+	}
+	return strings.Join(names, ", ")
+}
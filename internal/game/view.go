@@ -0,0 +1,163 @@
+package game
+
+import "slices"
+
+// View is a per-viewer projection of a GameState that is safe to hand to a
+// client. It never includes the deck or the ESP answer, and only carries
+// another player's hand once that hand has legitimately been shown.
+type View struct {
+	ID          string      `json:"id"`
+	Players     []Player    `json:"players"`
+	RoundStates []RoundView `json:"round_states"` // Index-matched with Players
+	Pot         int         `json:"pot"`
+	Pots        []Pot       `json:"pots"`
+	TurnIndex   int         `json:"turn_index"`
+	DealerIndex int         `json:"dealer_index"`
+	ViewerSeat  int         `json:"viewer_seat"` // -1 when the viewer is not seated
+	GamePhase   GamePhase   `json:"game_phase"`
+	Variant     Variant     `json:"variant"`
+	Board       Hand        `json:"board"` // Community cards, always public
+	Rules       Rules       `json:"rules"`
+	RulesName   string      `json:"rules_name"` // e.g. "Eldritch Wilds: deuces wild"
+	DeckSize    int         `json:"deck_size"`  // Cards remaining, never the cards themselves
+	DrawRound   int         `json:"draw_round"` // In draw poker, which draw this is, from 1; 0 before the first
+	Draws       int         `json:"draws"`      // ... out of how many
+
+	CurrentBet   int       `json:"current_bet"`
+	BetRange     *BetRange `json:"bet_range,omitempty"` // What the viewer may bet or raise by, when it is their turn
+	LastAction   string    `json:"last_action"`
+	ActivePlayer string    `json:"active_player"`
+	Winner       string    `json:"winner"`
+	RevealOnFold bool      `json:"reveal_on_fold"`
+
+	LegalActions LegalActions `json:"legal_actions"`   // What the viewer may do now
+	Delay        int          `json:"delay,omitempty"` // In a delayed broadcast, how many actions behind live play it is
+
+	ESP *ESPView `json:"esp,omitempty"`
+}
+
+// RoundView is the public part of a RoundState. Hand is nil when the viewer
+// is not allowed to see it; HandSize is always set so face-down cards can
+// still be drawn, and in stud the face-up cards are always shown.
+type RoundView struct {
+	Hand       Hand   `json:"hand"`
+	HandSize   int    `json:"hand_size"`
+	FaceUp     []bool `json:"face_up,omitempty"` // In stud, which cards of the hand are face up
+	Showing    Hand   `json:"showing,omitempty"` // ... and those cards, which everyone can see
+	Bet        int    `json:"bet"`
+	Committed  int    `json:"committed"`
+	AllIn      bool   `json:"all_in"`
+	Folded     bool   `json:"folded"`
+	Discarded  bool   `json:"discarded"`
+	Drew       int    `json:"drew"`
+	SittingOut bool   `json:"sitting_out"`
+}
+
+// ESPView is the ESP minigame without the match indices.
+type ESPView struct {
+	Hand1     Hand   `json:"hand1"`
+	Hand2     Hand   `json:"hand2"`
+	Attempts  int    `json:"attempts"`
+	Theme     string `json:"theme"`
+	StartTime int64  `json:"start_time"`
+}
+
+// View builds the projection of the game as seen by viewerID. An unknown
+// viewer (e.g. an empty string) sees only what has been shown to the table.
+func (g *GameState) View(viewerID string) *View {
+	v := &View{
+		ID:           g.ID,
+		Players:      make([]Player, len(g.Players)),
+		RoundStates:  make([]RoundView, len(g.RoundStates)),
+		Pot:          g.Pot,
+		Pots:         slices.Clone(g.Pots),
+		TurnIndex:    g.TurnIndex,
+		DealerIndex:  g.DealerIndex,
+		ViewerSeat:   g.SeatOf(viewerID),
+		GamePhase:    g.GamePhase,
+		Variant:      g.variant(),
+		Board:        append(Hand{}, g.Board...),
+		Rules:        g.Rules,
+		RulesName:    g.Rules.Name(),
+		DeckSize:     len(g.Deck),
+		DrawRound:    g.DrawRound,
+		Draws:        g.Rules.draws(),
+		CurrentBet:   g.CurrentBet,
+		LastAction:   g.LastAction,
+		ActivePlayer: g.ActivePlayer,
+		Winner:       g.Winner,
+		RevealOnFold: g.RevealOnFold,
+	}
+
+	if r, ok := g.BetRange(v.ViewerSeat); ok {
+		v.BetRange = &r
+	}
+	v.LegalActions = g.LegalActions(v.ViewerSeat)
+
+	for i, p := range g.Players {
+		v.Players[i] = *p
+	}
+
+	for i, rs := range g.RoundStates {
+		rv := RoundView{
+			HandSize:   len(rs.Hand),
+			FaceUp:     slices.Clone(rs.FaceUp),
+			Showing:    rs.Showing(),
+			Bet:        rs.Bet,
+			Committed:  rs.Committed,
+			AllIn:      rs.AllIn,
+			Folded:     rs.Folded,
+			Discarded:  rs.Discarded,
+			Drew:       rs.Drew,
+			SittingOut: rs.SittingOut,
+		}
+		if g.handVisibleTo(i, viewerID) {
+			rv.Hand = append(Hand(nil), rs.Hand...)
+		}
+		v.RoundStates[i] = rv
+	}
+
+	if g.ESP != nil {
+		v.ESP = &ESPView{
+			Hand1:     append(Hand(nil), g.ESP.Hand1...),
+			Hand2:     append(Hand(nil), g.ESP.Hand2...),
+			Attempts:  g.ESP.Attempts,
+			Theme:     g.ESP.Theme,
+			StartTime: g.ESP.StartTime,
+		}
+	}
+
+	return v
+}
+
+// handVisibleTo reports whether the hand at seat may be shown to viewerID.
+// Players always see their own cards. Everyone else's cards stay hidden until
+// the hand is over, and then only if they reached showdown, or the hand was
+// won by a fold and RevealOnFold is set. Folded hands are never shown.
+func (g *GameState) handVisibleTo(seat int, viewerID string) bool {
+	if viewerID != "" && g.Players[seat].ID == viewerID {
+		return true
+	}
+	if g.RoundStates[seat].Folded || !g.handOver() {
+		return false
+	}
+	if g.endedByFold() {
+		return g.RevealOnFold
+	}
+	return true
+}
+
+// handOver reports whether the current (or most recent) hand has finished.
+func (g *GameState) handOver() bool {
+	switch g.GamePhase {
+	case PhaseShowdown, PhaseComplete, PhaseGameOver, PhaseESP:
+		return true
+	}
+	return false
+}
+
+// endedByFold reports whether the most recent hand was won without a
+// showdown because everyone else folded.
+func (g *GameState) endedByFold() bool {
+	return len(g.contenders()) < 2
+}
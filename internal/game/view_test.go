@@ -0,0 +1,152 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// viewJSON marshals the view exactly as the handlers would send it.
+func viewJSON(t *testing.T, v *View) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal view: %v", err)
+	}
+	return string(data)
+}
+
+// cardJSON is the wire form of a card, used to search views for leaks.
+func cardJSON(c Card) string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+func assertHidden(t *testing.T, body string, hand Hand) {
+	t.Helper()
+	for _, c := range hand {
+		if strings.Contains(body, cardJSON(c)) {
+			t.Errorf("view leaked hidden card %s of %s", c.Rank, c.Suit)
+		}
+	}
+}
+
+func TestViewHidesDeckAndOpponentHand(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+
+	v := g.View(g.Players[0].ID)
+	body := viewJSON(t, v)
+
+	if strings.Contains(body, `"deck"`) {
+		t.Errorf("view should not contain the deck")
+	}
+	if v.DeckSize != len(g.Deck) {
+		t.Errorf("expected deck size %d, got %d", len(g.Deck), v.DeckSize)
+	}
+	if len(v.RoundStates[0].Hand) != 5 {
+		t.Errorf("viewer should see own hand, got %v", v.RoundStates[0].Hand)
+	}
+	if v.RoundStates[1].Hand != nil {
+		t.Errorf("opponent hand should be hidden before showdown")
+	}
+	if v.RoundStates[1].HandSize != 5 {
+		t.Errorf("expected opponent hand size 5, got %d", v.RoundStates[1].HandSize)
+	}
+
+	assertHidden(t, body, g.RoundStates[1].Hand)
+	assertHidden(t, body, Hand(g.Deck))
+}
+
+func TestViewForUnknownViewerHidesAllHands(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+
+	body := viewJSON(t, g.View(""))
+	for _, rs := range g.RoundStates {
+		assertHidden(t, body, rs.Hand)
+	}
+}
+
+func TestViewRevealsAtShowdown(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+	g.CompleteShowdown()
+
+	v := g.View(g.Players[0].ID)
+	if len(v.RoundStates[1].Hand) != 5 {
+		t.Errorf("opponent hand should be revealed at showdown")
+	}
+}
+
+func TestShowdownAfterAFoldKeepsTheMuckHidden(t *testing.T) {
+	g := NewGame("")
+	g.RevealOnFold = false
+	g.CollectAnte(10)
+	g.PlayerAction("fold", 0)
+
+	winner, last, events := g.Winner, g.LastAction, len(g.Events)
+	if !g.CanShowdown() || g.ShowedDown() {
+		t.Fatalf("expected a hand won by a fold, in %s", g.GamePhase)
+	}
+	g.CompleteShowdown()
+	if g.Winner != winner || g.LastAction != last || len(g.Events) != events {
+		t.Errorf("showdown after a fold changed the hand: %q, %q", g.Winner, g.LastAction)
+	}
+	v := g.View(g.Players[0].ID)
+	if v.RoundStates[1].Hand != nil {
+		t.Errorf("opponent hand should stay hidden after a fold")
+	}
+	assertHidden(t, viewJSON(t, v), g.RoundStates[1].Hand)
+}
+
+func TestViewRevealOnFold(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+	g.PlayerAction("fold", 0)
+
+	g.RevealOnFold = true
+	if v := g.View(g.Players[0].ID); len(v.RoundStates[1].Hand) != 5 {
+		t.Errorf("opponent hand should be revealed when RevealOnFold is set")
+	}
+
+	g.RevealOnFold = false
+	v := g.View(g.Players[0].ID)
+	if v.RoundStates[1].Hand != nil {
+		t.Errorf("opponent hand should stay hidden when RevealOnFold is unset")
+	}
+	assertHidden(t, viewJSON(t, v), g.RoundStates[1].Hand)
+
+	// The folded hand itself is mucked, never shown to the other side
+	if v := g.View(g.Players[1].ID); v.RoundStates[0].Hand != nil {
+		t.Errorf("folded hand should not be shown to other players")
+	}
+}
+
+func TestViewHidesESPAnswer(t *testing.T) {
+	g := NewGame("")
+	if ok, msg := g.StartESP(); !ok {
+		t.Fatalf("StartESP failed: %s", msg)
+	}
+
+	v := g.View(g.Players[0].ID)
+	body := viewJSON(t, v)
+	if strings.Contains(body, "match_index") {
+		t.Errorf("view should not contain ESP match indices: %s", body)
+	}
+	if v.ESP == nil || len(v.ESP.Hand1) != 5 || len(v.ESP.Hand2) != 5 {
+		t.Errorf("ESP hands should still be visible")
+	}
+}
+
+func TestViewHidesSeed(t *testing.T) {
+	g := NewGame("")
+	g.CollectAnte(10)
+
+	body := viewJSON(t, g.View(g.Players[0].ID))
+	for _, key := range []string{`"seed"`, `"rng"`} {
+		if strings.Contains(body, key) {
+			t.Errorf("view should not contain %s: the deck could be recomputed from it", key)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package game
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// Strength scores a five-card hand under these rules. Each wild card
+// stands in for whichever card makes the hand best, even one already in
+// it, so five of a kind is possible and beats a royal flush.
+func (r Rules) Strength(hand []Card) HandStrength {
+	if !r.wilds() || len(hand) != 5 {
+		return Strength(hand)
+	}
+	var buf [5]Card
+	naturals := buf[:0]
+	for _, c := range hand {
+		if r.Wild(c) {
+			continue
+		}
+		if rankIndex(c.Rank) < 0 {
+			return 0
+		}
+		naturals = append(naturals, c)
+	}
+	if len(naturals) == 5 {
+		return Strength(hand)
+	}
+	return wildStrength(naturals, 5-len(naturals))
+}
+
+// wildStrength is the strength of the best hand naturals make with the
+// given number of wild cards. Rather than trying every substitution it
+// tries each category from the top, filling in the cards that make it.
+func wildStrength(naturals []Card, wilds int) HandStrength {
+	var counts [15]int
+	var ranks uint16 // Bit v set for each value v held
+	suited := true
+	for _, c := range naturals {
+		v := CardValue(c.Rank)
+		counts[v]++
+		ranks |= 1 << v
+		suited = suited && c.Suit == naturals[0].Suit
+	}
+	distinct := bits.OnesCount16(ranks) == len(naturals)
+
+	hand := make(Hand, len(naturals), 5)
+	copy(hand, naturals)
+	add := func(v int, suit Suit) {
+		hand = append(hand, Card{Suit: suit, Rank: Ranks[v-2]})
+	}
+	// free is a suit in which v is not yet in the hand
+	free := func(v int) Suit {
+		for _, s := range Suits {
+			if !slices.Contains(hand, Card{Suit: s, Rank: Ranks[v-2]}) {
+				return s
+			}
+		}
+		return Suits[0]
+	}
+	// kind is the highest value held that the wild cards make n of
+	kind := func(n int) int {
+		for v := 14; v >= 2; v-- {
+			if counts[v] > 0 && counts[v]+wilds >= n {
+				return v
+			}
+		}
+		return 0
+	}
+	// straight is the highest straight every natural fits in
+	straight := func() int {
+		if !distinct {
+			return 0
+		}
+		for high := 14; high >= 5; high-- {
+			window := uint16(0x1f) << (high - 4)
+			if high == 5 {
+				window = 0x3c | 1<<14 // The wheel: ace to five
+			}
+			if ranks&^window == 0 {
+				return high
+			}
+		}
+		return 0
+	}
+	// fill adds the values of a straight to high that are missing
+	fill := func(high int, suit func(int) Suit) {
+		for v := high - 4; v <= high; v++ {
+			value := v
+			if value == 1 {
+				value = 14 // The wheel's ace
+			}
+			if ranks&(1<<value) == 0 {
+				add(value, suit(value))
+			}
+		}
+	}
+
+	switch {
+	case bits.OnesCount16(ranks) <= 1:
+		v := 14
+		if len(naturals) > 0 {
+			v = CardValue(naturals[0].Rank)
+		}
+		return fiveStrength[v-2]
+
+	case suited && straight() > 0:
+		fill(straight(), func(int) Suit { return naturals[0].Suit })
+
+	case kind(4) > 0:
+		v := kind(4)
+		for range 4 - counts[v] {
+			add(v, free(v))
+		}
+
+	case wilds == 1 && len(naturals) == 4 && bits.OnesCount16(ranks) == 2:
+		// Two pair: the wild card makes the higher pair a set
+		v := 15 - bits.LeadingZeros16(ranks)
+		add(v, free(v))
+
+	case suited:
+		// The highest cards missing from the flush
+		for v := 14; len(hand) < 5; v-- {
+			if ranks&(1<<v) == 0 {
+				add(v, naturals[0].Suit)
+			}
+		}
+
+	case straight() > 0:
+		fill(straight(), free)
+
+	case kind(3) > 0:
+		v := kind(3)
+		for range 3 - counts[v] {
+			add(v, free(v))
+		}
+
+	default:
+		// One wild card pairs the highest card
+		v := 15 - bits.LeadingZeros16(ranks)
+		add(v, free(v))
+	}
+	return Strength(hand)
+}
+
+// BestFive is BestFive under these rules.
+func (r Rules) BestFive(cards []Card) (HandStrength, []int) {
+	if !r.wilds() {
+		return BestFive(cards)
+	}
+	return bestFive(cards, r.Strength)
+}
+
+// Evaluate is EvaluateHand under these rules.
+func (r Rules) Evaluate(hand []Card) HandValue {
+	if len(hand) < 5 {
+		return r.partialValue(hand)
+	}
+	s, _ := r.BestFive(hand)
+	return s.Value()
+}
+
+// partialValue is partialValue with the wild cards joining the biggest
+// set, or pairing the highest card.
+func (r Rules) partialValue(hand []Card) HandValue {
+	var naturals Hand
+	wilds := 0
+	for _, c := range hand {
+		if r.Wild(c) {
+			wilds++
+		} else {
+			naturals = append(naturals, c)
+		}
+	}
+	v := partialValue(naturals)
+	if wilds == 0 {
+		return v
+	}
+
+	size := wilds
+	switch v.Rank {
+	case HighCard:
+		v.Primary = 14 // Wild cards alone make aces
+		if len(v.Kickers) > 0 {
+			v.Primary, v.Kickers = v.Kickers[0], v.Kickers[1:]
+			size++
+		}
+	case OnePair:
+		size += 2
+	case ThreeOfAKind:
+		size += 3
+	default:
+		// Two pair and four of a kind leave no room in four cards
+		return v
+	}
+	switch size {
+	case 1:
+		return HandValue{Rank: HighCard, Kickers: []int{14}}
+	case 2:
+		v.Rank = OnePair
+	case 3:
+		v.Rank = ThreeOfAKind
+	default:
+		v.Rank = FourOfAKind
+	}
+	return v
+}
+
+// Compare is CompareHands under these rules. In lowball it compares the
+// lows; in hi-lo it compares the high hands, for the high half of the pot.
+func (r Rules) Compare(hand1, hand2 []Card) ComparisonResult {
+	if r.lowball() {
+		return r.CompareLow(hand1, hand2)
+	}
+	s1, _ := r.BestFive(hand1)
+	s2, _ := r.BestFive(hand2)
+	return compareStrengths(s1, s2)
+}
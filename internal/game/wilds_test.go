@@ -0,0 +1,234 @@
+package game
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+var (
+	jokersWild = Rules{Jokers: true}
+	eldritch   = Rules{Jokers: true, DeucesWild: true}
+)
+
+// bruteJokerStrength is the strength of hand with each joker replaced by
+// every card it could stand for, the slow way.
+func bruteJokerStrength(hand Hand) HandStrength {
+	var naturals Hand
+	for _, c := range hand {
+		if c.Rank != Joker {
+			naturals = append(naturals, c)
+		}
+	}
+	// Five of a kind needs more of a value than the deck holds
+	if !slices.ContainsFunc(naturals, func(c Card) bool { return c.Rank != naturals[0].Rank }) {
+		return fiveStrength[CardValue(naturals[0].Rank)-2]
+	}
+	i := slices.IndexFunc(hand, func(c Card) bool { return c.Rank == Joker })
+	if i < 0 {
+		return Strength(hand)
+	}
+	best := HandStrength(0)
+	for _, c := range orderedDeck() {
+		if slices.Contains(hand, c) {
+			continue
+		}
+		sub := slices.Clone(hand)
+		sub[i] = c
+		best = max(best, bruteJokerStrength(sub))
+	}
+	return best
+}
+
+// randomWildHands returns n shuffled five-card hands from deck holding at
+// least one card wild under rules.
+func randomWildHands(n int, deck Deck, rules Rules) []Hand {
+	rng := NewRNG(7)
+	var hands []Hand
+	for len(hands) < n {
+		d := slices.Clone(deck)
+		ShuffleDeck(&d, rng)
+		if slices.ContainsFunc(d[:5], rules.Wild) {
+			hands = append(hands, Hand(d[:5]))
+		}
+	}
+	return hands
+}
+
+func TestWildStrengthMatchesBruteForce(t *testing.T) {
+	for _, hand := range randomWildHands(300, jokersWild.fullDeck(), jokersWild) {
+		if got, want := jokersWild.Strength(hand), bruteJokerStrength(hand); got != want {
+			t.Fatalf("%v: got %s (%d), want %s (%d)", hand, GetHandName(got.Rank()), got, GetHandName(want.Rank()), want)
+		}
+	}
+
+	// Both jokers at once
+	hand := Hand{{"black", Joker}, {"red", Joker}, {Hearts, "9"}, {Hearts, "jack"}, {Clubs, "4"}}
+	if got, want := jokersWild.Strength(hand), bruteJokerStrength(hand); got != want {
+		t.Errorf("%v: got %s, want %s", hand, GetHandName(got.Rank()), GetHandName(want.Rank()))
+	}
+}
+
+func TestDeucesPlayLikeJokers(t *testing.T) {
+	deuces := Rules{DeucesWild: true}
+	for _, hand := range randomWildHands(300, orderedDeck(), deuces) {
+		jokered := slices.Clone(hand)
+		for i, c := range jokered {
+			if c.Rank == "2" {
+				jokered[i] = Card{"red", Joker}
+			}
+		}
+		if deuces.Strength(hand) != jokersWild.Strength(jokered) {
+			t.Fatalf("%v should play like %v", hand, jokered)
+		}
+	}
+}
+
+func TestFiveOfAKindBeatsRoyalFlush(t *testing.T) {
+	five := Hand{{Hearts, "ace"}, {Spades, "ace"}, {Clubs, "ace"}, {Diamonds, "ace"}, {"red", Joker}}
+	royal := Hand{{Spades, "king"}, {Spades, "queen"}, {Spades, "jack"}, {Spades, "10"}, {"black", Joker}}
+
+	if v := jokersWild.Evaluate(five); v.Rank != FiveOfAKind || v.Primary != 14 {
+		t.Errorf("expected five aces, got %s of %d", GetHandName(v.Rank), v.Primary)
+	}
+	if v := jokersWild.Evaluate(royal); v.Rank != RoyalFlush {
+		t.Errorf("expected the joker to make a royal flush, got %s", GetHandName(v.Rank))
+	}
+	if jokersWild.Compare(five, royal) != ResultHand1Wins {
+		t.Errorf("expected five of a kind to beat a royal flush")
+	}
+	if Strength(five) != 0 {
+		t.Errorf("without wild cards a joker should make the hand invalid")
+	}
+}
+
+func TestWildPartialValues(t *testing.T) {
+	deuces := Rules{DeucesWild: true}
+	tests := []struct {
+		hand    Hand
+		rank    HandRank
+		primary int
+	}{
+		{Hand{{Hearts, "queen"}, {Spades, "2"}}, OnePair, 12},
+		{Hand{{Hearts, "2"}}, HighCard, 0},
+		{Hand{{Hearts, "2"}, {Spades, "2"}}, OnePair, 14},
+		{Hand{{Hearts, "9"}, {Spades, "9"}, {Clubs, "2"}}, ThreeOfAKind, 9},
+		{Hand{{Hearts, "9"}, {Spades, "5"}, {Clubs, "2"}, {Diamonds, "2"}}, ThreeOfAKind, 9},
+	}
+	for _, tt := range tests {
+		val := deuces.Evaluate(tt.hand)
+		if val.Rank != tt.rank || val.Primary != tt.primary {
+			t.Errorf("%v: got %s of %d, want %s of %d", tt.hand, GetHandName(val.Rank), val.Primary, GetHandName(tt.rank), tt.primary)
+		}
+	}
+}
+
+func TestRulesDeck(t *testing.T) {
+	deck := jokersWild.NewDeck(NewRNG(1))
+	if len(deck) != 54 || !slices.Contains(deck, Card{"black", Joker}) || !slices.Contains(deck, Card{"red", Joker}) {
+		t.Errorf("expected 54 cards with both jokers, got %d", len(deck))
+	}
+	if len(Rules{DeucesWild: true}.NewDeck(NewRNG(1))) != 52 {
+		t.Errorf("deuces wild should not add cards")
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := map[string]Rules{
+		"":              {},
+		"none":          {},
+		"jokers":        {Jokers: true},
+		"deuces":        {DeucesWild: true},
+		"jokers,deuces": {Jokers: true, DeucesWild: true},
+	}
+	for s, want := range tests {
+		if got, err := ParseRules(s); err != nil || got != want {
+			t.Errorf("%q: got %+v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseRules("tentacles"); err == nil {
+		t.Errorf("expected unknown wild cards to be refused")
+	}
+}
+
+func TestChooseDiscardKeepsWilds(t *testing.T) {
+	for _, hand := range randomWildHands(20, eldritch.fullDeck(), eldritch) {
+		for _, discards := range [][]int{DefaultAI.ChooseDiscard(hand, eldritch, NewRNG(1)), typicalDraw(hand, eldritch)} {
+			for _, i := range discards {
+				if eldritch.Wild(hand[i]) {
+					t.Fatalf("%v: discarded wild card %v", hand, hand[i])
+				}
+			}
+		}
+	}
+}
+
+func TestTypicalDrawWithWilds(t *testing.T) {
+	tests := []struct {
+		name string
+		hand Hand
+		want []int
+	}{
+		{"pair and joker", Hand{{Hearts, "9"}, {Spades, "9"}, {"red", Joker}, {Clubs, "4"}, {Diamonds, "king"}}, []int{3, 4}},
+		{"joker and high card", Hand{{Hearts, "9"}, {Spades, "4"}, {"red", Joker}, {Clubs, "jack"}, {Diamonds, "6"}}, []int{0, 1, 4}},
+		{"made straight", Hand{{Hearts, "9"}, {Spades, "10"}, {"red", Joker}, {Clubs, "queen"}, {Diamonds, "king"}}, nil},
+	}
+	for _, tt := range tests {
+		if got := typicalDraw(tt.hand, jokersWild); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWildShowdown(t *testing.T) {
+	g := newHumanTable(t, 2)
+	if err := g.SetRules(Rules{DeucesWild: true}); err != nil {
+		t.Fatalf("SetRules: %v", err)
+	}
+	g.StackDeck(Deck{
+		{Hearts, "king"}, {Spades, "king"}, {Clubs, "9"}, {Diamonds, "4"}, {Hearts, "6"}, // Seat 0
+		{Hearts, "2"}, {Spades, "2"}, {Clubs, "7"}, {Diamonds, "jack"}, {Hearts, "3"}, // Seat 1
+	})
+	g.CollectAnte(10)
+	for g.GamePhase != PhaseComplete {
+		if g.GamePhase == PhaseDiscard {
+			g.SeatDiscard(g.TurnIndex, nil)
+			continue
+		}
+		act(t, g, "check", 0)
+	}
+
+	// Two deuces make trip jacks, beating the natural kings
+	if g.Winner != "Seat 1" {
+		t.Errorf("expected Seat 1 to win, got %q: %s", g.Winner, g.LastAction)
+	}
+	if err := g.SetRules(Rules{}); err != nil {
+		t.Errorf("expected the rules to change between hands: %v", err)
+	}
+}
+
+func TestReplayWithWilds(t *testing.T) {
+	for seed := uint64(1); seed <= 5; seed++ {
+		g := NewGame("p")
+		g.SetSeed(seed)
+		g.SetRules(eldritch)
+		playScripted(g)
+
+		// The rules survive a round trip through JSON, as saved hands do
+		data, err := json.Marshal(g.CurrentHand())
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var hand HandRecord
+		if err := json.Unmarshal(data, &hand); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if _, err := Replay(&hand, g.Seed, nil); err != nil {
+			t.Fatalf("seed %d: replay failed: %v", seed, err)
+		}
+		table, _ := replayTable(&hand, g.Seed)
+		if table == nil || table.Rules != eldritch {
+			t.Errorf("seed %d: expected the replayed table to play by %+v", seed, eldritch)
+		}
+	}
+}
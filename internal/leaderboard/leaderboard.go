@@ -0,0 +1,107 @@
+// Package leaderboard ranks the players with profiles against each other
+// over the results of the hands they finished in a window of time.
+package leaderboard
+
+import (
+	"fmt"
+	"time"
+)
+
+// MinHands is the fewest hands a player must have played in the window to
+// be ranked by win rate, so one lucky hand does not top the board.
+const MinHands = 20
+
+// DefaultLimit and MaxLimit are how many players a board lists unless asked
+// for fewer, and at most.
+const (
+	DefaultLimit = 10
+	MaxLimit     = 100
+)
+
+// Board is what players are ranked by.
+type Board string
+
+const (
+	BoardNet     Board = "net"      // Most sanity won over the hands, less what was lost
+	BoardWinRate Board = "win_rate" // Highest share of hands won, over at least MinHands
+	BoardStreak  Board = "streak"   // Most hands in a row against the Ancient One without going mad
+	BoardESP     Board = "esp"      // Quickest ESP solve, the fewest guesses breaking ties
+)
+
+// ParseBoard checks the name of a board.
+func ParseBoard(s string) (Board, error) {
+	switch b := Board(s); b {
+	case BoardNet, BoardWinRate, BoardStreak, BoardESP:
+		return b, nil
+	}
+	return "", fmt.Errorf("unknown leaderboard %q", s)
+}
+
+// Window is the stretch of time a board counts the hands of.
+type Window string
+
+const (
+	WindowDay  Window = "day"  // Since midnight UTC
+	WindowWeek Window = "week" // Since midnight UTC on Monday
+	WindowAll  Window = "all"  // Every hand ever, the default
+)
+
+// ParseWindow checks the name of a window; none means all time.
+func ParseWindow(s string) (Window, error) {
+	switch w := Window(s); w {
+	case "":
+		return WindowAll, nil
+	case WindowDay, WindowWeek, WindowAll:
+		return w, nil
+	}
+	return "", fmt.Errorf("unknown window %q", s)
+}
+
+// Since returns when the window opened as of now, or the zero time if it
+// spans all time.
+func (w Window) Since(now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch w {
+	case WindowDay:
+		return day
+	case WindowWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return time.Time{}
+}
+
+// Entry is one player's place on a board.
+type Entry struct {
+	Rank      int     `json:"rank"` // Players with the same score share a rank
+	ProfileID string  `json:"profile_id"`
+	Name      string  `json:"name"`
+	Avatar    string  `json:"avatar,omitempty"`
+	Score     float64 `json:"score"`             // Sanity, a share of hands, hands or milliseconds, by board
+	Hands     int     `json:"hands,omitempty"`   // Hands played in the window
+	Guesses   int     `json:"guesses,omitempty"` // Guesses the quickest ESP solve took
+}
+
+// Leaderboard is a board over a window, best first.
+type Leaderboard struct {
+	Board    Board     `json:"board"`
+	Window   Window    `json:"window"`
+	Since    time.Time `json:"since,omitzero"` // When the window opened; zero, and left out, for all time
+	MinHands int       `json:"min_hands,omitempty"`
+	Entries  []Entry   `json:"entries"`
+}
+
+// Rank numbers entries already in order from the best. Tied entries share
+// the better rank, and the next one skips as many: 1, 2, 2, 4.
+func Rank(entries []Entry) {
+	for i := range entries {
+		e := &entries[i]
+		e.Rank = i + 1
+		if i > 0 {
+			prev := entries[i-1]
+			if prev.Score == e.Score && prev.Guesses == e.Guesses {
+				e.Rank = prev.Rank
+			}
+		}
+	}
+}
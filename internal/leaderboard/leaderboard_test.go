@@ -0,0 +1,54 @@
+package leaderboard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowSince(t *testing.T) {
+	sunday := time.Date(2026, 10, 18, 23, 30, 0, 0, time.FixedZone("", -3*60*60)) // Monday morning UTC
+	tests := []struct {
+		window Window
+		want   time.Time
+	}{
+		{WindowDay, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{WindowWeek, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{WindowAll, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Since(sunday); !got.Equal(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.window, tt.want, got)
+		}
+	}
+
+	saturday := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	if got := WindowWeek.Since(saturday); !got.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the week to open on Monday, got %v", got)
+	}
+
+	if w, err := ParseWindow(""); err != nil || w != WindowAll {
+		t.Errorf("expected all time by default, got %q: %v", w, err)
+	}
+	if _, err := ParseWindow("month"); err == nil {
+		t.Errorf("expected an unknown window to be refused")
+	}
+	if _, err := ParseBoard("chips"); err == nil {
+		t.Errorf("expected an unknown board to be refused")
+	}
+}
+
+func TestRankSharesTies(t *testing.T) {
+	entries := []Entry{
+		{Score: 900, Guesses: 1},
+		{Score: 1200, Guesses: 1},
+		{Score: 1200, Guesses: 1},
+		{Score: 1200, Guesses: 3},
+		{Score: 1500},
+	}
+	Rank(entries)
+	for i, want := range []int{1, 2, 2, 4, 5} {
+		if entries[i].Rank != want {
+			t.Errorf("entry %d: expected rank %d, got %d", i, want, entries[i].Rank)
+		}
+	}
+}
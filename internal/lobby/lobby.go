@@ -0,0 +1,281 @@
+// Package lobby keeps the tables players create and sit down at. A table
+// outlives the sessions of the players at it: it holds who is seated and
+// watching and the rules its game is dealt with, while the game itself is
+// stored under the table's ID like any other.
+package lobby
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"card-shoggoths/internal/game"
+
+	"github.com/google/uuid"
+)
+
+// DefaultStake is the ante, or the big blind in Hold'em, of a table created
+// without one.
+const DefaultStake = 10
+
+// MaxNameLength is the longest a table or player name may be.
+const MaxNameLength = 32
+
+// MaxDelay is the longest broadcast delay a table may have, in actions.
+const MaxDelay = 50
+
+// Table is a game in the lobby. Its human seats are filled by players who
+// join; the AI seats are dealt in after them every hand.
+type Table struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Variant    game.Variant   `json:"variant,omitempty"`
+	Rules      game.Rules     `json:"rules"`
+	Stake      int            `json:"stake"`              // Ante, or the big blind in Hold'em
+	AISeats    int            `json:"ai_seats"`           // AI players dealt in after the humans
+	Seats      []Seat         `json:"seats"`              // Human seats in order; an empty one is open
+	Spectators []Seat         `json:"spectators"`         // Players watching without a seat
+	Delay      int            `json:"delay"`              // Actions spectators are shown every hand behind; 0 hides hole cards instead
+	Departed   map[string]int `json:"departed,omitempty"` // Sanity of the players who left, by ID, until the table closes
+	Created    time.Time      `json:"created"`
+}
+
+// Seat is a player at a table, or an open seat if PlayerID is empty.
+type Seat struct {
+	PlayerID string `json:"player_id,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+// New creates a table with seats human seats and aiSeats AI ones. The
+// variant and rules are checked the way a game would check them.
+func New(name string, variant game.Variant, rules game.Rules, stake, seats, aiSeats int) (*Table, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "The Nameless Table"
+	}
+	if len([]rune(name)) > MaxNameLength {
+		return nil, fmt.Errorf("table names are at most %d characters", MaxNameLength)
+	}
+	if stake == 0 {
+		stake = DefaultStake
+	}
+	if stake < 0 {
+		return nil, fmt.Errorf("the stake cannot be negative")
+	}
+	if seats < 1 || aiSeats < 0 {
+		return nil, fmt.Errorf("a table needs at least one human seat")
+	}
+	if total := seats + aiSeats; total < game.MinSeats || total > game.MaxSeats {
+		return nil, fmt.Errorf("a table needs %d to %d seats, got %d", game.MinSeats, game.MaxSeats, total)
+	}
+
+	// A scratch game checks the rules, so a table never holds any its game
+	// would refuse
+	g := game.NewGame("")
+	if variant != "" {
+		if err := g.SetVariant(variant); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.SetRules(rules); err != nil {
+		return nil, err
+	}
+
+	return &Table{
+		ID:         uuid.NewString(),
+		Name:       name,
+		Variant:    variant,
+		Rules:      rules,
+		Stake:      stake,
+		AISeats:    aiSeats,
+		Seats:      make([]Seat, seats),
+		Spectators: []Seat{},
+		Created:    time.Now(),
+	}, nil
+}
+
+// SetDelay sets the broadcast delay, in actions. With a delay spectators
+// see every hand face up that many actions behind live play; without one
+// they see the table live with the hole cards hidden.
+func (t *Table) SetDelay(delay int) error {
+	if delay < 0 || delay > MaxDelay {
+		return fmt.Errorf("the broadcast delay is from 0 to %d actions, not %d", MaxDelay, delay)
+	}
+	t.Delay = delay
+	return nil
+}
+
+// SeatOf returns the human seat of playerID, or -1 if they have none.
+func (t *Table) SeatOf(playerID string) int {
+	for i, s := range t.Seats {
+		if s.PlayerID == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Watching reports whether playerID is seated at the table or spectating.
+func (t *Table) Watching(playerID string) bool {
+	if t.SeatOf(playerID) >= 0 {
+		return true
+	}
+	for _, s := range t.Spectators {
+		if s.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// Open counts the human seats nobody has taken.
+func (t *Table) Open() int {
+	n := 0
+	for _, s := range t.Seats {
+		if s.PlayerID == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Empty reports whether nobody is seated at the table or watching it.
+func (t *Table) Empty() bool {
+	return t.Open() == len(t.Seats) && len(t.Spectators) == 0
+}
+
+// Join sits playerID down at seat, or at the first open seat if seat is
+// negative, and returns the seat taken. A spectator who joins stops
+// spectating.
+func (t *Table) Join(playerID, name string, seat int) (int, error) {
+	name, err := checkName(name)
+	if err != nil {
+		return -1, err
+	}
+	if t.SeatOf(playerID) >= 0 {
+		return -1, fmt.Errorf("you already have a seat at this table")
+	}
+	if sanity, ok := t.Departed[playerID]; ok && sanity <= 0 {
+		return -1, fmt.Errorf("you lost your mind at this table and cannot sit back down")
+	}
+	if seat < 0 {
+		for i, s := range t.Seats {
+			if s.PlayerID == "" {
+				seat = i
+				break
+			}
+		}
+		if seat < 0 {
+			return -1, fmt.Errorf("the table is full")
+		}
+	}
+	if seat >= len(t.Seats) {
+		return -1, fmt.Errorf("there is no seat %d", seat)
+	}
+	if t.Seats[seat].PlayerID != "" {
+		return -1, fmt.Errorf("seat %d is taken", seat)
+	}
+
+	t.unwatch(playerID)
+	t.Seats[seat] = Seat{PlayerID: playerID, Name: name}
+	return seat, nil
+}
+
+// Spectate lets playerID watch the table without a seat.
+func (t *Table) Spectate(playerID, name string) error {
+	name, err := checkName(name)
+	if err != nil {
+		return err
+	}
+	if t.SeatOf(playerID) >= 0 {
+		return fmt.Errorf("you already have a seat at this table")
+	}
+	if !t.Watching(playerID) {
+		t.Spectators = append(t.Spectators, Seat{PlayerID: playerID, Name: name})
+	}
+	return nil
+}
+
+// Leave gives up playerID's seat or stops them spectating, and reports
+// whether they were at the table.
+func (t *Table) Leave(playerID string) bool {
+	if seat := t.SeatOf(playerID); seat >= 0 {
+		t.Seats[seat] = Seat{}
+		return true
+	}
+	return t.unwatch(playerID)
+}
+
+// unwatch removes playerID from the spectators.
+func (t *Table) unwatch(playerID string) bool {
+	for i, s := range t.Spectators {
+		if s.PlayerID == playerID {
+			t.Spectators = append(t.Spectators[:i], t.Spectators[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Players seats the next hand: the humans in seat order, then the AI.
+// Players already at g keep their sanity and, for the AI, their
+// personality; those who left note theirs in Departed, to sit back down
+// with, so leaving is no way to buy back in. Newcomers start fresh. g is
+// nil before the first hand.
+func (t *Table) Players(g *game.GameState) []*game.Player {
+	var kept []*game.Player
+	if g != nil {
+		kept = g.Players
+	}
+	var players, ais []*game.Player
+	for _, p := range kept {
+		if p.IsAI {
+			ais = append(ais, p)
+		} else if t.SeatOf(p.ID) < 0 {
+			if t.Departed == nil {
+				t.Departed = make(map[string]int)
+			}
+			t.Departed[p.ID] = p.Sanity
+		}
+	}
+
+	for _, s := range t.Seats {
+		if s.PlayerID == "" {
+			continue
+		}
+		p := &game.Player{ID: s.PlayerID, Name: s.Name, Sanity: 100}
+		if sanity, ok := t.Departed[s.PlayerID]; ok {
+			p.Sanity = sanity
+			delete(t.Departed, s.PlayerID)
+		}
+		if g != nil {
+			if i := g.SeatOf(s.PlayerID); i >= 0 {
+				p = g.Players[i]
+			}
+		}
+		players = append(players, p)
+	}
+	for i := range t.AISeats {
+		if i < len(ais) {
+			players = append(players, ais[i])
+		} else {
+			players = append(players, game.NewAIPlayer(i))
+		}
+	}
+	return players
+}
+
+// checkName trims a player's name and refuses one that is empty, too long,
+// or "You", which the game keeps for the player reading it.
+func checkName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("a name is required")
+	case len([]rune(name)) > MaxNameLength:
+		return "", fmt.Errorf("names are at most %d characters", MaxNameLength)
+	case strings.EqualFold(name, "You"):
+		return "", fmt.Errorf("%q is reserved, choose another name", name)
+	}
+	return name, nil
+}
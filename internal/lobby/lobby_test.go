@@ -0,0 +1,129 @@
+package lobby
+
+import (
+	"strings"
+	"testing"
+
+	"card-shoggoths/internal/game"
+)
+
+func TestNewChecksSeatsAndRules(t *testing.T) {
+	if _, err := New("", "", game.Rules{}, 0, 1, 0); err == nil {
+		t.Errorf("expected a table of one to be refused")
+	}
+	if _, err := New("", "", game.Rules{}, 0, 0, 2); err == nil {
+		t.Errorf("expected a table without humans to be refused")
+	}
+	if _, err := New("", "", game.Rules{Betting: "spread_limit"}, 0, 2, 0); err == nil {
+		t.Errorf("expected rules the game refuses to be refused")
+	}
+	tbl, err := New("", game.VariantHoldem, game.Rules{}, 0, 2, 1)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if tbl.Stake != DefaultStake || tbl.Open() != 2 {
+		t.Errorf("expected two open seats at the default stake, got %+v", tbl)
+	}
+	if err := tbl.SetDelay(MaxDelay + 1); err == nil || tbl.Delay != 0 {
+		t.Errorf("expected an overlong broadcast delay to be refused")
+	}
+
+	// Names are counted in characters, as profiles count them
+	long := strings.Repeat("Ш", MaxNameLength)
+	if _, err := New(long, "", game.Rules{}, 0, 2, 0); err != nil {
+		t.Errorf("expected a name of %d characters to do, got %v", MaxNameLength, err)
+	}
+	if _, err := New(long+"Ш", "", game.Rules{}, 0, 2, 0); err == nil {
+		t.Errorf("expected a name over %d characters to be refused", MaxNameLength)
+	}
+	if _, err := tbl.Join("a", long, -1); err != nil {
+		t.Errorf("expected a player name of %d characters to do, got %v", MaxNameLength, err)
+	}
+}
+
+func TestJoinSpectateAndLeave(t *testing.T) {
+	tbl, _ := New("Ritual", "", game.Rules{}, 10, 2, 0)
+	if err := tbl.Spectate("a", "Armitage"); err != nil {
+		t.Fatalf("Spectate: %v", err)
+	}
+	if seat, err := tbl.Join("a", "Armitage", -1); err != nil || seat != 0 {
+		t.Fatalf("expected the first open seat, got %d: %v", seat, err)
+	}
+	if len(tbl.Spectators) != 0 {
+		t.Errorf("expected joining to stop spectating")
+	}
+	if _, err := tbl.Join("b", "Whateley", 0); err == nil {
+		t.Errorf("expected a taken seat to be refused")
+	}
+	if _, err := tbl.Join("b", "You", -1); err == nil {
+		t.Errorf("expected the reserved name to be refused")
+	}
+	tbl.Join("b", "Whateley", -1)
+	if _, err := tbl.Join("c", "Peaslee", -1); err == nil {
+		t.Errorf("expected a full table to be refused")
+	}
+
+	if !tbl.Leave("a") || tbl.Watching("a") || tbl.Open() != 1 {
+		t.Errorf("expected leaving to open the seat")
+	}
+	if tbl.Leave("a") {
+		t.Errorf("expected leaving twice to do nothing")
+	}
+}
+
+func TestPlayersKeepTheirSanity(t *testing.T) {
+	tbl, _ := New("", "", game.Rules{}, 10, 3, 1)
+	tbl.Join("a", "Armitage", -1)
+	tbl.Join("b", "Whateley", -1)
+	g, err := game.NewTable(tbl.Players(nil))
+	if err != nil {
+		t.Fatalf("NewTable: %v", err)
+	}
+	g.Players[0].Sanity = 42
+	g.SetPersonality(2, game.PersonalityManiac)
+
+	tbl.Leave("b")
+	tbl.Join("c", "Peaslee", -1)
+	players := tbl.Players(g)
+	if len(players) != 3 || players[0].Sanity != 42 || players[1].ID != "c" || players[1].Sanity != 100 {
+		t.Errorf("unexpected players %+v", players)
+	}
+	if ai := players[2]; !ai.IsAI || ai.Personality != game.PersonalityManiac {
+		t.Errorf("expected the AI seat to carry over, got %+v", ai)
+	}
+}
+
+func TestLeavingIsNoRebuy(t *testing.T) {
+	tbl, _ := New("", "", game.Rules{}, 10, 4, 0)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		tbl.Join(id, "Player "+id, -1)
+	}
+	g, err := game.NewTable(tbl.Players(nil))
+	if err != nil {
+		t.Fatalf("NewTable: %v", err)
+	}
+	g.Players[0].Sanity = 0
+	g.Players[1].Sanity = 42
+
+	// Gone by the next deal, they are remembered as they left
+	tbl.Leave("a")
+	tbl.Leave("b")
+	if err := g.Reseat(tbl.Players(g)); err != nil {
+		t.Fatalf("Reseat: %v", err)
+	}
+	if sanity, ok := tbl.Departed["a"]; !ok || sanity != 0 || tbl.Departed["b"] != 42 {
+		t.Errorf("expected the sanity of those who left kept, got %v", tbl.Departed)
+	}
+
+	if _, err := tbl.Join("a", "Player a", -1); err == nil {
+		t.Errorf("expected a player who lost their mind here not to sit back down")
+	}
+	tbl.Join("b", "Player b", -1)
+	players := tbl.Players(g)
+	if len(players) != 3 || players[0].ID != "b" || players[0].Sanity != 42 {
+		t.Errorf("expected b back with 42, got %+v", players[0])
+	}
+	if _, ok := tbl.Departed["b"]; ok {
+		t.Errorf("expected b no longer noted as departed")
+	}
+}
@@ -0,0 +1,100 @@
+// Package profile keeps what other players see of an account: its name, its
+// avatar and how it has fared over every game it has played.
+package profile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"card-shoggoths/internal/game"
+)
+
+// MaxNameLength is the longest a display name may be.
+const MaxNameLength = 32
+
+// Profile is an account as other players see it, under the account's ID.
+// The player IDs its games are stored under stay private: its own game's,
+// and the one it sits at tables under.
+type Profile struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Avatar   string    `json:"avatar,omitempty"` // An icon of the monster pack, none if empty
+	Created  time.Time `json:"created"`
+	PlayerID string    `json:"-"`
+	SeatID   string    `json:"-"`
+	Stats    *Stats    `json:"stats,omitempty"`
+}
+
+// Stats are a player's lifetime statistics, over every completed hand.
+type Stats struct {
+	Hands      int               `json:"hands"`       // Hands played
+	Won        int               `json:"won"`         // ... and come out of ahead
+	BiggestPot int               `json:"biggest_pot"` // Most sanity awarded in one hand
+	Best       game.HandStrength `json:"-"`
+	BestHand   string            `json:"best_hand,omitempty"`  // The strongest hand ever shown down
+	BestCards  game.Hand         `json:"best_cards,omitempty"` // ... and its cards
+	ESPRounds  int               `json:"esp_rounds"`
+	ESPSolved  int               `json:"esp_solved"`
+	ESPRate    float64           `json:"esp_rate"` // Share of ESP rounds solved
+	Mad        int               `json:"mad"`      // Times driven mad
+}
+
+// Finish names the best hand and works out the rates once the counts are in.
+func (s *Stats) Finish() {
+	if s.Best > 0 {
+		s.BestHand = game.GetHandName(s.Best.Rank())
+	}
+	if s.ESPRounds > 0 {
+		s.ESPRate = float64(s.ESPSolved) / float64(s.ESPRounds)
+	}
+}
+
+// New makes the profile of a new account, named as it signed up.
+func New(accountID, playerID, seatID, name string, created time.Time) *Profile {
+	return &Profile{ID: accountID, PlayerID: playerID, SeatID: seatID, Name: name, Created: created}
+}
+
+// SetName changes the display name.
+func (p *Profile) SetName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > MaxNameLength {
+		return fmt.Errorf("a name is 1 to %d characters", MaxNameLength)
+	}
+	if strings.EqualFold(name, "You") {
+		return fmt.Errorf("%q is reserved", name)
+	}
+	p.Name = name
+	return nil
+}
+
+// SetAvatar picks an icon of the monster pack, or none if empty.
+func (p *Profile) SetAvatar(avatar string) error {
+	if avatar != "" && !slices.Contains(Avatars(), avatar) {
+		return fmt.Errorf("there is no avatar %q", avatar)
+	}
+	p.Avatar = avatar
+	return nil
+}
+
+// The icons of the monster pack that are installed, by name.
+var (
+	avatars   []string
+	avatarsMu sync.RWMutex
+)
+
+// SetAvatars sets which icons of the monster pack may be picked.
+func SetAvatars(names []string) {
+	avatarsMu.Lock()
+	defer avatarsMu.Unlock()
+	avatars = slices.Sorted(slices.Values(names))
+}
+
+// Avatars returns the icons that may be picked, in order.
+func Avatars() []string {
+	avatarsMu.RLock()
+	defer avatarsMu.RUnlock()
+	return slices.Clone(avatars)
+}
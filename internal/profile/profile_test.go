@@ -0,0 +1,44 @@
+package profile
+
+import (
+	"testing"
+	"time"
+
+	"card-shoggoths/internal/game"
+)
+
+func TestNameAndAvatar(t *testing.T) {
+	p := New("a", "p", "s", "Armitage", time.Now())
+	for _, name := range []string{"You", "you", " YOU "} {
+		if err := p.SetName(name); err == nil || p.Name != "Armitage" {
+			t.Errorf("expected the reserved name %q to be refused", name)
+		}
+	}
+	if err := p.SetName(" Henry Armitage "); err != nil || p.Name != "Henry Armitage" {
+		t.Errorf("expected the name to change, got %q: %v", p.Name, err)
+	}
+
+	SetAvatars([]string{"shoggoth", "byakhee"})
+	defer SetAvatars(nil)
+	if err := p.SetAvatar("mi-go"); err == nil {
+		t.Errorf("expected an avatar outside the pack to be refused")
+	}
+	if err := p.SetAvatar("byakhee"); err != nil || p.Avatar != "byakhee" {
+		t.Errorf("expected the avatar picked, got %q: %v", p.Avatar, err)
+	}
+	if err := p.SetAvatar(""); err != nil || p.Avatar != "" {
+		t.Errorf("expected the avatar cleared, got %q: %v", p.Avatar, err)
+	}
+}
+
+func TestStatsFinish(t *testing.T) {
+	best, _ := game.BestFive(game.Hand{
+		{Suit: "hearts", Rank: "9"}, {Suit: "spades", Rank: "9"}, {Suit: "clubs", Rank: "9"},
+		{Suit: "hearts", Rank: "2"}, {Suit: "spades", Rank: "2"},
+	})
+	s := &Stats{Best: best, ESPRounds: 4, ESPSolved: 3}
+	s.Finish()
+	if s.BestHand != "Full House" || s.ESPRate != 0.75 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
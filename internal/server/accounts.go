@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"card-shoggoths/internal/account"
+)
+
+// credentials are what a player signs up or logs in with.
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Attach   bool   `json:"attach"` // Register: keep playing the anonymous session's game
+}
+
+// Attempts to sign up or log in are limited per client address, and
+// attempts to log in per username too, so guessing passwords online costs
+// time.
+var (
+	addressAttempts  = account.NewLimiter(30, 10*time.Minute)
+	usernameAttempts = account.NewLimiter(10, 10*time.Minute)
+)
+
+// clientAddress is the host the request came from. Forwarding headers are
+// not trusted, as anyone could set them to dodge the limits.
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limit is a limiter and the key an attempt counts under on it.
+type limit struct {
+	*account.Limiter
+	key string
+}
+
+// allowAttempt answers 429 if any of the limits has been reached, and
+// otherwise records the attempt on every one of them: a refused attempt
+// counts against none, so being locked out of one username does not use up
+// the address's attempts, nor the other way round.
+func allowAttempt(w http.ResponseWriter, limits ...limit) bool {
+	now := time.Now()
+	for _, l := range limits {
+		if !l.Permits(l.key, now) {
+			http.Error(w, "Too many attempts, try again later", http.StatusTooManyRequests)
+			return false
+		}
+	}
+	for _, l := range limits {
+		l.Record(l.key, now)
+	}
+	return true
+}
+
+// logIn ends the request's session and starts one logged in to the
+// account, so a session ID known from before can never act for it.
+func logIn(w http.ResponseWriter, r *http.Request, a *account.Account) error {
+	if err := gameStore.DeleteSession(currentSession(r).ID); err != nil {
+		log.Printf("[ERROR] Failed to end session: %v", err)
+		return err
+	}
+	if _, err := startSession(w, r, a); err != nil {
+		log.Printf("[ERROR] Failed to save session for %s: %v", a.Username, err)
+		return err
+	}
+	log.Printf("[DEBUG] %s logged in", a.Username)
+	return nil
+}
+
+// AccountHandler returns the account the session is logged in to.
+func AccountHandler(w http.ResponseWriter, r *http.Request) {
+	a := currentSession(r).Account
+	if a == nil {
+		http.Error(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, a)
+}
+
+// RegisterHandler signs up a new account, with a profile under its
+// username, and logs the session in to it.
+// With attach, the account takes over the game the anonymous session was
+// playing, and what the AI has learned about it.
+func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var payload credentials
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if currentSession(r).Account != nil {
+		http.Error(w, "Log out first", http.StatusBadRequest)
+		return
+	}
+	if !allowAttempt(w, limit{addressAttempts, clientAddress(r)}) {
+		return
+	}
+
+	playerID := ""
+	if payload.Attach {
+		playerID = getSessionID(r)
+	}
+	a, err := account.New(payload.Username, payload.Password, playerID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := gameStore.CreateAccount(a); err != nil {
+		if errors.Is(err, account.ErrTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("[ERROR] Failed to create account %s: %v", a.Username, err)
+		http.Error(w, "Failed to create account", http.StatusInternalServerError)
+		return
+	}
+	if err := gameStore.SaveProfile(newProfile(a)); err != nil {
+		log.Printf("[ERROR] Failed to create profile of %s: %v", a.Username, err)
+	}
+	if err := logIn(w, r, a); err != nil {
+		http.Error(w, "Failed to log in", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, a)
+}
+
+// LoginHandler logs the session in to an account, leaving any anonymous
+// game behind.
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var payload credentials
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	username := strings.ToLower(strings.TrimSpace(payload.Username))
+	if !allowAttempt(w, limit{addressAttempts, clientAddress(r)}, limit{usernameAttempts, username}) {
+		return
+	}
+	a, err := gameStore.AccountByName(strings.TrimSpace(payload.Username))
+	if err != nil {
+		log.Printf("[ERROR] Failed to load account %s: %v", payload.Username, err)
+		http.Error(w, "Failed to load account", http.StatusInternalServerError)
+		return
+	}
+	if !account.Verify(a, payload.Password) {
+		http.Error(w, "Wrong username or password", http.StatusUnauthorized)
+		return
+	}
+	usernameAttempts.Reset(username)
+	if err := logIn(w, r, a); err != nil {
+		http.Error(w, "Failed to log in", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, a)
+}
+
+// LogoutHandler ends the session and starts a new anonymous one.
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if err := gameStore.DeleteSession(currentSession(r).ID); err != nil {
+		log.Printf("[ERROR] Failed to end session: %v", err)
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+	if _, err := startSession(w, r, nil); err != nil {
+		log.Printf("[ERROR] Failed to start session: %v", err)
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// RevokeSessionsHandler logs the account out everywhere but this session,
+// as after a lost device or a shared computer.
+func RevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	s := currentSession(r)
+	if s.Account == nil {
+		http.Error(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+	if err := gameStore.DeleteSessions(s.Account.ID, s.ID); err != nil {
+		log.Printf("[ERROR] Failed to revoke sessions of %s: %v", s.Account.Username, err)
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,7 +16,19 @@ import (
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin: sameOrigin,
+}
+
+// sameOrigin lets only pages served from this host open the socket, since
+// it takes game actions on the strength of the session cookie. Clients that
+// send no Origin are not browsers, so carry no one else's cookie.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
 }
 
 // ChatMessage represents a message in the chat
@@ -30,13 +44,29 @@ type ClientConnection struct {
 	Conn      *websocket.Conn
 	SessionID string
 	mu        sync.Mutex
+	watching  string // Game the client follows, its session's or a table's; guarded by clientsMu
+	game      string // Game, hand number and last event pushed, see push
+	hand, seq int
 }
 
+// The connected clients by session, one per socket: the same account may
+// be open in several tabs or devices.
 var (
-	clients   = make(map[string]*ClientConnection)
+	clients   = make(map[string]map[*ClientConnection]bool)
 	clientsMu sync.RWMutex
 )
 
+// clientsOf returns the session's connected clients.
+func clientsOf(sessionID string) []*ClientConnection {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+	var of []*ClientConnection
+	for c := range clients[sessionID] {
+		of = append(of, c)
+	}
+	return of
+}
+
 // Ancient One's pre-defined messages by situation
 var ancientQuips = map[string][]string{
 	"deal": {
@@ -115,24 +145,17 @@ func GetAncientQuip(situation string) string {
 	return quips[rand.Intn(len(quips))]
 }
 
-// SendToClient sends a chat message to a specific client
+// SendToClient sends a chat message to every client of a session
 func SendToClient(sessionID string, msg ChatMessage) {
-	clientsMu.RLock()
-	client, ok := clients[sessionID]
-	clientsMu.RUnlock()
-
-	if !ok || client == nil {
-		return
-	}
-
 	msg.Timestamp = time.Now().Unix()
-
-	client.mu.Lock()
-	defer client.mu.Unlock()
-
 	data, _ := json.Marshal(msg)
-	if err := client.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		log.Printf("[CHAT] Error sending to %s: %v", sessionID, err)
+
+	for _, client := range clientsOf(sessionID) {
+		client.mu.Lock()
+		if err := client.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			log.Printf("[CHAT] Error sending to %s: %v", sessionID, err)
+		}
+		client.mu.Unlock()
 	}
 }
 
@@ -154,19 +177,26 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionID := getSessionID(w, r)
+	sessionID := getSessionID(r)
 	log.Printf("[CHAT] Client connected: %s", sessionID)
 
 	client := &ClientConnection{
 		Conn:      conn,
 		SessionID: sessionID,
+		watching:  sessionID,
 	}
 
 	clientsMu.Lock()
-	clients[sessionID] = client
+	if clients[sessionID] == nil {
+		clients[sessionID] = make(map[*ClientConnection]bool)
+	}
+	clients[sessionID][client] = true
 	clientsMu.Unlock()
 
-	// Send greeting
+	// Send the game so far, then a greeting
+	if g, err := gameStore.Load(sessionID); err == nil && g != nil {
+		client.push(newSights(sessionID, g, nil))
+	}
 	go func() {
 		time.Sleep(500 * time.Millisecond)
 		SendAncientMessage(sessionID, "greeting")
@@ -175,7 +205,10 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 	// Read loop (for future player messages)
 	defer func() {
 		clientsMu.Lock()
-		delete(clients, sessionID)
+		delete(clients[sessionID], client)
+		if len(clients[sessionID]) == 0 {
+			delete(clients, sessionID)
+		}
 		clientsMu.Unlock()
 		conn.Close()
 		log.Printf("[CHAT] Client disconnected: %s", sessionID)
@@ -186,9 +219,9 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			break
 		}
-		// For now, just log player messages
-		log.Printf("[CHAT] From %s: %s", sessionID, string(message))
-		// Future: Forward to LLM, parse commands, etc.
+		// Game messages act on the game; chat is just logged for now
+		handleSocketMessage(client, message)
+		// Future: Forward chat to LLM, parse commands, etc.
 	}
 }
 
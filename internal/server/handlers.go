@@ -7,8 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-
-	"github.com/google/uuid"
+	"strconv"
 )
 
 var gameStore store.GameStore
@@ -17,25 +16,9 @@ var gameStore store.GameStore
 func Init(s store.GameStore) {
 	gameStore = s
 }
-func getSessionID(w http.ResponseWriter, r *http.Request) string {
-	if cookie, err := r.Cookie("session_id"); err == nil {
-		log.Printf("[DEBUG] Cookie found: %s", cookie.Value)
-		return cookie.Value
-	}
-	sessionID := uuid.NewString()
-	log.Printf("[DEBUG] No cookie found. Generated new Session ID: %s", sessionID)
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-	})
-	return sessionID
-}
 
-func getGame(w http.ResponseWriter, r *http.Request) (*game.GameState, string) {
-	sid := getSessionID(w, r)
+func getGame(r *http.Request) (*game.GameState, string) {
+	sid := getSessionID(r)
 	g, err := gameStore.Load(sid)
 	if err != nil {
 		log.Printf("[DEBUG] Load failed for session %s: %v", sid, err)
@@ -45,28 +28,103 @@ func getGame(w http.ResponseWriter, r *http.Request) (*game.GameState, string) {
 	return g, sid
 }
 
+// newGame starts a heads-up game for a session. The session is the human's
+// player ID, so what the AI learns about them carries over between games.
+func newGame(sid string) *game.GameState {
+	g := game.NewGame(sid)
+	g.ID = sid
+	stats, err := gameStore.PlayerStats(sid)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load stats for %s: %v", sid, err)
+	}
+	if stats != nil {
+		g.Stats = map[string]*game.PlayerStats{sid: stats}
+	}
+	return g
+}
+
 func saveGame(id string, g *game.GameState) error {
 	if err := gameStore.Save(id, g); err != nil {
 		log.Printf("[ERROR] Failed to save session %s: %v", id, err)
 		return err
 	}
 	log.Printf("[DEBUG] Saved game for session %s", id)
+	pushState(id, g)
 	return nil
 }
 
 func DealHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	log.Printf("[DEBUG] DealHandler: Session %s", sid)
 
 	if g == nil {
-		g = game.NewGame("")
-		g.ID = sid
+		g = newGame(sid)
 		log.Printf("[DEBUG] Created new game object for session %s", sid)
 	} else {
 		g.NewRound()
 	}
 
+	// The game and the opponent may be chosen along with the deal
+	if v := r.URL.Query().Get("variant"); v != "" {
+		if err := g.SetVariant(game.Variant(v)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	rules := g.Rules
+	if r.URL.Query().Has("wilds") {
+		wilds, err := game.ParseRules(r.URL.Query().Get("wilds"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules.Jokers, rules.DeucesWild = wilds.Jokers, wilds.DeucesWild
+	}
+	if r.URL.Query().Has("ranking") {
+		ranking, err := game.ParseRanking(r.URL.Query().Get("ranking"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules.Ranking = ranking
+	}
+	if r.URL.Query().Has("betting") {
+		betting, err := game.ParseBetting(r.URL.Query().Get("betting"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules.Betting = betting
+	}
+	for name, field := range map[string]*int{
+		"draws":     &rules.Draws,
+		"small_bet": &rules.SmallBet,
+		"big_bet":   &rules.BigBet,
+		"raise_cap": &rules.RaiseCap,
+	} {
+		if !r.URL.Query().Has(name) {
+			continue
+		}
+		n, err := strconv.Atoi(r.URL.Query().Get(name))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid %s", name), http.StatusBadRequest)
+			return
+		}
+		*field = n
+	}
+	if err := g.SetRules(rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p := r.URL.Query().Get("personality"); p != "" {
+		if err := g.SetPersonality(g.SeatOf(game.AncientOneID), game.Personality(p)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	g.CollectAnte(10)
+	g.OpponentTurn() // The button may leave an AI first to act
 	if err := saveGame(sid, g); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to save game state: %v", err), http.StatusInternalServerError)
 		return
@@ -75,21 +133,21 @@ func DealHandler(w http.ResponseWriter, r *http.Request) {
 	// Ancient One comments on the deal
 	go SendAncientMessage(sid, "deal")
 
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
 }
 
 func StateHandler(w http.ResponseWriter, r *http.Request) {
-	g, _ := getGame(w, r)
+	g, _ := getGame(r)
 	if g == nil {
 		// No game exists yet, return empty/null
 		writeJSON(w, nil)
 		return
 	}
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
 }
 
 func ActionHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
@@ -104,51 +162,20 @@ func ActionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Auto-fill defaults if legacy
-	if payload.Action == "" {
-		// Basic inference or default to 'call'/'check' logic?
-		// Prefer explicit actions now.
-		http.Error(w, "Action required", http.StatusBadRequest)
-		return
-	}
-
-	success, msg := g.PlayerAction(payload.Action, payload.Amount)
-	if !success {
-		http.Error(w, msg, http.StatusBadRequest)
-		return
-	}
-
-	g.OpponentTurn()
-	if err := saveGame(sid, g); err != nil {
-		// Log but maybe don't fail the request completely since action succeeded?
-		// Actually, if we don't save, state is lost. Better to warn.
-		http.Error(w, "State save failed", http.StatusInternalServerError)
+	if err := takeAction(sid, sid, g, payload.Action, payload.Amount); err != nil {
+		writeError(w, err)
 		return
 	}
-
-	// Ancient One reacts to player action
-	switch payload.Action {
-	case "fold":
-		go SendAncientMessage(sid, "player_fold")
-	case "bet", "call", "raise", "check":
-		go SendAncientMessage(sid, "player_bet")
-	}
-
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
 }
 
 func DiscardHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
 	}
 
-	if !g.CanDiscard() {
-		http.Error(w, "Cannot discard now", http.StatusBadRequest)
-		return
-	}
-
 	var payload struct {
 		Indices []int `json:"indices"`
 	}
@@ -157,16 +184,15 @@ func DiscardHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	g.PerformDiscard(payload.Indices)
-	if err := saveGame(sid, g); err != nil {
-		http.Error(w, "State save failed", http.StatusInternalServerError)
+	if err := takeDiscard(sid, sid, g, payload.Indices); err != nil {
+		writeError(w, err)
 		return
 	}
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
 }
 
 func ShowdownHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
@@ -180,22 +206,24 @@ func ShowdownHandler(w http.ResponseWriter, r *http.Request) {
 	g.CompleteShowdown()
 	saveGame(sid, g)
 
+	seat := sessionSeat(g)
+	ancient := g.SeatOf(game.AncientOneID)
+
 	// Ancient One reacts to outcome
-	if g.Winner == g.Players[0].Name {
+	if g.Winner == g.Players[seat].Name {
 		go SendAncientMessage(sid, "player_wins")
-	} else if g.Winner == g.Players[1].Name {
+	} else if ancient >= 0 && g.Winner == g.Players[ancient].Name {
 		go SendAncientMessage(sid, "ancient_wins")
 	}
 
-	// Frontend expects { result: ..., state: ... }
-	playerHand := g.RoundStates[0].Hand
-	opponentHand := g.RoundStates[1].Hand
-	result := game.CompareHandsForDisplay(playerHand, opponentHand)
-
-	writeJSON(w, map[string]interface{}{
-		"result": result,
-		"state":  g,
-	})
+	// Frontend expects { result: ..., state: ... }. The result sets the
+	// player's hand against whichever shown down hand beat it or it beat,
+	// so it is left out after a fold, which keeps the mucked cards hidden
+	resp := map[string]interface{}{"state": viewFor(g)}
+	if result, ok := g.ShowdownResult(seat); ok {
+		resp["result"] = result
+	}
+	writeJSON(w, resp)
 }
 
 func ClearSessionHandler(w http.ResponseWriter, r *http.Request) {
@@ -205,29 +233,96 @@ func ClearSessionHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func RebuyHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		// No game exists, create one
-		g = game.NewGame("")
-		g.ID = sid
+		g = newGame(sid)
 		g.CollectAnte(10) // Auto-start
+		g.OpponentTurn()
 	} else {
 		// Reset logic: New Game completely (preserve player ID)
 		playerID := ""
-		if len(g.Players) > 0 {
-			playerID = g.Players[0].ID
+		if seat := sessionSeat(g); seat >= 0 {
+			playerID = g.Players[seat].ID
 		}
 		newGame := game.NewGame(playerID)
 		newGame.ID = sid
+		// Keep numbering and seed so archived hands stay replayable
+		newGame.HandNumber = g.HandNumber
+		newGame.SetSeed(g.Seed)
+		newGame.Stats = g.Stats
+		newGame.Variant = g.Variant
+		newGame.Rules = g.Rules
 		g = newGame
 		g.CollectAnte(10)
+		g.OpponentTurn()
 	}
 	saveGame(sid, g)
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
+}
+
+// HistoryHandler returns the archived hands of this session's game, newest
+// first. ?limit= caps how many (default 20, max 100).
+func HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	_, sid := getGame(r)
+
+	limit := 20
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = min(v, 100)
+	}
+
+	hands, err := gameStore.History(sid, limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load history for session %s: %v", sid, err)
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+	if hands == nil {
+		hands = []*game.HandRecord{}
+	}
+	writeJSON(w, hands)
+}
+
+// PersonalitiesHandler lists the AI personalities a player can face.
+func PersonalitiesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, game.Personalities)
+}
+
+// PersonalityHandler changes the strategy of an AI seat. Seat defaults to
+// the Ancient One's.
+func PersonalityHandler(w http.ResponseWriter, r *http.Request) {
+	g, sid := getGame(r)
+	if g == nil {
+		http.Error(w, "Game not found. Deal first.", http.StatusNotFound)
+		return
+	}
+
+	var payload struct {
+		Seat        *int             `json:"seat"`
+		Personality game.Personality `json:"personality"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	seat := g.SeatOf(game.AncientOneID)
+	if payload.Seat != nil {
+		seat = *payload.Seat
+	}
+
+	if err := g.SetPersonality(seat, payload.Personality); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := saveGame(sid, g); err != nil {
+		http.Error(w, "State save failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, viewFor(g))
 }
 
 func ESPStartHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		http.Error(w, "Game not found. Deal first.", http.StatusNotFound)
 		return
@@ -239,11 +334,11 @@ func ESPStartHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	saveGame(sid, g)
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
 }
 
 func ESPGuessHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
@@ -263,12 +358,12 @@ func ESPGuessHandler(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, map[string]interface{}{
 		"correct": correct,
-		"state":   g,
+		"state":   viewFor(g),
 	})
 }
 
 func ESPExitHandler(w http.ResponseWriter, r *http.Request) {
-	g, sid := getGame(w, r)
+	g, sid := getGame(r)
 	if g == nil {
 		http.Error(w, "Game not found", http.StatusNotFound)
 		return
@@ -276,10 +371,40 @@ func ESPExitHandler(w http.ResponseWriter, r *http.Request) {
 
 	g.ExitESP()
 	saveGame(sid, g)
-	writeJSON(w, g)
+	writeJSON(w, viewFor(g))
+}
+
+// sessionSeat is the seat played by this session. Session games seat a
+// single human, so that is the table's human seat.
+func sessionSeat(g *game.GameState) int {
+	return g.HumanSeat()
+}
+
+// viewerOf is the player ID of this session's seat, or "" if it has none.
+func viewerOf(g *game.GameState) string {
+	seat := sessionSeat(g)
+	if seat < 0 {
+		return ""
+	}
+	return g.Players[seat].ID
+}
+
+// viewFor projects g for the human player of this session so hidden
+// information never leaves the server.
+func viewFor(g *game.GameState) *game.View {
+	return g.View(viewerOf(g))
 }
 
 func writeJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(v)
 }
+
+// writeError answers a refused action with 400 and anything else with 500.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if isRefusal(err) {
+		status = http.StatusBadRequest
+	}
+	http.Error(w, err.Error(), status)
+}
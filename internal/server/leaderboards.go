@@ -0,0 +1,44 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"card-shoggoths/internal/leaderboard"
+
+	chi "github.com/go-chi/chi/v5"
+)
+
+// LeaderboardHandler ranks the players with profiles on a board.
+// ?window= is day, week or all (the default), and ?limit= caps how many
+// players are listed (default 10, max 100).
+func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	board, err := leaderboard.ParseBoard(chi.URLParam(r, "board"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	window, err := leaderboard.ParseWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := leaderboard.DefaultLimit
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = min(v, leaderboard.MaxLimit)
+	}
+
+	lb := &leaderboard.Leaderboard{Board: board, Window: window, Since: window.Since(time.Now())}
+	if board == leaderboard.BoardWinRate {
+		lb.MinHands = leaderboard.MinHands
+	}
+	lb.Entries, err = gameStore.Leaderboard(board, lb.Since, limit)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load the %s leaderboard: %v", board, err)
+		http.Error(w, "Failed to load leaderboard", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, lb)
+}
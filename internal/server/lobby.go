@@ -0,0 +1,436 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/lobby"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// playerNamespace derives the IDs sessions play under at tables.
+var playerNamespace = uuid.MustParse("5e47ab1e-c0de-4bad-8000-00000000f00d")
+
+// playerID is the player ID a session sits and watches tables under.
+// Everyone at a table sees it, so unlike the session ID it must not let
+// them take over the session.
+func playerID(sid string) string {
+	return uuid.NewSHA1(playerNamespace, []byte(sid)).String()
+}
+
+// Each table is changed by one request at a time, since many sessions share
+// it; its game is stored under the table's ID.
+var (
+	tableLocks   = make(map[string]*sync.Mutex)
+	tableLocksMu sync.Mutex
+)
+
+// lockTable locks the table until the returned func is called.
+func lockTable(id string) func() {
+	tableLocksMu.Lock()
+	mu, ok := tableLocks[id]
+	if !ok {
+		mu = &sync.Mutex{}
+		tableLocks[id] = mu
+	}
+	tableLocksMu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+// loadTable finds the table, answering 404 if there is none.
+func loadTable(w http.ResponseWriter, id string) (*lobby.Table, bool) {
+	t, err := gameStore.LoadTable(id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load table %s: %v", id, err)
+		http.Error(w, "Failed to load table", http.StatusInternalServerError)
+		return nil, false
+	}
+	if t == nil {
+		http.Error(w, "Table not found", http.StatusNotFound)
+		return nil, false
+	}
+	return t, true
+}
+
+// saveTable stores the table, or drops it with its game once everyone has
+// left, and tells the lobby.
+func saveTable(t *lobby.Table) error {
+	var err error
+	if t.Empty() {
+		stopTurn(t.ID)
+		err = gameStore.DeleteTable(t.ID)
+	} else {
+		err = gameStore.SaveTable(t)
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to save table %s: %v", t.ID, err)
+		return err
+	}
+	broadcastLobby()
+	return nil
+}
+
+// tableState is a table and its game as one player may see it; State is
+// nil before the first deal.
+type tableState struct {
+	Table    *lobby.Table `json:"table"`
+	State    *game.View   `json:"state"`
+	PlayerID string       `json:"player_id"` // The player's ID at tables, to find them among the seats
+}
+
+// writeTable answers with the table and its game as the session sees it.
+func writeTable(w http.ResponseWriter, t *lobby.Table, sid string) {
+	g, err := gameStore.Load(t.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load game of table %s: %v", t.ID, err)
+	}
+	ts := tableState{Table: t, PlayerID: playerID(sid)}
+	if g != nil {
+		ts.State, _ = newSights(t.ID, g, t).of(sid)
+	}
+	writeJSON(w, ts)
+}
+
+// TablesHandler lists the lobby's tables, oldest first.
+func TablesHandler(w http.ResponseWriter, r *http.Request) {
+	tables, err := gameStore.Tables()
+	if err != nil {
+		log.Printf("[ERROR] Failed to list tables: %v", err)
+		http.Error(w, "Failed to list tables", http.StatusInternalServerError)
+		return
+	}
+	if tables == nil {
+		tables = []*lobby.Table{}
+	}
+	writeJSON(w, tables)
+}
+
+// CreateTableHandler opens a table in the lobby. Seats is the number of
+// human seats (default 2); given a player name, the creator takes the
+// first of them. Delay is how many actions spectators are shown the hands
+// behind, face up; without one they watch live with the hole cards hidden.
+func CreateTableHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+
+	var payload struct {
+		Name    string       `json:"name"`
+		Variant game.Variant `json:"variant"`
+		Rules   game.Rules   `json:"rules"`
+		Stake   int          `json:"stake"`
+		Seats   int          `json:"seats"`
+		AISeats int          `json:"ai_seats"`
+		Player  string       `json:"player"`
+		Delay   int          `json:"delay"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if payload.Seats == 0 {
+		payload.Seats = 2
+	}
+
+	t, err := lobby.New(payload.Name, payload.Variant, payload.Rules, payload.Stake, payload.Seats, payload.AISeats)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := t.SetDelay(payload.Delay); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Player != "" {
+		if _, err := t.Join(playerID(sid), payload.Player, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := saveTable(t); err != nil {
+		http.Error(w, "Failed to save table", http.StatusInternalServerError)
+		return
+	}
+	if payload.Player != "" {
+		watch(sid, t.ID)
+	}
+	log.Printf("[DEBUG] Session %s created table %s", sid, t.ID)
+	writeTable(w, t, sid)
+}
+
+// TableHandler returns a table and its game as the session sees it.
+func TableHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	t, ok := loadTable(w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	writeTable(w, t, sid)
+}
+
+// JoinTableHandler sits the session down at the table, at the seat asked
+// for or the first open one, under the name given or its profile's.
+func JoinTableHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	id := chi.URLParam(r, "id")
+
+	var payload struct {
+		Name string `json:"name"`
+		Seat *int   `json:"seat"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	seat := -1
+	if payload.Seat != nil {
+		seat = *payload.Seat
+	}
+
+	defer lockTable(id)()
+	t, ok := loadTable(w, id)
+	if !ok {
+		return
+	}
+	if _, err := t.Join(playerID(sid), seatName(r, payload.Name), seat); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := saveTable(t); err != nil {
+		http.Error(w, "Failed to save table", http.StatusInternalServerError)
+		return
+	}
+	watch(sid, t.ID)
+	writeTable(w, t, sid)
+}
+
+// SpectateTableHandler lets the session watch the table without a seat.
+func SpectateTableHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	id := chi.URLParam(r, "id")
+
+	var payload struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	defer lockTable(id)()
+	t, ok := loadTable(w, id)
+	if !ok {
+		return
+	}
+	if err := t.Spectate(playerID(sid), seatName(r, payload.Name)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := saveTable(t); err != nil {
+		http.Error(w, "Failed to save table", http.StatusInternalServerError)
+		return
+	}
+	watch(sid, t.ID)
+	writeTable(w, t, sid)
+}
+
+// LeaveTableHandler gives up the session's seat, or stops it watching. A
+// player who leaves mid-hand folds; their seat is gone from the next deal.
+func LeaveTableHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	id := chi.URLParam(r, "id")
+	pid := playerID(sid)
+
+	defer lockTable(id)()
+	t, ok := loadTable(w, id)
+	if !ok {
+		return
+	}
+	if !t.Leave(pid) {
+		http.Error(w, "You are not at this table", http.StatusBadRequest)
+		return
+	}
+
+	g, err := gameStore.Load(t.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load game of table %s: %v", t.ID, err)
+	}
+	if g != nil {
+		if seat := g.SeatOf(pid); seat >= 0 && g.LegalActions(seat).Fold {
+			g.SeatAction(seat, "fold", 0)
+			g.OpponentTurn()
+			if err := saveGame(t.ID, g); err == nil {
+				timeTurn(t.ID, g)
+			}
+		}
+	}
+
+	if err := saveTable(t); err != nil {
+		http.Error(w, "Failed to save table", http.StatusInternalServerError)
+		return
+	}
+	watch(sid, "")
+	writeTable(w, t, sid)
+}
+
+// TableDealHandler deals the table's next hand to whoever is seated now.
+// Any seated player may deal once the last hand is over.
+func TableDealHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	id := chi.URLParam(r, "id")
+
+	defer lockTable(id)()
+	t, ok := loadTable(w, id)
+	if !ok {
+		return
+	}
+	if t.SeatOf(playerID(sid)) < 0 {
+		http.Error(w, "Take a seat to deal", http.StatusBadRequest)
+		return
+	}
+
+	g, err := gameStore.Load(t.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load game of table %s: %v", t.ID, err)
+		http.Error(w, "Failed to load game", http.StatusInternalServerError)
+		return
+	}
+	if g == nil {
+		g, err = game.NewTable(t.Players(nil))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Waiting for players: %v", err), http.StatusBadRequest)
+			return
+		}
+		g.ID = t.ID
+	} else {
+		if !g.CanDeal() {
+			http.Error(w, "The hand is still being played", http.StatusBadRequest)
+			return
+		}
+		g.NewRound()
+		if err := g.Reseat(t.Players(g)); err != nil {
+			http.Error(w, fmt.Sprintf("Waiting for players: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if t.Variant != "" {
+		if err := g.SetVariant(t.Variant); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := g.SetRules(t.Rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	loadStats(g)
+
+	g.CollectAnte(t.Stake)
+	g.OpponentTurn()
+	if err := saveGame(t.ID, g); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save game state: %v", err), http.StatusInternalServerError)
+		return
+	}
+	timeTurn(t.ID, g)
+	// Seating the hand noted the sanity of anyone who left
+	if err := saveTable(t); err != nil {
+		http.Error(w, "Failed to save table", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, g.View(playerID(sid)))
+}
+
+// TableActionHandler takes a betting action for the session's seat at the
+// table, as ActionHandler does in its own game.
+func TableActionHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	id := chi.URLParam(r, "id")
+
+	var payload struct {
+		Action string `json:"action"`
+		Amount int    `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	defer lockTable(id)()
+	g, ok := loadTableGame(w, id)
+	if !ok {
+		return
+	}
+	if err := takeAction(sid, id, g, payload.Action, payload.Amount); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, g.View(playerID(sid)))
+}
+
+// TableDiscardHandler draws for the session's seat at the table.
+func TableDiscardHandler(w http.ResponseWriter, r *http.Request) {
+	sid := getSessionID(r)
+	id := chi.URLParam(r, "id")
+
+	var payload struct {
+		Indices []int `json:"indices"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	defer lockTable(id)()
+	g, ok := loadTableGame(w, id)
+	if !ok {
+		return
+	}
+	if err := takeDiscard(sid, id, g, payload.Indices); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, g.View(playerID(sid)))
+}
+
+// loadTableGame loads the game of a table, answering 404 before the first
+// deal.
+func loadTableGame(w http.ResponseWriter, id string) (*game.GameState, bool) {
+	g, err := gameStore.Load(id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load game of table %s: %v", id, err)
+		http.Error(w, "Failed to load game", http.StatusInternalServerError)
+		return nil, false
+	}
+	if g == nil {
+		http.Error(w, "Game not found. Deal first.", http.StatusNotFound)
+		return nil, false
+	}
+	return g, true
+}
+
+// loadStats brings what the AI has learned about each human at the table
+// from their other games, for those it has not met here yet.
+func loadStats(g *game.GameState) {
+	for _, p := range g.Players {
+		if p.IsAI || g.Stats[p.ID] != nil {
+			continue
+		}
+		stats, err := gameStore.PlayerStats(p.ID)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load stats for %s: %v", p.ID, err)
+		}
+		if stats == nil {
+			continue
+		}
+		if g.Stats == nil {
+			g.Stats = make(map[string]*game.PlayerStats)
+		}
+		g.Stats[p.ID] = stats
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"card-shoggoths/internal/account"
+	"card-shoggoths/internal/profile"
+
+	chi "github.com/go-chi/chi/v5"
+)
+
+// ProfileHandler returns an account's profile with its lifetime stats,
+// over its own games and the tables it sat at.
+func ProfileHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := gameStore.Profile(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("[ERROR] Failed to load profile: %v", err)
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+	if p == nil {
+		http.Error(w, "Profile not found", http.StatusNotFound)
+		return
+	}
+	p.Stats, err = gameStore.Lifetime(p.PlayerID, p.SeatID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load stats of profile %s: %v", p.ID, err)
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, p)
+}
+
+// UpdateProfileHandler changes the name or avatar of the session's account.
+func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	a := currentSession(r).Account
+	if a == nil {
+		http.Error(w, "Not logged in", http.StatusUnauthorized)
+		return
+	}
+	var payload struct {
+		Name   *string `json:"name"`
+		Avatar *string `json:"avatar"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	p, err := ownProfile(a)
+	if err != nil {
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+	if payload.Name != nil {
+		if err := p.SetName(*payload.Name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if payload.Avatar != nil {
+		if err := p.SetAvatar(*payload.Avatar); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := gameStore.SaveProfile(p); err != nil {
+		log.Printf("[ERROR] Failed to save profile %s: %v", p.ID, err)
+		http.Error(w, "Failed to save profile", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, p)
+}
+
+// AvatarsHandler lists the icons a profile may pick.
+func AvatarsHandler(w http.ResponseWriter, r *http.Request) {
+	avatars := profile.Avatars()
+	if avatars == nil {
+		avatars = []string{}
+	}
+	writeJSON(w, avatars)
+}
+
+// seatName is the name to sit or watch at a table under: the one given, or
+// else the profile's if the session is logged in.
+func seatName(r *http.Request, name string) string {
+	a := currentSession(r).Account
+	if name != "" || a == nil {
+		return name
+	}
+	if p, err := ownProfile(a); err == nil {
+		return p.Name
+	}
+	return name
+}
+
+// ownProfile returns the account's profile, or a new one if it has none
+// yet.
+func ownProfile(a *account.Account) (*profile.Profile, error) {
+	p, err := gameStore.Profile(a.ID)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load profile %s: %v", a.ID, err)
+		return nil, err
+	}
+	if p == nil {
+		p = newProfile(a)
+	}
+	return p, nil
+}
+
+// newProfile makes the profile of a new account, which sits at tables under
+// the player ID of its session.
+func newProfile(a *account.Account) *profile.Profile {
+	return profile.New(a.ID, a.PlayerID, playerID(a.PlayerID), a.Username, a.Created)
+}
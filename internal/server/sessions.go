@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"card-shoggoths/internal/account"
+	"card-shoggoths/internal/token"
+
+	"github.com/google/uuid"
+)
+
+// SessionConfig is how long sessions and the secrets signing them last.
+type SessionConfig struct {
+	TTL         time.Duration // How long a session lasts unused; using it renews it
+	RotateEvery time.Duration // How often a new secret takes over signing
+}
+
+// DefaultSessionConfig keeps a session for a month of absence and signs
+// with a new secret every day.
+var DefaultSessionConfig = SessionConfig{TTL: 30 * 24 * time.Hour, RotateEvery: 24 * time.Hour}
+
+var (
+	sessionConfig = DefaultSessionConfig
+	keyring       = token.NewKeyring(nil)
+)
+
+// InitSessions loads the secrets session tokens are signed with and keeps
+// rotating them, making the first if there are none.
+func InitSessions(cfg SessionConfig) error {
+	secrets, err := gameStore.Secrets()
+	if err != nil {
+		return err
+	}
+	sessionConfig = cfg
+	keyring = token.NewKeyring(secrets)
+	if err := rotateSecret(); err != nil {
+		return err
+	}
+	go func() {
+		for range time.Tick(min(cfg.RotateEvery, time.Hour)) {
+			rotateSecret()
+		}
+	}()
+	return nil
+}
+
+// rotateSecret signs with a new secret once the current one is due, and
+// drops those whose tokens have all expired.
+func rotateSecret() error {
+	now := time.Now()
+	if current, ok := keyring.Current(); ok && now.Sub(current.Created) < sessionConfig.RotateEvery {
+		return nil
+	}
+	s := token.NewSecret(now)
+	if err := gameStore.SaveSecret(s); err != nil {
+		log.Printf("[ERROR] Failed to save session secret: %v", err)
+		return err
+	}
+	for _, old := range keyring.Rotate(s, sessionConfig.TTL) {
+		if err := gameStore.DeleteSecret(old.ID); err != nil {
+			log.Printf("[ERROR] Failed to drop session secret %s: %v", old.ID, err)
+		}
+	}
+	log.Printf("[DEBUG] Session tokens are signed with secret %s", s.ID)
+	return nil
+}
+
+// session is the request's session, as the Sessions middleware found or
+// started it.
+type session struct {
+	ID      string
+	Account *account.Account // Nil if anonymous
+}
+
+type sessionKey struct{}
+
+// Sessions checks the signed session token of every request, starting a new
+// anonymous session if it has none that is valid, expired or revoked, and
+// renewing it as it is used. Handlers find the session with getSessionID.
+func Sessions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := checkSession(w, r)
+		if err == nil && s == nil {
+			s, err = startSession(w, r, nil)
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to load session: %v", err)
+			http.Error(w, "Failed to load session", http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+	})
+}
+
+// checkSession returns the session the request's token is for, or nil if
+// it has no token that is valid and not revoked.
+func checkSession(w http.ResponseWriter, r *http.Request) (*session, error) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		return nil, nil
+	}
+	now := time.Now()
+	t, err := keyring.Check(cookie.Value, now)
+	if err != nil {
+		log.Printf("[DEBUG] Refused session token: %v", err)
+		return nil, nil
+	}
+	a, ok, err := gameStore.SessionAccount(t.Session)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if keyring.Stale(t, sessionConfig.TTL, now) {
+		if err := setSessionCookie(w, r, t.Session); err != nil {
+			log.Printf("[ERROR] Failed to renew session: %v", err)
+		}
+	}
+	return &session{ID: t.Session, Account: a}, nil
+}
+
+// startSession starts a session, logged in to the account or anonymous if
+// it is nil, and gives the client its token.
+func startSession(w http.ResponseWriter, r *http.Request, a *account.Account) (*session, error) {
+	s := &session{ID: uuid.NewString(), Account: a}
+	accountID := ""
+	if a != nil {
+		accountID = a.ID
+	}
+	if err := gameStore.SaveSession(s.ID, accountID); err != nil {
+		return nil, err
+	}
+	if err := setSessionCookie(w, r, s.ID); err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] Started session %s", s.ID)
+	return s, nil
+}
+
+// setSessionCookie gives the client a token for the session, valid for the
+// configured TTL from now. Served over TLS, directly or through a proxy,
+// the cookie is only ever sent back over TLS.
+func setSessionCookie(w http.ResponseWriter, r *http.Request, id string) error {
+	expires := time.Now().Add(sessionConfig.TTL)
+	value, err := keyring.Sign(id, expires)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// currentSession returns the request's session, set by Sessions.
+func currentSession(r *http.Request) *session {
+	s, _ := r.Context().Value(sessionKey{}).(*session)
+	return s
+}
+
+// getSessionID returns the ID the request's session plays under: its
+// account's player ID once logged in, otherwise the session's own ID.
+func getSessionID(r *http.Request) string {
+	s := currentSession(r)
+	if s.Account != nil {
+		return s.Account.PlayerID
+	}
+	return s.ID
+}
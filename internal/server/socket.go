@@ -0,0 +1,289 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/lobby"
+
+	"github.com/gorilla/websocket"
+)
+
+// Socket message types. Chat keeps the types of ChatMessage; these carry
+// the game, from the server unless marked as from the client.
+const (
+	MsgStateUpdate = "state_update" // State is the game as the client may see it, after a transition
+	MsgEvents      = "events"       // Events of hand Hand happened since the last push
+	MsgError       = "error"        // The client's last message was refused, for Error
+	MsgAction      = "action"       // Client: take Action for Amount, as POST /api/bet
+	MsgDiscard     = "discard"      // Client: discard Indices, as POST /api/discard
+	MsgWatch       = "watch"        // Client: follow the game of Table, or its own game if empty
+	MsgLobby       = "lobby"        // Tables are the lobby's, none if omitted, after a table was created or a seat changed
+)
+
+// SocketMessage is a game message on the WebSocket, in either direction.
+type SocketMessage struct {
+	Type      string         `json:"type"`
+	State     *game.View     `json:"state,omitempty"`
+	Hand      int            `json:"hand,omitempty"`
+	Events    []game.Event   `json:"events,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Action    string         `json:"action,omitempty"`
+	Amount    int            `json:"amount,omitempty"`
+	Indices   []int          `json:"indices,omitempty"`
+	Table     string         `json:"table,omitempty"` // Client: the table an action or watch is for; its own game if empty
+	Tables    []*lobby.Table `json:"tables,omitempty"`
+	Timestamp int64          `json:"timestamp"`
+}
+
+// refusal is an action the game would not allow, as opposed to a failure
+// of the server; REST answers it with 400.
+type refusal string
+
+func (r refusal) Error() string { return string(r) }
+
+// isRefusal reports whether err is a refusal.
+func isRefusal(err error) bool {
+	var r refusal
+	return errors.As(err, &r)
+}
+
+// send writes msg to the client; the caller holds c.mu.
+func (c *ClientConnection) send(msg SocketMessage) {
+	msg.Timestamp = time.Now().Unix()
+	data, _ := json.Marshal(msg)
+	if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Printf("[CHAT] Error sending to %s: %v", c.SessionID, err)
+	}
+}
+
+// pushState sends every client following the game stored under id the
+// events since its last push and then the game as its session may see it.
+// saveGame calls it, so every transition that is kept is pushed.
+func pushState(id string, g *game.GameState) {
+	clientsMu.RLock()
+	var following []*ClientConnection
+	for _, session := range clients {
+		for c := range session {
+			if c.watching == id {
+				following = append(following, c)
+			}
+		}
+	}
+	clientsMu.RUnlock()
+
+	s := newSights(id, g, nil)
+	for _, c := range following {
+		c.push(s)
+	}
+}
+
+// push sends the client what it has not seen of the game, as its session
+// may see it.
+func (c *ClientConnection) push(s *sights) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	view, events := s.of(c.SessionID)
+
+	// A new hand, or another game, starts a new log
+	seq := c.seq
+	if c.game != s.id || c.hand != s.g.HandNumber {
+		seq = 0
+	}
+	for len(events) > 0 && events[0].Seq <= seq {
+		events = events[1:]
+	}
+	if len(events) > 0 {
+		c.send(SocketMessage{Type: MsgEvents, Hand: s.g.HandNumber, Events: events})
+		seq = events[len(events)-1].Seq
+	}
+	c.game, c.hand, c.seq = s.id, s.g.HandNumber, seq
+	c.send(SocketMessage{Type: MsgStateUpdate, State: view})
+}
+
+// watch makes the session's clients follow the game of the table id, or
+// its own game if id is empty, and pushes it.
+func watch(sid, id string) {
+	show(sid, id, clientsOf(sid)...)
+}
+
+// show makes the clients of the session follow the game of the table id,
+// or the session's own game if id is empty, and pushes it.
+func show(sid, id string, following ...*ClientConnection) {
+	if id == "" {
+		id = sid
+	}
+	if len(following) == 0 {
+		return
+	}
+	follow(id, following...)
+	if g, err := gameStore.Load(id); err == nil && g != nil {
+		s := newSights(id, g, nil)
+		for _, c := range following {
+			c.push(s)
+		}
+	}
+}
+
+// follow makes the clients follow the game stored under id from their next
+// push.
+func follow(id string, following ...*ClientConnection) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	for _, c := range following {
+		c.watching = id
+	}
+}
+
+// broadcastLobby sends every client the lobby's tables, after one was
+// created or someone sat down, stood up or started watching.
+func broadcastLobby() {
+	tables, err := gameStore.Tables()
+	if err != nil {
+		log.Printf("[ERROR] Failed to list tables: %v", err)
+		return
+	}
+	if tables == nil {
+		tables = []*lobby.Table{}
+	}
+
+	clientsMu.RLock()
+	var all []*ClientConnection
+	for _, session := range clients {
+		for c := range session {
+			all = append(all, c)
+		}
+	}
+	clientsMu.RUnlock()
+
+	for _, c := range all {
+		c.mu.Lock()
+		c.send(SocketMessage{Type: MsgLobby, Tables: tables})
+		c.mu.Unlock()
+	}
+}
+
+// sendError tells the client why its message was refused.
+func (c *ClientConnection) sendError(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.send(SocketMessage{Type: MsgError, Error: text})
+}
+
+// handleSocketMessage acts on a message from a client of a session.
+// Anything that is not a game message is chat, which is only logged for
+// now.
+func handleSocketMessage(client *ClientConnection, data []byte) {
+	sid := client.SessionID
+	var msg SocketMessage
+	if err := json.Unmarshal(data, &msg); err != nil || (msg.Type != MsgAction && msg.Type != MsgDiscard && msg.Type != MsgWatch) {
+		log.Printf("[CHAT] From %s: %s", sid, string(data))
+		return
+	}
+
+	// The session's own game, or a table's, which is shared and so locked
+	id := sid
+	if msg.Table != "" {
+		id = msg.Table
+		defer lockTable(id)()
+		t, err := gameStore.LoadTable(id)
+		if err != nil || t == nil {
+			client.sendError("Table not found")
+			return
+		}
+		if !t.Watching(playerID(sid)) {
+			client.sendError("You are not at this table")
+			return
+		}
+	}
+	if msg.Type == MsgWatch {
+		show(sid, msg.Table, client)
+		return
+	}
+	follow(id, client) // Playing a game means following it
+
+	g, err := gameStore.Load(id)
+	if err != nil || g == nil {
+		client.sendError("Game not found")
+		return
+	}
+	if msg.Type == MsgAction {
+		err = takeAction(sid, id, g, msg.Action, msg.Amount)
+	} else {
+		err = takeDiscard(sid, id, g, msg.Indices)
+	}
+	if err != nil {
+		client.sendError(err.Error())
+	}
+}
+
+// seatFor is the seat the session plays in the game stored under id: the
+// human seat of its own game, or its player's seat at a table.
+func seatFor(sid, id string, g *game.GameState) int {
+	if id == sid {
+		return sessionSeat(g)
+	}
+	return g.SeatOf(playerID(sid))
+}
+
+// takeAction applies a betting action for the session's seat in the game
+// stored under id, lets the AI answer and saves the game, for the REST
+// handlers and the socket alike.
+func takeAction(sid, id string, g *game.GameState, action string, amount int) error {
+	if action == "" {
+		return refusal("Action required")
+	}
+	seat := seatFor(sid, id, g)
+	if seat < 0 {
+		return refusal("You are not seated in this game")
+	}
+	if ok, msg := g.SeatAction(seat, action, amount); !ok {
+		return refusal(msg)
+	}
+
+	g.OpponentTurn()
+	if err := saveGame(id, g); err != nil {
+		return errors.New("State save failed")
+	}
+	if id != sid {
+		timeTurn(id, g)
+		return nil
+	}
+
+	// Ancient One reacts to player action
+	switch action {
+	case "fold":
+		go SendAncientMessage(sid, "player_fold")
+	case "bet", "call", "raise", "check":
+		go SendAncientMessage(sid, "player_bet")
+	}
+	return nil
+}
+
+// takeDiscard draws for the session's seat in the game stored under id,
+// lets the AI carry on and saves the game, for the REST handlers and the
+// socket alike.
+func takeDiscard(sid, id string, g *game.GameState, indices []int) error {
+	if !g.CanDiscard() {
+		return refusal("Cannot discard now")
+	}
+	seat := seatFor(sid, id, g)
+	if seat < 0 {
+		return refusal("You are not seated in this game")
+	}
+	if ok, msg := g.SeatDiscard(seat, indices); !ok {
+		return refusal(msg)
+	}
+	g.OpponentTurn()
+	if err := saveGame(id, g); err != nil {
+		return errors.New("State save failed")
+	}
+	if id != sid {
+		timeTurn(id, g)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"card-shoggoths/internal/store"
+	"card-shoggoths/internal/token"
+
+	chi "github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer serves the lobby and the socket from a fresh store.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	st, err := store.NewSQLiteStore(filepath.Join(t.TempDir(), "game.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	Init(st)
+	keyring = token.NewKeyring(nil)
+	if err := rotateSecret(); err != nil {
+		t.Fatalf("rotateSecret: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(Sessions)
+		r.Post("/api/tables", CreateTableHandler)
+		r.Post("/api/tables/{id}/join", JoinTableHandler)
+		r.Post("/api/tables/{id}/deal", TableDealHandler)
+		r.HandleFunc("/ws/chat", ChatHandler)
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testClient is a browser of one player: a cookie jar for its session.
+type testClient struct {
+	t   *testing.T
+	srv *httptest.Server
+	jar http.CookieJar
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *testClient {
+	jar, _ := cookiejar.New(nil)
+	return &testClient{t: t, srv: srv, jar: jar}
+}
+
+// post sends body to the API as the client and decodes the answer into out.
+func (c *testClient) post(path string, body any, out any) {
+	c.t.Helper()
+	data, _ := json.Marshal(body)
+	client := &http.Client{Jar: c.jar}
+	res, err := client.Post(c.srv.URL+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		c.t.Fatalf("POST %s: %v", path, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		c.t.Fatalf("POST %s: %s", path, res.Status)
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			c.t.Fatalf("POST %s: %v", path, err)
+		}
+	}
+}
+
+// dial opens the socket as the client, from a page of origin if not empty.
+func (c *testClient) dial(origin string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	dialer := websocket.Dialer{Jar: c.jar, HandshakeTimeout: time.Second}
+	return dialer.Dial("ws"+strings.TrimPrefix(c.srv.URL, "http")+"/ws/chat", header)
+}
+
+func (c *testClient) connect() *websocket.Conn {
+	c.t.Helper()
+	conn, _, err := c.dial(c.srv.URL)
+	if err != nil {
+		c.t.Fatalf("dial: %v", err)
+	}
+	c.t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// await reads the socket until a message satisfies ok, failing the test if
+// none does within a second.
+func await(t *testing.T, conn *websocket.Conn, ok func(SocketMessage) bool) SocketMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var msg SocketMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("no message came: %v", err)
+		}
+		if ok(msg) {
+			return msg
+		}
+	}
+}
+
+// newTableHand seats two players at a fresh table and deals, returning
+// them with the table's ID.
+func newTableHand(t *testing.T, srv *httptest.Server) (alice, bob *testClient, id string) {
+	alice, bob = newTestClient(t, srv), newTestClient(t, srv)
+	var ts tableState
+	alice.post("/api/tables", map[string]any{"name": "Test", "seats": 2, "player": "Alice"}, &ts)
+	bob.post("/api/tables/"+ts.Table.ID+"/join", map[string]any{"name": "Bob"}, nil)
+	alice.post("/api/tables/"+ts.Table.ID+"/deal", nil, nil)
+	return alice, bob, ts.Table.ID
+}
+
+func TestSocketTakesASeatedAction(t *testing.T) {
+	srv := newTestServer(t)
+	alice, bob, id := newTableHand(t, srv)
+	conns := map[*testClient]*websocket.Conn{alice: alice.connect(), bob: bob.connect()}
+
+	var turn *testClient
+	for c, conn := range conns {
+		conn.WriteJSON(SocketMessage{Type: MsgWatch, Table: id})
+		msg := await(t, conn, func(m SocketMessage) bool { return m.Type == MsgStateUpdate && m.State.ID == id })
+		if msg.State.ViewerSeat == msg.State.TurnIndex {
+			turn = c
+		}
+	}
+	if turn == nil {
+		t.Fatal("expected one of the players to be on turn")
+	}
+
+	conns[turn].WriteJSON(SocketMessage{Type: MsgAction, Action: "check", Table: id})
+	for _, conn := range conns {
+		msg := await(t, conn, func(m SocketMessage) bool { return m.Type == MsgStateUpdate })
+		if !strings.HasSuffix(msg.State.LastAction, "checks.") {
+			t.Errorf("expected the check pushed to everyone at the table, got %q", msg.State.LastAction)
+		}
+	}
+
+	// Acting out of turn, or at a table one is not at, is refused
+	conns[turn].WriteJSON(SocketMessage{Type: MsgAction, Action: "check", Table: id})
+	if msg := await(t, conns[turn], func(m SocketMessage) bool { return m.Type == MsgError }); msg.Error == "" {
+		t.Errorf("expected acting out of turn refused")
+	}
+	stranger := newTestClient(t, srv)
+	conn := stranger.connect()
+	conn.WriteJSON(SocketMessage{Type: MsgAction, Action: "check", Table: id})
+	if msg := await(t, conn, func(m SocketMessage) bool { return m.Type == MsgError }); msg.Error != "You are not at this table" {
+		t.Errorf("expected a stranger refused, got %q", msg.Error)
+	}
+}
+
+func TestSocketKeepsEveryTabOfASession(t *testing.T) {
+	srv := newTestServer(t)
+	alice, bob, id := newTableHand(t, srv)
+	first, second := alice.connect(), alice.connect()
+	for _, conn := range []*websocket.Conn{first, second} {
+		conn.WriteJSON(SocketMessage{Type: MsgWatch, Table: id})
+		await(t, conn, func(m SocketMessage) bool { return m.Type == MsgStateUpdate })
+	}
+
+	// The first tab closing leaves the second following the table
+	first.Close()
+	time.Sleep(50 * time.Millisecond)
+	bobConn := bob.connect()
+	bobConn.WriteJSON(SocketMessage{Type: MsgAction, Action: "fold", Table: id})
+	msg := await(t, second, func(m SocketMessage) bool { return m.Type == MsgStateUpdate })
+	if !strings.Contains(msg.State.LastAction, "folds") && !strings.Contains(msg.State.LastAction, "wins") {
+		t.Errorf("expected the fold pushed to the open tab, got %q", msg.State.LastAction)
+	}
+}
+
+func TestSocketRefusesOtherOrigins(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv)
+	c.post("/api/tables", map[string]any{"name": "Test"}, nil) // Starts a session
+
+	if _, res, err := c.dial("https://evil.example"); err == nil || res == nil || res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected a page of another origin refused, got %v", err)
+	}
+	conn, _, err := c.dial(srv.URL)
+	if err != nil {
+		t.Fatalf("expected a page of this origin let in: %v", err)
+	}
+	conn.Close()
+	if conn, _, err := c.dial(""); err != nil {
+		t.Errorf("expected a client without an origin let in: %v", err)
+	} else {
+		conn.Close()
+	}
+}
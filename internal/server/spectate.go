@@ -0,0 +1,70 @@
+package server
+
+import (
+	"log"
+
+	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/lobby"
+)
+
+// sights projects one game, stored under id, for each session that follows
+// it. Players see their own seat; spectators see the table live with the
+// hole cards hidden, or, at a table with a broadcast delay, every hand face
+// up that many actions behind. The table and the broadcast are worked out
+// at most once, however many sessions are watching.
+type sights struct {
+	id    string
+	g     *game.GameState
+	table *lobby.Table // Loaded on first need, unless given
+	ready bool         // Whether table is loaded
+
+	broadcast *game.Broadcast
+	tried     bool // Whether broadcast was projected, or failed to be
+}
+
+// newSights projects g for its followers; t is its table if the caller has
+// it already.
+func newSights(id string, g *game.GameState, t *lobby.Table) *sights {
+	return &sights{id: id, g: g, table: t, ready: t != nil}
+}
+
+// of is the game as the session may see it, and the events of the hand up
+// to that point.
+func (s *sights) of(sid string) (*game.View, []game.Event) {
+	// The session's own game is played by its human seat; at a table it
+	// sits, or watches, under its player ID
+	viewer := playerID(sid)
+	if s.id == sid {
+		viewer = viewerOf(s.g)
+	}
+	if s.g.SeatOf(viewer) < 0 {
+		if b := s.delayed(viewer); b != nil {
+			return b.View, b.Events
+		}
+	}
+	return s.g.View(viewer), s.g.EventsFor(viewer, 0)
+}
+
+// delayed is the broadcast a spectator is shown, or nil if the table has no
+// delay or the viewer has a seat at it, even one not dealt in yet.
+func (s *sights) delayed(viewer string) *game.Broadcast {
+	if !s.ready {
+		t, err := gameStore.LoadTable(s.id)
+		if err != nil {
+			log.Printf("[ERROR] Failed to load table %s: %v", s.id, err)
+		}
+		s.table, s.ready = t, true
+	}
+	if s.table == nil || s.table.Delay == 0 || s.table.SeatOf(viewer) >= 0 {
+		return nil
+	}
+	if !s.tried {
+		b, err := s.g.Broadcast(s.table.Delay)
+		if err != nil {
+			// Spectators fall back to the live table without hole cards
+			log.Printf("[ERROR] Failed to broadcast table %s: %v", s.id, err)
+		}
+		s.broadcast, s.tried = b, true
+	}
+	return s.broadcast
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"card-shoggoths/internal/game"
+)
+
+// TurnTimeout is how long a human seated at a lobby table has to act before
+// the turn is taken for them, so one player walking away cannot stall the
+// others: they check if they may and fold otherwise, and stand pat in a
+// draw.
+var TurnTimeout = time.Minute
+
+// The pending turn timeout of each table, by table ID.
+var (
+	turnTimers   = make(map[string]*time.Timer)
+	turnTimersMu sync.Mutex
+)
+
+// timeTurn starts the clock on whoever is to act in the game of the table
+// id, replacing the last turn's. A saved table game is handed to it after
+// every transition; once no human is to act the clock stops.
+func timeTurn(id string, g *game.GameState) {
+	stopTurn(id)
+	seat := g.TurnIndex
+	if seat < 0 || seat >= len(g.Players) || g.Players[seat].IsAI {
+		return
+	}
+	legal := g.LegalActions(seat)
+	if !legal.Check && legal.Call == 0 && !legal.Discard {
+		return
+	}
+	hand, events := g.HandNumber, len(g.Events)
+	turnTimersMu.Lock()
+	defer turnTimersMu.Unlock()
+	turnTimers[id] = time.AfterFunc(TurnTimeout, func() {
+		timeOut(id, seat, hand, events)
+	})
+}
+
+// stopTurn stops the clock of the table id.
+func stopTurn(id string) {
+	turnTimersMu.Lock()
+	defer turnTimersMu.Unlock()
+	if t := turnTimers[id]; t != nil {
+		t.Stop()
+		delete(turnTimers, id)
+	}
+}
+
+// ResumeTurns starts the clock at every lobby table, as the server starts,
+// so a turn left waiting when it stopped is still taken in time.
+func ResumeTurns() {
+	tables, err := gameStore.Tables()
+	if err != nil {
+		log.Printf("[ERROR] Failed to list tables: %v", err)
+		return
+	}
+	for _, t := range tables {
+		if g, err := gameStore.Load(t.ID); err == nil && g != nil {
+			timeTurn(t.ID, g)
+		}
+	}
+}
+
+// timeOut takes the turn of seat in the game of the table id, unless the
+// game has moved on since the clock was started: its hand and its number of
+// events tell.
+func timeOut(id string, seat, hand, events int) {
+	defer lockTable(id)()
+	g, err := gameStore.Load(id)
+	if err != nil {
+		log.Printf("[ERROR] Failed to load game of table %s: %v", id, err)
+		return
+	}
+	if g == nil || g.HandNumber != hand || len(g.Events) != events || g.TurnIndex != seat {
+		return
+	}
+
+	legal := g.LegalActions(seat)
+	switch {
+	case legal.Discard:
+		g.SeatDiscard(seat, nil)
+	case legal.Check:
+		g.SeatAction(seat, "check", 0)
+	default:
+		g.SeatAction(seat, "fold", 0)
+	}
+	g.LastAction = g.Players[seat].Name + " ran out of time. " + g.LastAction
+	log.Printf("[DEBUG] Seat %d of table %s ran out of time", seat, id)
+
+	g.OpponentTurn()
+	if err := saveGame(id, g); err == nil {
+		timeTurn(id, g)
+	}
+}
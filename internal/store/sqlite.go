@@ -1,10 +1,16 @@
 package store
 
 import (
+	"card-shoggoths/internal/account"
 	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/leaderboard"
+	"card-shoggoths/internal/lobby"
+	"card-shoggoths/internal/profile"
+	"card-shoggoths/internal/token"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -27,6 +33,72 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 		state TEXT,
 		updated_at DATETIME
 	);
+	CREATE TABLE IF NOT EXISTS hands (
+		game_id TEXT,
+		number INTEGER,
+		events TEXT,
+		updated_at DATETIME,
+		PRIMARY KEY (game_id, number)
+	);
+	CREATE TABLE IF NOT EXISTS player_stats (
+		player_id TEXT PRIMARY KEY,
+		stats TEXT,
+		updated_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS tables (
+		id TEXT PRIMARY KEY,
+		info TEXT,
+		created_at DATETIME,
+		updated_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS accounts (
+		id TEXT PRIMARY KEY,
+		username TEXT UNIQUE COLLATE NOCASE,
+		player_id TEXT UNIQUE,
+		salt BLOB,
+		hash BLOB,
+		iterations INTEGER,
+		created_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		account_id TEXT,
+		created_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS secrets (
+		id TEXT PRIMARY KEY,
+		key BLOB,
+		created_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS results (
+		game_id TEXT,
+		number INTEGER,
+		player_id TEXT,
+		played INTEGER,
+		won INTEGER,
+		net INTEGER,
+		pot INTEGER,
+		best INTEGER,
+		best_cards TEXT,
+		mad INTEGER,
+		broke INTEGER,
+		ancient INTEGER,
+		esp_rounds INTEGER,
+		esp_solved INTEGER,
+		esp_fastest INTEGER,
+		esp_guesses INTEGER,
+		finished_at DATETIME,
+		PRIMARY KEY (game_id, number, player_id)
+	);
+	CREATE INDEX IF NOT EXISTS results_player ON results (player_id, finished_at);
+	CREATE TABLE IF NOT EXISTS profiles (
+		id TEXT PRIMARY KEY,
+		player_id TEXT,
+		seat_id TEXT,
+		name TEXT,
+		avatar TEXT,
+		created_at DATETIME
+	);
 	`
 	if _, err := db.Exec(query); err != nil {
 		return nil, fmt.Errorf("failed to init db: %w", err)
@@ -35,18 +107,86 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 	return &SQLiteStore{db: db}, nil
 }
 
+// Save stores the game and archives the history of its current hand in the
+// same transaction, so the two never disagree. The stats of the humans at the
+// table are saved with it, since they are tallied as hands finish, and so are
+// their results once the hand is over. Results are worked out again from
+// the whole hand every time, so saving twice never counts a hand twice.
 func (s *SQLiteStore) Save(id string, state *game.GameState) error {
 	data, err := json.Marshal(state)
 	if err != nil {
 		return err
 	}
+	events, err := json.Marshal(state.Events)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	now := time.Now()
 	query := `
 	INSERT INTO games (id, state, updated_at) VALUES (?, ?, ?)
 	ON CONFLICT(id) DO UPDATE SET state=excluded.state, updated_at=excluded.updated_at;
 	`
-	_, err = s.db.Exec(query, id, string(data), time.Now())
-	return err
+	if _, err := tx.Exec(query, id, string(data), now); err != nil {
+		return err
+	}
+
+	if len(state.Events) > 0 {
+		query = `
+		INSERT INTO hands (game_id, number, events, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(game_id, number) DO UPDATE SET events=excluded.events, updated_at=excluded.updated_at;
+		`
+		if _, err := tx.Exec(query, id, state.HandNumber, string(events), now); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range state.Players {
+		stats := state.Stats[p.ID]
+		if p.IsAI || stats == nil {
+			continue
+		}
+		data, err := json.Marshal(stats)
+		if err != nil {
+			return err
+		}
+		query = `
+		INSERT INTO player_stats (player_id, stats, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(player_id) DO UPDATE SET stats=excluded.stats, updated_at=excluded.updated_at;
+		`
+		if _, err := tx.Exec(query, p.ID, string(data), now); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range state.Results() {
+		cards, err := json.Marshal(r.BestCards)
+		if err != nil {
+			return err
+		}
+		query = `
+		INSERT INTO results (game_id, number, player_id, played, won, net, pot, best, best_cards, mad, broke, ancient,
+			esp_rounds, esp_solved, esp_fastest, esp_guesses, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(game_id, number, player_id) DO UPDATE SET
+			played=excluded.played, won=excluded.won, net=excluded.net, pot=excluded.pot,
+			best=excluded.best, best_cards=excluded.best_cards, mad=excluded.mad, broke=excluded.broke, ancient=excluded.ancient,
+			esp_rounds=excluded.esp_rounds, esp_solved=excluded.esp_solved, esp_fastest=excluded.esp_fastest,
+			esp_guesses=excluded.esp_guesses, finished_at=excluded.finished_at;
+		`
+		_, err = tx.Exec(query, id, state.HandNumber, r.PlayerID, r.Played, r.Won(), r.Net, r.Pot, int(r.Best), string(cards),
+			r.Mad, r.Broke, r.Ancient, r.ESPRounds, r.ESPSolved, r.ESPFastest, r.ESPGuesses, time.UnixMilli(r.Finished).UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (s *SQLiteStore) Load(id string) (*game.GameState, error) {
@@ -65,3 +205,344 @@ func (s *SQLiteStore) Load(id string) (*game.GameState, error) {
 	}
 	return &state, nil
 }
+
+func (s *SQLiteStore) History(id string, limit int) ([]*game.HandRecord, error) {
+	rows, err := s.db.Query("SELECT number, events FROM hands WHERE game_id = ? ORDER BY number DESC LIMIT ?", id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hands []*game.HandRecord
+	for rows.Next() {
+		var data string
+		hand := &game.HandRecord{GameID: id}
+		if err := rows.Scan(&hand.Number, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &hand.Events); err != nil {
+			return nil, err
+		}
+		hands = append(hands, hand)
+	}
+	return hands, rows.Err()
+}
+
+func (s *SQLiteStore) PlayerStats(playerID string) (*game.PlayerStats, error) {
+	var data string
+	err := s.db.QueryRow("SELECT stats FROM player_stats WHERE player_id = ?", playerID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil // New player
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stats game.PlayerStats
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (s *SQLiteStore) SaveTable(t *lobby.Table) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	query := `
+	INSERT INTO tables (id, info, created_at, updated_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET info=excluded.info, updated_at=excluded.updated_at;
+	`
+	_, err = s.db.Exec(query, t.ID, string(data), t.Created, time.Now())
+	return err
+}
+
+func (s *SQLiteStore) LoadTable(id string) (*lobby.Table, error) {
+	var data string
+	err := s.db.QueryRow("SELECT info FROM tables WHERE id = ?", id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var t lobby.Table
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *SQLiteStore) DeleteTable(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM tables WHERE id = ?",
+		"DELETE FROM games WHERE id = ?",
+		"DELETE FROM hands WHERE game_id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Tables() ([]*lobby.Table, error) {
+	rows, err := s.db.Query("SELECT info FROM tables ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []*lobby.Table
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var t lobby.Table
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return nil, err
+		}
+		tables = append(tables, &t)
+	}
+	return tables, rows.Err()
+}
+
+func (s *SQLiteStore) CreateAccount(a *account.Account) error {
+	query := `
+	INSERT INTO accounts (id, username, player_id, salt, hash, iterations, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?);
+	`
+	_, err := s.db.Exec(query, a.ID, a.Username, a.PlayerID, a.Salt, a.Hash, a.Iterations, a.Created)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: accounts.username") {
+		return account.ErrTaken
+	}
+	return err
+}
+
+// accountColumns are what scanAccount reads, in order.
+const accountColumns = "id, username, player_id, salt, hash, iterations, created_at"
+
+// scanAccount reads the account from row, or nil if there was none.
+func scanAccount(row *sql.Row) (*account.Account, error) {
+	var a account.Account
+	err := row.Scan(&a.ID, &a.Username, &a.PlayerID, &a.Salt, &a.Hash, &a.Iterations, &a.Created)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (s *SQLiteStore) AccountByName(username string) (*account.Account, error) {
+	return scanAccount(s.db.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE username = ?", username))
+}
+
+func (s *SQLiteStore) SaveSession(sessionID, accountID string) error {
+	_, err := s.db.Exec("INSERT INTO sessions (id, account_id, created_at) VALUES (?, ?, ?)", sessionID, accountID, time.Now())
+	return err
+}
+
+func (s *SQLiteStore) SessionAccount(sessionID string) (*account.Account, bool, error) {
+	var accountID string
+	err := s.db.QueryRow("SELECT account_id FROM sessions WHERE id = ?", sessionID).Scan(&accountID)
+	if err == sql.ErrNoRows {
+		return nil, false, nil // Revoked, or never was
+	}
+	if err != nil || accountID == "" {
+		return nil, err == nil, err
+	}
+	a, err := scanAccount(s.db.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE id = ?", accountID))
+	return a, a != nil, err
+}
+
+func (s *SQLiteStore) DeleteSession(sessionID string) error {
+	_, err := s.db.Exec("DELETE FROM sessions WHERE id = ?", sessionID)
+	return err
+}
+
+func (s *SQLiteStore) DeleteSessions(accountID, except string) error {
+	_, err := s.db.Exec("DELETE FROM sessions WHERE account_id = ? AND id != ?", accountID, except)
+	return err
+}
+
+func (s *SQLiteStore) Secrets() ([]token.Secret, error) {
+	rows, err := s.db.Query("SELECT id, key, created_at FROM secrets")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []token.Secret
+	for rows.Next() {
+		var secret token.Secret
+		if err := rows.Scan(&secret.ID, &secret.Key, &secret.Created); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, rows.Err()
+}
+
+func (s *SQLiteStore) SaveSecret(secret token.Secret) error {
+	_, err := s.db.Exec("INSERT INTO secrets (id, key, created_at) VALUES (?, ?, ?)", secret.ID, secret.Key, secret.Created)
+	return err
+}
+
+func (s *SQLiteStore) DeleteSecret(id string) error {
+	_, err := s.db.Exec("DELETE FROM secrets WHERE id = ?", id)
+	return err
+}
+
+func (s *SQLiteStore) SaveProfile(p *profile.Profile) error {
+	query := `
+	INSERT INTO profiles (id, player_id, seat_id, name, avatar, created_at) VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET name=excluded.name, avatar=excluded.avatar;
+	`
+	_, err := s.db.Exec(query, p.ID, p.PlayerID, p.SeatID, p.Name, p.Avatar, p.Created)
+	return err
+}
+
+func (s *SQLiteStore) Profile(id string) (*profile.Profile, error) {
+	var p profile.Profile
+	err := s.db.QueryRow("SELECT id, player_id, seat_id, name, avatar, created_at FROM profiles WHERE id = ?", id).
+		Scan(&p.ID, &p.PlayerID, &p.SeatID, &p.Name, &p.Avatar, &p.Created)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *SQLiteStore) Lifetime(playerIDs ...string) (*profile.Stats, error) {
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(playerIDs)), ", ")
+	args := make([]any, len(playerIDs))
+	for i, id := range playerIDs {
+		args[i] = id
+	}
+
+	var stats profile.Stats
+	query := `
+	SELECT COALESCE(SUM(played), 0), COALESCE(SUM(played AND won), 0), COALESCE(MAX(pot), 0),
+		COALESCE(SUM(esp_rounds), 0), COALESCE(SUM(esp_solved), 0), COALESCE(SUM(mad), 0)
+	FROM results WHERE player_id IN (` + in + `)`
+	err := s.db.QueryRow(query, args...).
+		Scan(&stats.Hands, &stats.Won, &stats.BiggestPot, &stats.ESPRounds, &stats.ESPSolved, &stats.Mad)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards string
+	query = "SELECT best, best_cards FROM results WHERE player_id IN (" + in + ") AND best > 0 ORDER BY best DESC LIMIT 1"
+	err = s.db.QueryRow(query, args...).Scan(&stats.Best, &cards)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal([]byte(cards), &stats.BestCards); err != nil {
+			return nil, err
+		}
+	}
+	stats.Finish()
+	return &stats, nil
+}
+
+// profileResults picks out the results of the hands played under each
+// profile's player IDs that finished since the first argument, for the
+// leaderboards to rank. The index on (player_id, finished_at) means only
+// the rows inside the window are read, however long the history. The IDs
+// are a UNION, so should a profile's two ever be the same its hands still
+// count once.
+const profileResults = `
+	WITH who AS (
+		SELECT id, name, avatar, player_id FROM profiles
+		UNION
+		SELECT id, name, avatar, seat_id FROM profiles
+	), played AS (
+		SELECT who.id, who.name, who.avatar, r.game_id, r.number, r.played, r.won, r.net, r.mad, r.broke, r.ancient,
+			r.esp_fastest, r.esp_guesses
+		FROM who JOIN results r ON r.player_id = who.player_id
+		WHERE r.finished_at >= ?
+	)`
+
+// Each board's query selects the profile ID, name, avatar, score, hands and
+// guesses of its entries.
+var boardQueries = map[leaderboard.Board]string{
+	leaderboard.BoardNet: `
+	SELECT id, name, avatar, SUM(net), SUM(played), 0 FROM played
+	GROUP BY id HAVING SUM(played) > 0
+	ORDER BY 4 DESC, 5, name LIMIT ?`,
+
+	leaderboard.BoardWinRate: `
+	SELECT id, name, avatar, CAST(SUM(played AND won) AS REAL) / SUM(played), SUM(played), 0 FROM played
+	GROUP BY id HAVING SUM(played) >= ?
+	ORDER BY 4 DESC, 5 DESC, name LIMIT ?`,
+
+	// A life is the run of a player's hands in one game up to and including
+	// the one that drove them mad or left them broke; the streak is the
+	// hands of it they survived
+	leaderboard.BoardStreak: `
+	, lives AS (
+		SELECT id, name, avatar, game_id, played, mad,
+			SUM(mad OR broke) OVER (PARTITION BY id, game_id ORDER BY number) - (mad OR broke) AS life
+		FROM played WHERE ancient AND (played OR mad OR broke)
+	), streaks AS (
+		SELECT id, name, avatar, SUM(played AND NOT mad) AS survived, SUM(played) AS hands
+		FROM lives GROUP BY id, game_id, life
+	)
+	SELECT id, name, avatar, MAX(survived), SUM(hands), 0 FROM streaks
+	GROUP BY id HAVING MAX(survived) > 0
+	ORDER BY 4 DESC, name LIMIT ?`,
+
+	leaderboard.BoardESP: `
+	, solves AS (
+		SELECT id, name, avatar, esp_fastest, esp_guesses,
+			ROW_NUMBER() OVER (PARTITION BY id ORDER BY esp_fastest, esp_guesses) AS n
+		FROM played WHERE esp_fastest > 0
+	)
+	SELECT id, name, avatar, esp_fastest, 0, esp_guesses FROM solves
+	WHERE n = 1
+	ORDER BY 4, 6, name LIMIT ?`,
+}
+
+func (s *SQLiteStore) Leaderboard(board leaderboard.Board, since time.Time, limit int) ([]leaderboard.Entry, error) {
+	query, ok := boardQueries[board]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard %q", board)
+	}
+	args := []any{since.UTC()}
+	if board == leaderboard.BoardWinRate {
+		args = append(args, leaderboard.MinHands)
+	}
+	rows, err := s.db.Query(profileResults+query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []leaderboard.Entry{}
+	for rows.Next() {
+		var e leaderboard.Entry
+		if err := rows.Scan(&e.ProfileID, &e.Name, &e.Avatar, &e.Score, &e.Hands, &e.Guesses); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	leaderboard.Rank(entries)
+	return entries, nil
+}
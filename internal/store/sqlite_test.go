@@ -0,0 +1,250 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"card-shoggoths/internal/account"
+	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/leaderboard"
+	"card-shoggoths/internal/lobby"
+	"card-shoggoths/internal/profile"
+	"card-shoggoths/internal/token"
+)
+
+// newTestStore opens a store in memory. Every connection to ":memory:" is
+// a database of its own, so the pool is held to the one the schema is in.
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	s, err := NewSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	s.db.SetMaxOpenConns(1)
+	t.Cleanup(func() { s.db.Close() })
+	return s
+}
+
+// foldedHand is a game of playerID's whose first hand they folded.
+func foldedHand(t *testing.T, playerID string) *game.GameState {
+	t.Helper()
+	g := game.NewGame(playerID)
+	g.SetSeed(1)
+	g.CollectAnte(10)
+	if ok, msg := g.PlayerAction("fold", 0); !ok {
+		t.Fatalf("fold: %s", msg)
+	}
+	return g
+}
+
+// result is a row of the results table, for the boards to rank.
+type result struct {
+	game             string
+	number           int
+	player           string
+	played, mad      bool
+	broke, ancient   bool
+	net              int
+	fastest, guesses int
+}
+
+func addResults(t *testing.T, s *SQLiteStore, results ...result) {
+	t.Helper()
+	for _, r := range results {
+		_, err := s.db.Exec(`
+		INSERT INTO results (game_id, number, player_id, played, won, net, pot, best, best_cards, mad, broke, ancient,
+			esp_rounds, esp_solved, esp_fastest, esp_guesses, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, 0, 0, 'null', ?, ?, ?, 0, 0, ?, ?, ?)`,
+			r.game, r.number, r.player, r.played, r.net > 0, r.net, r.mad, r.broke, r.ancient, r.fastest, r.guesses, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("insert result: %v", err)
+		}
+	}
+}
+
+func TestSaveCountsAHandOnce(t *testing.T) {
+	s := newTestStore(t)
+	g := foldedHand(t, "p")
+	for range 2 {
+		if err := s.Save("p", g); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	loaded, err := s.Load("p")
+	if err != nil || loaded == nil || loaded.HandNumber != g.HandNumber {
+		t.Fatalf("expected the game back, got %v: %v", loaded, err)
+	}
+	if hands, err := s.History("p", 10); err != nil || len(hands) != 1 {
+		t.Errorf("expected one archived hand, got %d: %v", len(hands), err)
+	}
+
+	stats, err := s.Lifetime("p")
+	if err != nil {
+		t.Fatalf("Lifetime: %v", err)
+	}
+	if stats.Hands != 1 || stats.Won != 0 {
+		t.Errorf("expected one hand, lost, however often it is saved, got %+v", stats)
+	}
+}
+
+func TestLifetimeSumsEveryID(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("p", foldedHand(t, "p"))
+	s.Save("q", foldedHand(t, "q"))
+
+	if stats, err := s.Lifetime("p", "q", "p"); err != nil || stats.Hands != 2 {
+		t.Errorf("expected both games' hands, once each, got %+v: %v", stats, err)
+	}
+	if stats, err := s.Lifetime("nobody"); err != nil || stats.Hands != 0 {
+		t.Errorf("expected nothing for a stranger, got %+v: %v", stats, err)
+	}
+}
+
+func TestLeaderboardsRankProfiles(t *testing.T) {
+	s := newTestStore(t)
+	now := time.Now()
+	s.SaveProfile(profile.New("A", "a", "a-seat", "Armitage", now))
+	s.SaveProfile(profile.New("B", "b", "b-seat", "Whateley", now))
+	// Should the two IDs ever coincide, the hands count once all the same
+	s.SaveProfile(profile.New("C", "c", "c", "Peaslee", now))
+
+	// Armitage survives 2 hands, goes mad, then survives 5; Whateley, 3 in
+	// one game and 4 at a table
+	var results []result
+	for n := 1; n <= 8; n++ {
+		results = append(results, result{game: "g1", number: n, player: "a", played: true, ancient: true, net: 5, mad: n == 3})
+	}
+	for n := 1; n <= 3; n++ {
+		results = append(results, result{game: "g2", number: n, player: "b", played: true, ancient: true, net: -5})
+	}
+	for n := 1; n <= 4; n++ {
+		results = append(results, result{game: "t1", number: n, player: "b-seat", played: true, ancient: true, net: -5})
+	}
+	results = append(results,
+		result{game: "g1", number: 9, player: "a", fastest: 7000, guesses: 3},
+		result{game: "g1", number: 10, player: "a", fastest: 5000, guesses: 9},
+		result{game: "g2", number: 4, player: "b", fastest: 5000, guesses: 2},
+		result{game: "g3", number: 1, player: "c", played: true, net: 10},
+	)
+	addResults(t, s, results...)
+
+	board := func(b leaderboard.Board) []leaderboard.Entry {
+		t.Helper()
+		entries, err := s.Leaderboard(b, time.Time{}, 10)
+		if err != nil {
+			t.Fatalf("%s: %v", b, err)
+		}
+		return entries
+	}
+
+	streak := board(leaderboard.BoardStreak)
+	if len(streak) != 2 || streak[0].ProfileID != "A" || streak[0].Score != 5 || streak[1].ProfileID != "B" || streak[1].Score != 4 {
+		t.Errorf("expected Armitage's 5 over Whateley's 4, got %+v", streak)
+	}
+
+	esp := board(leaderboard.BoardESP)
+	if len(esp) != 2 || esp[0].ProfileID != "B" || esp[0].Guesses != 2 || esp[1].ProfileID != "A" || esp[1].Score != 5000 || esp[1].Guesses != 9 {
+		t.Errorf("expected the tie at 5s broken by guesses, Whateley first, got %+v", esp)
+	}
+
+	for _, e := range board(leaderboard.BoardNet) {
+		switch e.ProfileID {
+		case "B":
+			if e.Score != -35 || e.Hands != 7 {
+				t.Errorf("expected Whateley's hands under both IDs, got %+v", e)
+			}
+		case "C":
+			if e.Score != 10 || e.Hands != 1 {
+				t.Errorf("expected Peaslee's one hand counted once, got %+v", e)
+			}
+		}
+	}
+
+	if _, err := s.Leaderboard("luck", time.Time{}, 10); err == nil {
+		t.Errorf("expected an unknown board to be refused")
+	}
+}
+
+func TestDeleteTableKeepsTheResults(t *testing.T) {
+	s := newTestStore(t)
+	tbl, err := lobby.New("", "", game.Rules{}, 0, 2, 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.SaveTable(tbl)
+	s.Save(tbl.ID, foldedHand(t, "p"))
+
+	if err := s.DeleteTable(tbl.ID); err != nil {
+		t.Fatalf("DeleteTable: %v", err)
+	}
+	if got, err := s.LoadTable(tbl.ID); err != nil || got != nil {
+		t.Errorf("expected the table gone, got %+v: %v", got, err)
+	}
+	if g, err := s.Load(tbl.ID); err != nil || g != nil {
+		t.Errorf("expected its game gone, got %v: %v", g, err)
+	}
+	if hands, err := s.History(tbl.ID, 10); err != nil || len(hands) != 0 {
+		t.Errorf("expected its hands gone, got %d: %v", len(hands), err)
+	}
+	if stats, err := s.Lifetime("p"); err != nil || stats.Hands != 1 {
+		t.Errorf("expected its results kept, got %+v: %v", stats, err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	s := newTestStore(t)
+	a, err := account.New("Armitage", "miskatonic", "")
+	if err != nil {
+		t.Fatalf("account.New: %v", err)
+	}
+	if err := s.CreateAccount(a); err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	b, _ := account.New("ARMITAGE", "miskatonic", "")
+	if err := s.CreateAccount(b); err != account.ErrTaken {
+		t.Errorf("expected the username taken whatever its case, got %v", err)
+	}
+	if got, err := s.AccountByName("armitage"); err != nil || got == nil || got.ID != a.ID {
+		t.Errorf("expected the account by name, got %+v: %v", got, err)
+	}
+
+	s.SaveSession("anon", "")
+	s.SaveSession("one", a.ID)
+	s.SaveSession("two", a.ID)
+
+	if got, ok, err := s.SessionAccount("anon"); err != nil || !ok || got != nil {
+		t.Errorf("expected an anonymous session, got %+v %v: %v", got, ok, err)
+	}
+	if got, ok, err := s.SessionAccount("one"); err != nil || !ok || got == nil || got.ID != a.ID {
+		t.Errorf("expected the session logged in, got %+v %v: %v", got, ok, err)
+	}
+	if _, ok, err := s.SessionAccount("forged"); err != nil || ok {
+		t.Errorf("expected no such session, got %v: %v", ok, err)
+	}
+
+	s.DeleteSessions(a.ID, "two")
+	if _, ok, _ := s.SessionAccount("one"); ok {
+		t.Errorf("expected the other sessions of the account ended")
+	}
+	if _, ok, _ := s.SessionAccount("two"); !ok {
+		t.Errorf("expected the session kept")
+	}
+	s.DeleteSession("two")
+	if _, ok, _ := s.SessionAccount("two"); ok {
+		t.Errorf("expected the session ended")
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	s := newTestStore(t)
+	for _, id := range []string{"old", "new"} {
+		if err := s.SaveSecret(token.Secret{ID: id, Key: []byte(id), Created: time.Now()}); err != nil {
+			t.Fatalf("SaveSecret: %v", err)
+		}
+	}
+	s.DeleteSecret("old")
+	secrets, err := s.Secrets()
+	if err != nil || len(secrets) != 1 || secrets[0].ID != "new" || string(secrets[0].Key) != "new" {
+		t.Errorf("expected only the new secret, got %+v: %v", secrets, err)
+	}
+}
@@ -1,8 +1,70 @@
 package store
 
-import "card-shoggoths/internal/game"
+import (
+	"card-shoggoths/internal/account"
+	"card-shoggoths/internal/game"
+	"card-shoggoths/internal/leaderboard"
+	"card-shoggoths/internal/lobby"
+	"card-shoggoths/internal/profile"
+	"card-shoggoths/internal/token"
+	"time"
+)
 
 type GameStore interface {
+	// Save stores the game, archiving its current hand and, once the hand
+	// is over, the results of its players.
 	Save(id string, state *game.GameState) error
 	Load(id string) (*game.GameState, error)
+	// History returns up to limit archived hands of a game, newest first.
+	History(id string, limit int) ([]*game.HandRecord, error)
+	// PlayerStats returns what the AI has learned about a player across all
+	// their games, or nil if they are new.
+	PlayerStats(playerID string) (*game.PlayerStats, error)
+
+	// SaveTable stores a lobby table; its game is saved under its ID.
+	SaveTable(t *lobby.Table) error
+	// LoadTable returns a lobby table, or nil if there is none.
+	LoadTable(id string) (*lobby.Table, error)
+	// Tables returns every lobby table, oldest first.
+	Tables() ([]*lobby.Table, error)
+	// DeleteTable removes a lobby table with its game and hands. The results
+	// of its hands are kept, as they count towards profiles and leaderboards.
+	DeleteTable(id string) error
+
+	// CreateAccount stores a new account, or returns account.ErrTaken if
+	// its username is in use, whatever its case.
+	CreateAccount(a *account.Account) error
+	// AccountByName returns the account with the username, whatever its
+	// case, or nil if there is none.
+	AccountByName(username string) (*account.Account, error)
+
+	// SaveSession starts a session, logged in to the account or anonymous
+	// if accountID is empty.
+	SaveSession(sessionID, accountID string) error
+	// SessionAccount returns the account the session is logged in to, or
+	// nil if it is anonymous, and false if there is no such session.
+	SessionAccount(sessionID string) (*account.Account, bool, error)
+	// DeleteSession ends, and so revokes, the session.
+	DeleteSession(sessionID string) error
+	// DeleteSessions ends every session of the account but except.
+	DeleteSessions(accountID, except string) error
+
+	// Secrets returns the secrets session tokens are signed with.
+	Secrets() ([]token.Secret, error)
+	// SaveSecret stores a new secret.
+	SaveSecret(s token.Secret) error
+	// DeleteSecret drops a secret, refusing the tokens it signed.
+	DeleteSecret(id string) error
+
+	// SaveProfile stores an account's profile.
+	SaveProfile(p *profile.Profile) error
+	// Profile returns the profile of an account, or nil if there is none.
+	Profile(id string) (*profile.Profile, error)
+	// Lifetime sums up the results of every hand played under any of the
+	// player IDs.
+	Lifetime(playerIDs ...string) (*profile.Stats, error)
+	// Leaderboard ranks the players with profiles on the board, over the
+	// hands that finished since the time given, best first and at most
+	// limit of them.
+	Leaderboard(board leaderboard.Board, since time.Time, limit int) ([]leaderboard.Entry, error)
 }
@@ -0,0 +1,155 @@
+// Package token signs the session cookie, so a session ID is only accepted
+// as the server issued it and until it expires.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalid is returned for a token the server did not sign, or signed
+// with a secret it has since dropped.
+var ErrInvalid = errors.New("invalid token")
+
+// ErrExpired is returned for a token past its expiry.
+var ErrExpired = errors.New("expired token")
+
+// Secret is a key tokens are signed with.
+type Secret struct {
+	ID      string
+	Key     []byte
+	Created time.Time
+}
+
+// NewSecret makes a random secret.
+func NewSecret(now time.Time) Secret {
+	id := make([]byte, 4)
+	rand.Read(id)
+	key := make([]byte, 32)
+	rand.Read(key)
+	return Secret{ID: hex.EncodeToString(id), Key: key, Created: now}
+}
+
+// Token is what a signed token says: which session it is for, until when,
+// and which secret signed it.
+type Token struct {
+	Session string
+	Expires time.Time
+	Secret  string
+}
+
+// Keyring is the secrets tokens are signed and checked with. The newest
+// signs; older ones still check the tokens they signed until those expire.
+type Keyring struct {
+	mu      sync.RWMutex
+	secrets []Secret // Newest first
+}
+
+// NewKeyring makes a keyring of the secrets, in any order.
+func NewKeyring(secrets []Secret) *Keyring {
+	k := &Keyring{secrets: slices.Clone(secrets)}
+	slices.SortFunc(k.secrets, func(a, b Secret) int { return b.Created.Compare(a.Created) })
+	return k
+}
+
+// Current returns the secret tokens are signed with, and false if there is
+// none yet.
+func (k *Keyring) Current() (Secret, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.secrets) == 0 {
+		return Secret{}, false
+	}
+	return k.secrets[0], true
+}
+
+// Rotate makes s the secret tokens are signed with, and drops and returns
+// those that stopped signing more than ttl ago, as every token they signed
+// has expired since.
+func (k *Keyring) Rotate(s Secret, ttl time.Duration) []Secret {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.secrets = append([]Secret{s}, k.secrets...)
+	for i := 1; i < len(k.secrets); i++ {
+		// A secret stopped signing when the one before it was made
+		if k.secrets[i-1].Created.Before(s.Created.Add(-ttl)) {
+			dropped := slices.Clone(k.secrets[i:])
+			k.secrets = k.secrets[:i]
+			return dropped
+		}
+	}
+	return nil
+}
+
+// Sign makes a token for the session, valid until expires, signed with the
+// current secret.
+func (k *Keyring) Sign(session string, expires time.Time) (string, error) {
+	s, ok := k.Current()
+	if !ok {
+		return "", errors.New("no secret to sign with")
+	}
+	payload := fmt.Sprintf("%s.%s.%d", s.ID, session, expires.Unix())
+	return payload + "." + sign(s.Key, payload), nil
+}
+
+// Check returns what the token says, if the keyring signed it and it has
+// not expired by now.
+func (k *Keyring) Check(value string, now time.Time) (Token, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 4 {
+		return Token{}, ErrInvalid
+	}
+	s, ok := k.secret(parts[0])
+	if !ok {
+		return Token{}, ErrInvalid
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(sign(s.Key, payload))) {
+		return Token{}, ErrInvalid
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Token{}, ErrInvalid
+	}
+
+	t := Token{Session: parts[1], Expires: time.Unix(expires, 0), Secret: s.ID}
+	if !now.Before(t.Expires) {
+		return t, ErrExpired
+	}
+	return t, nil
+}
+
+func (k *Keyring) secret(id string) (Secret, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, s := range k.secrets {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Secret{}, false
+}
+
+// Stale reports whether a token should be renewed: once less than half of
+// its ttl is left, so an active session slides on, or once the secret that
+// signed it has been rotated out of signing.
+func (k *Keyring) Stale(t Token, ttl time.Duration, now time.Time) bool {
+	s, ok := k.Current()
+	return t.Expires.Sub(now) < ttl/2 || (ok && t.Secret != s.ID)
+}
+
+func sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
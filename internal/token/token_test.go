@@ -0,0 +1,77 @@
+package token
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var day = 24 * time.Hour
+
+func TestSignAndCheck(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	k := NewKeyring([]Secret{NewSecret(now)})
+	value, err := k.Sign("s1", now.Add(day))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	tok, err := k.Check(value, now)
+	if err != nil || tok.Session != "s1" || !tok.Expires.Equal(now.Add(day)) {
+		t.Fatalf("expected the session back, got %+v: %v", tok, err)
+	}
+	if _, err := k.Check(value, now.Add(day)); !errors.Is(err, ErrExpired) {
+		t.Errorf("expected the token to expire, got %v", err)
+	}
+
+	forged := strings.Replace(value, "s1", "s2", 1)
+	if _, err := k.Check(forged, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected another session's ID to be refused, got %v", err)
+	}
+	for _, bad := range []string{"", "s1", "x.s1.1.sig"} {
+		if _, err := k.Check(bad, now); !errors.Is(err, ErrInvalid) {
+			t.Errorf("expected %q to be refused, got %v", bad, err)
+		}
+	}
+	if _, err := NewKeyring([]Secret{NewSecret(now)}).Check(value, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected another secret's token to be refused, got %v", err)
+	}
+}
+
+func TestRotateKeepsCheckingUntilTokensExpire(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	ttl := 30 * day
+	first := NewSecret(now)
+	k := NewKeyring([]Secret{first})
+	old, _ := k.Sign("s1", now.Add(ttl))
+
+	now = now.Add(day)
+	if dropped := k.Rotate(NewSecret(now), ttl); len(dropped) != 0 {
+		t.Errorf("expected the first secret kept, dropped %v", dropped)
+	}
+	tok, err := k.Check(old, now)
+	if err != nil {
+		t.Fatalf("expected the old token to still check: %v", err)
+	}
+	if !k.Stale(tok, ttl, now) {
+		t.Errorf("expected a token of a rotated secret to be renewed")
+	}
+	fresh, _ := k.Sign("s1", now.Add(ttl))
+	tok, _ = k.Check(fresh, now)
+	if k.Stale(tok, ttl, now) {
+		t.Errorf("expected a fresh token to be kept")
+	}
+	if !k.Stale(tok, ttl, now.Add(ttl/2+time.Second)) {
+		t.Errorf("expected a token past half its life to be renewed")
+	}
+
+	now = now.Add(ttl + day)
+	dropped := k.Rotate(NewSecret(now), ttl)
+	if len(dropped) != 1 || dropped[0].ID != first.ID {
+		t.Errorf("expected the first secret dropped, got %v", dropped)
+	}
+	if _, err := k.Check(old, now); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected a dropped secret's tokens to be refused, got %v", err)
+	}
+}
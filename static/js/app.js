@@ -1,12 +1,16 @@
 let gameState = null;
 let discardIndices = [];
 let chatSocket = null;
+let currentTable = localStorage.getItem('table'); // Lobby table we sit or watch at, if any
+let currentAccount = null; // Account we are logged in to, if any
 const HTTP_STATUS = {
     UNAUTHORIZED: 401,
     FORBIDDEN: 403
 };
 
-// ==================== WEBSOCKET CHAT ====================
+// ==================== WEBSOCKET ====================
+// The socket carries chat, game state pushed after every transition, and
+// our actions when it is open.
 
 function connectChat() {
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
@@ -16,12 +20,31 @@ function connectChat() {
 
     chatSocket.onopen = () => {
         console.log('[CHAT] Connected');
+        // Follow our table's game rather than our own
+        if (currentTable) sendOverSocket({ type: 'watch' });
     };
 
     chatSocket.onmessage = (event) => {
         try {
             const msg = JSON.parse(event.data);
-            displayChatMessage(msg);
+            switch (msg.type) {
+                case 'state_update':
+                    gameState = msg.state;
+                    renderState();
+                    break;
+                case 'events':
+                    // The state update that follows has everything we draw
+                    console.debug('[GAME] Hand', msg.hand, 'events', msg.events);
+                    break;
+                case 'error':
+                    document.getElementById('result').textContent = "Error: " + msg.error;
+                    break;
+                case 'lobby':
+                    renderLobby(msg.tables || []);
+                    break;
+                default:
+                    displayChatMessage(msg);
+            }
         } catch (e) {
             console.error('[CHAT] Parse error:', e);
         }
@@ -37,6 +60,16 @@ function connectChat() {
     };
 }
 
+// sendOverSocket sends a game message, for our table's game if we are at
+// one, if the socket is open, and reports whether it did; the answer comes
+// back as a state update.
+function sendOverSocket(msg) {
+    if (!chatSocket || chatSocket.readyState !== WebSocket.OPEN) return false;
+    if (currentTable) msg.table = currentTable;
+    chatSocket.send(JSON.stringify(msg));
+    return true;
+}
+
 function displayChatMessage(msg) {
     const container = document.getElementById('chat-messages');
     if (!container) return;
@@ -108,13 +141,26 @@ function renderSanity(prefix, name, sanity) {
 function updateSanityDisplay() {
     if (!gameState || !gameState.players) return;
 
-    const human = gameState.players[0];
-    const ai = gameState.players[1];
+    const human = gameState.players[mySeat()];
+    const ai = gameState.players[theirSeat()];
 
     renderSanity('player', human.name, human.sanity);
     renderSanity('opponent', ai.name, ai.sanity);
+    if (currentTable) {
+        document.getElementById('player-label').textContent = human.name;
+        document.getElementById('opponent-label').textContent = ai.name;
+    }
 
     document.getElementById('pot-amount').textContent = gameState.pot;
+    renderBoard();
+}
+
+// Community cards are public; the board only shows in Hold'em
+function renderBoard() {
+    const board = document.getElementById('board');
+    if (!board || !gameState) return;
+    board.classList.toggle('hidden', gameState.variant !== 'holdem');
+    renderHand('board', gameState.board || [], true);
 }
 
 function renderHand(containerId, hand, faceUp) {
@@ -131,9 +177,14 @@ function renderHand(containerId, hand, faceUp) {
         const rank = card.rank ? card.rank.toString() : '';
         const suit = card.suit ? card.suit.toString() : '';
 
-        img.src = faceUp ? `cards/${rank}_of_${suit}.png` : 'cards/back.png';
+        // Hidden cards arrive from the server as placeholders without rank/suit;
+        // stud up-cards are marked so they show even in a face-down hand
+        const shown = (faceUp || card.up) && rank !== '' && suit !== '';
+        // Jokers are drawn by colour: black_joker.png, red_joker.png
+        const face = rank === 'joker' ? `${suit}_joker` : `${rank}_of_${suit}`;
+        img.src = shown ? `cards/${face}.png` : 'cards/back.png';
         img.className = 'card';
-        img.alt = faceUp ? `${rank} of ${suit}` : 'Card back';
+        img.alt = shown ? (rank === 'joker' ? `${suit} joker` : `${rank} of ${suit}`) : 'Card back';
 
         // Always bind click for player hand, let handler decide
         if (faceUp && containerId === 'player-hand') {
@@ -157,12 +208,28 @@ function toggleDiscard(idx, img) {
         discardIndices.splice(i, 1);
         img.classList.remove('discard');
     } else {
-        if (discardIndices.length >= 3) return;
+        const legal = gameState.legal_actions || {};
+        if (discardIndices.length >= (legal.max_discard || 0)) return;
         discardIndices.push(idx);
         img.classList.add('discard');
     }
 }
 
+// mySeat is the seat we play, or the first when we only watch; theirSeat is
+// the one drawn across from us, next round the table.
+function mySeat() {
+    return gameState && gameState.viewer_seat >= 0 ? gameState.viewer_seat : 0;
+}
+function theirSeat() {
+    const seats = gameState && gameState.players ? gameState.players.length : 2;
+    return (mySeat() + 1) % seats;
+}
+
+// apiPath is where an action is posted: our table's game, or our own.
+function apiPath(action) {
+    return currentTable ? `/api/tables/${currentTable}/${action}` : `/api/${action}`;
+}
+
 // Helper to get player state which is now split
 function getPlayerIdentity(idx) {
     if (!gameState || !gameState.players) return null;
@@ -172,6 +239,17 @@ function getPlayerRoundState(idx) {
     if (!gameState || !gameState.round_states) return null;
     return gameState.round_states[idx];
 }
+// The server redacts hands we may not see (hand is null, hand_size is set),
+// so fall back to face-down placeholders of the right size. In stud the
+// up-cards are public and take their places among them.
+function getVisibleHand(idx) {
+    const rs = getPlayerRoundState(idx);
+    if (!rs) return [];
+    if (rs.hand) return rs.hand;
+    const showing = (rs.showing || []).slice();
+    return Array.from({ length: rs.hand_size || 0 }, (_, i) =>
+        rs.face_up && rs.face_up[i] ? { ...showing.shift(), up: true } : {});
+}
 
 function updateButtons() {
     const dealBtn = document.getElementById('deal-btn');
@@ -180,69 +258,97 @@ function updateButtons() {
     const discardBtn = document.getElementById('discard-btn');
     const showdownBtn = document.getElementById('showdown-btn');
     const betInput = document.getElementById('bet-amount');
+    const betSlider = document.getElementById('bet-slider');
 
     if (!gameState) {
         if (dealBtn) dealBtn.disabled = false;
         return;
     }
 
-    // React to phase names from Go (MarshalText returns strings: "ante", "bet_pre", "discard", "bet_post", "showdown", "complete")
+    // React to phase names from Go (MarshalText returns strings: "ante", "bet_pre", "discard", "bet_post", "showdown", "complete",
+    // in Hold'em "preflop", "flop", "turn", "river", and in stud "third_street" to "seventh_street")
     const phase = gameState.game_phase;
 
-    const isBetting = (phase === "bet_pre" || phase === "bet_post" || phase === "bet"); // "bet" legacy support
-    const isDiscard = (phase === "discard");
     const isComplete = (phase === "complete" || phase === "end" || phase === "ante" || phase === "deal");
 
-    const playerState = getPlayerRoundState(0);
-
-    // ESP is only available between rounds or when game over
-    const canESP = (phase === "complete" || phase === "ante" || phase === "game_over");
+    // The server says what we may do, so no guessing from the phase
+    const legal = gameState.legal_actions || {};
+    const range = legal.bet || legal.raise;
+    const canBet = legal.check || legal.call > 0 || !!range || legal.all_in > 0;
     const espBtn = document.getElementById('esp-btn');
 
     if (dealBtn) dealBtn.disabled = !isComplete && phase !== "ante" && phase !== "deal";
-    if (betBtn) betBtn.disabled = !isBetting;
-    if (foldBtn) foldBtn.disabled = (!isBetting && !isDiscard);
-    if (discardBtn) discardBtn.disabled = !isDiscard || (playerState && playerState.discarded);
+    if (betBtn) betBtn.disabled = !canBet;
+    if (foldBtn) foldBtn.disabled = !legal.fold;
+    if (discardBtn) discardBtn.disabled = !legal.discard;
     if (showdownBtn) showdownBtn.disabled = !(phase === "showdown");
-    if (espBtn) espBtn.disabled = !canESP;
+    if (espBtn) espBtn.disabled = !legal.esp;
 
     // Input Handling
-    if (isBetting && playerState) {
+    if (canBet) {
         betInput.disabled = false;
-        const player = getPlayerIdentity(0);
-        const toCall = gameState.current_bet - playerState.bet;
-
-        // Set min/max constraints
-        betInput.min = toCall > 0 ? toCall : 0;
-        betInput.max = player ? player.sanity : 100;
-
-        // Update button text? "Bet" / "Call" / "Check"
-        if (toCall === 0) {
-            betBtn.textContent = (gameState.current_bet === 0) ? "Check/Bet" : "Check";
-            if (Math.abs(gameState.current_bet) < 0.01) betInput.value = 10; // Default open
-            else betInput.value = 0; // Check
+        // Short stacks can only call for what they have (all in)
+        const toCall = legal.call || 0;
+
+        // Set min/max constraints: the call, up to the most the betting
+        // structure lets us raise on top of it
+        betInput.min = toCall;
+        betInput.max = toCall + (range ? range.max : 0);
+        betSlider.disabled = !range;
+        betSlider.min = betInput.min;
+        betSlider.max = betInput.max;
+
+        if (legal.check) {
+            betBtn.textContent = range ? "Check/Bet" : "Check";
+            betInput.value = legal.bet ? legal.bet.min : 0; // Default open, or check
         } else {
-            betBtn.textContent = "Call/Raise";
+            betBtn.textContent = range ? "Call/Raise" : "Call";
             betInput.value = toCall;
         }
+        betSlider.value = betInput.value;
     } else {
         betInput.disabled = true;
         betInput.min = 0;
         betInput.max = 100;
+        betSlider.disabled = true;
         betBtn.textContent = "Bet";
     }
 }
 
+// slideBet keeps the bet slider and the amount box showing the same bet,
+// copying from the box when fromInput is set.
+function slideBet(fromInput) {
+    const betInput = document.getElementById('bet-amount');
+    const betSlider = document.getElementById('bet-slider');
+    if (fromInput) betSlider.value = betInput.value;
+    else betInput.value = betSlider.value;
+}
+
 async function deal() {
     try {
-        const res = await safeFetch('/api/deal');
+        const params = new URLSearchParams();
+        const personality = document.getElementById('personality-select').value;
+        if (personality) params.set('personality', personality);
+        params.set('variant', document.getElementById('variant-select').value);
+        params.set('wilds', document.getElementById('wilds-select').value);
+        params.set('ranking', document.getElementById('ranking-select').value);
+        params.set('draws', document.getElementById('draws-select').value);
+        params.set('betting', document.getElementById('betting-select').value);
+        // A table deals by its own rules
+        const res = currentTable
+            ? await safeFetch(apiPath('deal'), { method: 'POST' })
+            : await safeFetch('/api/deal?' + params);
+        if (!res.ok) {
+            document.getElementById('result').textContent = await res.text();
+            return;
+        }
         gameState = await res.json();
 
         // Reset local state
         discardIndices = [];
 
-        renderHand('player-hand', getPlayerRoundState(0).hand, true);
-        renderHand('opponent-hand', getPlayerRoundState(1).hand, false); // Hidden
+        renderHand('player-hand', getPlayerRoundState(mySeat()).hand, true);
+        renderHand('opponent-hand', getVisibleHand(theirSeat()), false); // Hidden
         updateSanityDisplay();
         updateButtons();
 
@@ -256,42 +362,41 @@ async function placeBet() {
     const input = document.getElementById('bet-amount');
     const amount = parseInt(input.value) || 0;
 
-    // Infer action
-    // Need current state
+    // Infer the action from the amount and what the server says is legal
     if (!gameState) return;
-    const playerState = getPlayerRoundState(0);
-    const toCall = gameState.current_bet - playerState.bet;
+    const legal = gameState.legal_actions || {};
+    const toCall = legal.call || 0;
+    const range = legal.bet || legal.raise;
 
     let action = "check";
-    let finalAmount = amount;
-
-    if (toCall > 0) {
-        // We are facing a bet
-        if (amount === toCall) {
-            action = "call";
-            finalAmount = 0; // Call doesn't usually need amount in backend logic if strictly "match", but backend PlayerAction handles logic.
-            // My backend: "call" -> ignores amount. "raise" -> amount is RAISE BY.
-        } else if (amount > toCall) {
-            action = "raise";
-            finalAmount = amount - toCall; // Raise BY
-        } else {
-            // Under-bet?
-            alert("Amount must be at least " + toCall + " to call.");
+    let finalAmount = 0;
+
+    if (legal.all_in && amount > toCall && amount >= legal.all_in) {
+        // Everything we have: the server works out call vs. raise
+        action = "allin";
+    } else if (amount === 0 && legal.check) {
+        action = "check";
+    } else if (amount === toCall && legal.call) {
+        action = "call";
+    } else if (amount > toCall && range) {
+        action = legal.bet ? "bet" : "raise";
+        finalAmount = amount - toCall; // Raise BY
+        if (finalAmount < range.min || finalAmount > range.max) {
+            alert(`Bets and raises are from ${range.min} to ${range.max} on top of the call.`);
             return;
         }
+    } else if (toCall > 0) {
+        alert(range ? "Amount must be at least " + toCall + " to call." : "You can only call " + toCall + " or fold.");
+        return;
     } else {
-        // Opening pot
-        if (amount > 0) {
-            action = "bet";
-            finalAmount = amount;
-        } else {
-            action = "check";
-            finalAmount = 0;
-        }
+        alert("You can only check here.");
+        return;
     }
 
+    if (sendOverSocket({ type: 'action', action: action, amount: finalAmount })) return;
+
     try {
-        const res = await safeFetch('/api/bet', {
+        const res = await safeFetch(apiPath('bet'), {
             method: 'POST',
             body: JSON.stringify({ action: action, amount: finalAmount })
         });
@@ -308,9 +413,10 @@ async function placeBet() {
 
         gameState = data; // Handler returns state directly
 
-        if (gameState.game_phase === 'complete' || gameState.game_phase === 'showdown') {
-            renderHand('opponent-hand', getPlayerRoundState(1).hand, true);
-        }
+        // Stud deals new cards between betting rounds
+        renderHand('player-hand', getPlayerRoundState(mySeat()).hand, true);
+        const over = gameState.game_phase === 'complete' || gameState.game_phase === 'showdown';
+        renderHand('opponent-hand', getVisibleHand(theirSeat()), over);
 
         updateSanityDisplay();
         updateButtons();
@@ -322,8 +428,10 @@ async function placeBet() {
 }
 
 async function fold() {
+    if (sendOverSocket({ type: 'action', action: 'fold' })) return;
+
     try {
-        const res = await safeFetch('/api/bet', {
+        const res = await safeFetch(apiPath('bet'), {
             method: 'POST',
             body: JSON.stringify({ action: "fold", amount: 0 })
         });
@@ -332,7 +440,7 @@ async function fold() {
         // Always reveal if specific flag or just game over?
         // Backend sets reveal_on_fold
         if (gameState.reveal_on_fold || gameState.game_phase === 'complete') {
-            renderHand('opponent-hand', getPlayerRoundState(1).hand, true);
+            renderHand('opponent-hand', getVisibleHand(theirSeat()), true);
         }
 
         updateSanityDisplay();
@@ -344,17 +452,22 @@ async function fold() {
 }
 
 async function submitDiscard() {
+    if (sendOverSocket({ type: 'discard', indices: discardIndices })) {
+        discardIndices = [];  // Cleared before the new cards arrive
+        return;
+    }
+
     try {
-        const res = await safeFetch('/api/discard', {
+        const res = await safeFetch(apiPath('discard'), {
             method: 'POST',
             body: JSON.stringify({ indices: discardIndices })
         });
         gameState = await res.json();
         discardIndices = [];  // Clear BEFORE render so new cards aren't greyed out
-        renderHand('player-hand', getPlayerRoundState(0).hand, true);
+        renderHand('player-hand', getPlayerRoundState(mySeat()).hand, true);
 
         if (gameState.game_phase === 'complete' || gameState.game_phase === 'showdown') {
-            renderHand('opponent-hand', getPlayerRoundState(1).hand, true);
+            renderHand('opponent-hand', getVisibleHand(theirSeat()), true);
         }
 
         updateButtons();
@@ -369,16 +482,20 @@ async function showdown() {
         const data = await res.json();
         gameState = data.state;
 
-        renderHand('opponent-hand', getPlayerRoundState(1).hand, true);
+        renderHand('opponent-hand', getVisibleHand(1), true);
         updateSanityDisplay();
         updateButtons();
-        document.getElementById('result').textContent = data.result;
+        document.getElementById('result').textContent = data.result ? data.result.Message : gameState.last_action;
     } catch (e) { console.error(e); }
 }
 
 // Init
 document.addEventListener('DOMContentLoaded', () => {
+    document.getElementById('player-name').value = localStorage.getItem('playerName') || '';
     loadState();
+    loadPersonalities();
+    loadTables();
+    loadAccount();
     connectChat();
 });
 window.addEventListener('click', () => {
@@ -386,22 +503,75 @@ window.addEventListener('click', () => {
     if (audio) audio.play().catch(console.warn);
 }, { once: true });
 
+// Fill the opponent picker; the current opponent is selected once state loads
+async function loadPersonalities() {
+    try {
+        const res = await safeFetch('/api/personalities');
+        const list = await res.json();
+        const select = document.getElementById('personality-select');
+        select.innerHTML = '';
+        for (const p of list) {
+            const opt = document.createElement('option');
+            opt.value = p.id;
+            opt.textContent = p.name;
+            opt.title = p.description;
+            select.appendChild(opt);
+        }
+        syncPersonality();
+    } catch (e) {
+        console.error('Failed to load personalities:', e);
+    }
+}
+
+function syncPersonality() {
+    const select = document.getElementById('personality-select');
+    const opponent = gameState && gameState.players && gameState.players[1];
+    if (select && opponent && opponent.personality) {
+        select.value = opponent.personality;
+    }
+    if (gameState && gameState.variant) {
+        document.getElementById('variant-select').value = gameState.variant;
+    }
+    if (gameState && gameState.rules) {
+        const wilds = [];
+        if (gameState.rules.jokers) wilds.push('jokers');
+        if (gameState.rules.deuces_wild) wilds.push('deuces');
+        document.getElementById('wilds-select').value = wilds.join(',') || 'none';
+        document.getElementById('ranking-select').value = gameState.rules.ranking || 'high';
+        document.getElementById('draws-select').value = gameState.rules.draws || 1;
+        document.getElementById('betting-select').value = gameState.rules.betting || 'no_limit';
+    }
+}
+
+async function choosePersonality() {
+    // Before the first deal the choice is sent along with it
+    if (!gameState) return;
+    const personality = document.getElementById('personality-select').value;
+    try {
+        const res = await safeFetch('/api/personality', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ personality })
+        });
+        if (!res.ok) {
+            // Mid-hand the choice waits for the next deal, which sends it along
+            document.getElementById('result').textContent = `${(await res.text()).trim()}: the next deal will bring it.`;
+            return;
+        }
+        gameState = await res.json();
+    } catch (e) {
+        console.error(e);
+    }
+}
+
 async function loadState() {
+    if (currentTable && await loadTable()) return;
     try {
         const res = await safeFetch('/api/state');
         const data = await res.json();
         if (data) {
             gameState = data;
-            updateSanityDisplay();
-            updateButtons();
-            if (getPlayerRoundState(0) && getPlayerRoundState(0).hand) {
-                renderHand('player-hand', getPlayerRoundState(0).hand, true);
-            }
-            if (getPlayerRoundState(1) && getPlayerRoundState(1).hand) {
-                renderHand('opponent-hand', getPlayerRoundState(1).hand, false);
-            }
-            document.getElementById('result').textContent = gameState.last_action || '';
-            checkGameOver();
+            renderState();
         }
     } catch (e) {
         console.error('Failed to load state:', e);
@@ -409,10 +579,28 @@ async function loadState() {
     updateButtons();
 }
 
+// renderState draws the whole table from gameState, as loaded or pushed.
+function renderState() {
+    if (!gameState) return;
+    updateSanityDisplay();
+    updateButtons();
+    if (getPlayerRoundState(mySeat()) && getPlayerRoundState(mySeat()).hand) {
+        renderHand('player-hand', getPlayerRoundState(mySeat()).hand, true);
+    }
+    if (getPlayerRoundState(theirSeat())) {
+        renderHand('opponent-hand', getVisibleHand(theirSeat()), true);
+    }
+    // A delayed broadcast shows spectators every hand, some actions late
+    document.getElementById('result').textContent = (gameState.last_action || '') +
+        (gameState.delay ? ` (📺 ${gameState.delay} actions behind)` : '');
+    syncPersonality();
+    checkGameOver();
+}
 
 function checkGameOver() {
     const overlay = document.getElementById('game-over-overlay');
-    if (gameState && gameState.game_phase === 'game_over') {
+    // Rebuying starts our own game over; a table deals again once it has players
+    if (!currentTable && gameState && gameState.game_phase === 'game_over') {
         overlay.classList.remove('hidden');
     } else {
         overlay.classList.add('hidden');
@@ -465,6 +653,379 @@ showdown = async function () {
     checkGameOver();
 };
 
+// ==================== LOBBY ====================
+// Tables are shared with other players. At one, its game takes the place of
+// our own until we leave.
+
+async function loadTables() {
+    try {
+        const res = await safeFetch('/api/tables');
+        renderLobby(await res.json());
+    } catch (e) {
+        console.error('Failed to load tables:', e);
+    }
+}
+
+function renderLobby(tables) {
+    const list = document.getElementById('table-list');
+    list.innerHTML = '';
+    if (tables.length === 0) {
+        list.textContent = 'No tables yet.';
+    }
+    for (const t of tables) {
+        const seated = t.seats.filter(s => s.player_id).map(s => s.name);
+        const row = document.createElement('div');
+        row.className = 'table-row' + (t.id === currentTable ? ' current' : '');
+
+        const label = document.createElement('span');
+        label.textContent = `${t.name} · ${t.variant || 'draw'} · ${seated.length}/${t.seats.length}` +
+            (t.ai_seats ? ` +${t.ai_seats} AI` : '') + (t.spectators.length ? ` · ${t.spectators.length} 👁` : '') + (t.delay ? ` · 📺 ${t.delay}` : '');
+        label.title = seated.join(', ');
+        row.appendChild(label);
+
+        if (t.id !== currentTable) {
+            if (seated.length < t.seats.length) {
+                const join = document.createElement('button');
+                join.textContent = 'Join';
+                join.onclick = () => tableRequest(t.id, 'join');
+                row.appendChild(join);
+            }
+            const watch = document.createElement('button');
+            watch.textContent = 'Watch';
+            watch.onclick = () => tableRequest(t.id, 'spectate');
+            row.appendChild(watch);
+        }
+        list.appendChild(row);
+    }
+}
+
+function playerName() {
+    const name = document.getElementById('player-name').value.trim();
+    localStorage.setItem('playerName', name);
+    return name;
+}
+
+// createTable opens a table with the rules picked for our own game, and
+// sits us at it.
+async function createTable() {
+    const wilds = document.getElementById('wilds-select').value;
+    const body = {
+        name: document.getElementById('table-name').value,
+        variant: document.getElementById('variant-select').value,
+        rules: {
+            jokers: wilds.includes('jokers'),
+            deuces_wild: wilds.includes('deuces'),
+            ranking: document.getElementById('ranking-select').value,
+            draws: parseInt(document.getElementById('draws-select').value),
+            betting: document.getElementById('betting-select').value
+        },
+        seats: parseInt(document.getElementById('seats-select').value),
+        ai_seats: parseInt(document.getElementById('ai-seats-select').value),
+        delay: parseInt(document.getElementById('delay-select').value),
+        player: playerName()
+    };
+    if (currentTable) await leaveTable();
+    await postTable('/api/tables', body);
+}
+
+// tableRequest joins, watches or leaves the table.
+async function tableRequest(id, action) {
+    // One table at a time: leave the last before sitting at the next
+    if (currentTable && currentTable !== id) await leaveTable();
+    await postTable(`/api/tables/${id}/${action}`, { name: playerName() });
+}
+
+async function leaveTable() {
+    if (!currentTable) return;
+    await tableRequest(currentTable, 'leave');
+}
+
+async function postTable(url, body) {
+    try {
+        const res = await safeFetch(url, { method: 'POST', body: JSON.stringify(body) });
+        if (!res.ok) {
+            document.getElementById('result').textContent = await res.text();
+            return;
+        }
+        enterTable(await res.json());
+    } catch (e) {
+        console.error(e);
+    }
+}
+
+// loadTable shows the table we were at, and reports whether we still are.
+async function loadTable() {
+    try {
+        const res = await safeFetch(`/api/tables/${currentTable}`);
+        if (res.ok) {
+            const data = await res.json();
+            const at = data.table.seats.concat(data.table.spectators).some(s => s.player_id === data.player_id);
+            if (at) {
+                enterTable(data);
+                return true;
+            }
+        }
+    } catch (e) {
+        console.error(e);
+    }
+    setTable(null);
+    return false;
+}
+
+// enterTable switches to the table we have joined or are watching, or back
+// to our own game if we have left it.
+function enterTable(data) {
+    const at = data.table.seats.concat(data.table.spectators).some(s => s.player_id === data.player_id);
+    if (!at) {
+        setTable(null);
+        loadState();
+        return;
+    }
+    setTable(data.table.id, data.table.name);
+    gameState = data.state;
+    discardIndices = [];
+    if (gameState) {
+        renderState();
+    } else {
+        renderHand('player-hand', [], true);
+        renderHand('opponent-hand', [], false);
+        document.getElementById('result').textContent = 'Waiting for the first deal.';
+        updateButtons();
+    }
+}
+
+function setTable(id, name) {
+    currentTable = id;
+    if (id) localStorage.setItem('table', id);
+    else localStorage.removeItem('table');
+    document.body.classList.toggle('at-table', !!id);
+    document.getElementById('table-label').textContent = id ? `· ${name}` : '';
+    if (!id) {
+        document.getElementById('player-label').textContent = 'You';
+        document.getElementById('opponent-label').textContent = 'Ancient One';
+    }
+    loadTables();
+}
+
+// ==================== ACCOUNTS ====================
+// Logged in, we play as our account from any browser; logged out we are an
+// anonymous session, whose game is lost with its cookie.
+
+async function loadAccount() {
+    try {
+        const res = await safeFetch('/api/account');
+        setAccount(res.ok ? await res.json() : null);
+    } catch (e) {
+        console.error('Failed to load account:', e);
+    }
+}
+
+function setAccount(account) {
+    currentAccount = account;
+    document.body.classList.toggle('logged-in', !!account);
+    document.getElementById('account-label').textContent = account ? `👤 ${account.username}` : '';
+    document.getElementById('password').value = '';
+}
+
+// ==================== PROFILE ====================
+// What others see of our account, and how we have fared over every game.
+
+// avatarElement draws a monster of the icon pack, or a stand-in if there is
+// none or its icon is not installed.
+function avatarElement(avatar) {
+    const fallback = document.createElement('span');
+    fallback.textContent = '👾';
+    if (!avatar) return fallback;
+    const img = document.createElement('img');
+    img.className = 'avatar';
+    img.src = `/avatars/${encodeURIComponent(avatar)}.png`;
+    img.alt = avatar;
+    img.onerror = () => img.replaceWith(fallback);
+    return img;
+}
+
+async function showProfile() {
+    if (!currentAccount) return;
+    try {
+        const res = await safeFetch(`/api/profile/${currentAccount.id}`);
+        if (!res.ok) {
+            document.getElementById('result').textContent = await res.text();
+            return;
+        }
+        renderProfile(await res.json());
+        const avatars = await (await safeFetch('/api/avatars')).json();
+        const select = document.getElementById('avatar-select');
+        const picked = select.dataset.avatar || '';
+        select.innerHTML = '<option value="">👾 No Avatar</option>';
+        for (const a of avatars) {
+            const option = document.createElement('option');
+            option.value = a;
+            option.textContent = a;
+            select.appendChild(option);
+        }
+        select.value = picked;
+    } catch (e) {
+        console.error('Failed to load profile:', e);
+        return;
+    }
+    document.getElementById('profile-overlay').classList.remove('hidden');
+}
+
+function renderProfile(p) {
+    document.getElementById('profile-avatar').replaceChildren(avatarElement(p.avatar));
+    document.getElementById('profile-name').textContent = p.name;
+    document.getElementById('profile-name-input').value = p.name;
+    document.getElementById('avatar-select').dataset.avatar = p.avatar || '';
+    document.getElementById('avatar-select').value = p.avatar || '';
+    document.getElementById('profile-since').textContent = `Haunting the tables since ${new Date(p.created).toLocaleDateString()}`;
+
+    const s = p.stats || {};
+    const rows = [
+        ['Hands played', s.hands || 0],
+        ['Hands won', s.won || 0],
+        ['Biggest pot', `${s.biggest_pot || 0} 🧠`],
+        ['Best hand', s.best_hand || '—'],
+        ['ESP solved', s.esp_rounds ? `${s.esp_solved} of ${s.esp_rounds} (${Math.round(s.esp_rate * 100)}%)` : '—'],
+        ['Driven mad', s.mad || 0]
+    ];
+    const table = document.getElementById('profile-stats');
+    table.innerHTML = '';
+    for (const [label, value] of rows) {
+        const row = table.insertRow();
+        row.insertCell().textContent = label;
+        row.insertCell().textContent = value;
+    }
+}
+
+async function saveProfile() {
+    const body = {
+        name: document.getElementById('profile-name-input').value,
+        avatar: document.getElementById('avatar-select').value
+    };
+    try {
+        const res = await safeFetch('/api/profile', { method: 'PUT', body: JSON.stringify(body) });
+        if (!res.ok) {
+            document.getElementById('profile-since').textContent = await res.text();
+            return;
+        }
+        await showProfile();
+    } catch (e) {
+        console.error(e);
+    }
+}
+
+function hideProfile() {
+    document.getElementById('profile-overlay').classList.add('hidden');
+}
+
+// ==================== LEADERBOARDS ====================
+// Every player with a profile, ranked over a day, a week or all time.
+
+async function showLeaderboard() {
+    const board = document.getElementById('leaderboard-board').value;
+    const span = document.getElementById('leaderboard-window').value;
+    try {
+        const res = await safeFetch(`/api/leaderboards/${board}?window=${span}`);
+        if (!res.ok) {
+            document.getElementById('leaderboard-note').textContent = await res.text();
+            return;
+        }
+        renderLeaderboard(await res.json());
+    } catch (e) {
+        console.error('Failed to load leaderboard:', e);
+        return;
+    }
+    document.getElementById('leaderboard-overlay').classList.remove('hidden');
+}
+
+function renderLeaderboard(lb) {
+    const score = {
+        net: e => `${e.score > 0 ? '+' : ''}${e.score} 🧠 over ${e.hands} hands`,
+        win_rate: e => `${Math.round(e.score * 100)}% of ${e.hands} hands`,
+        streak: e => `${e.score} hands`,
+        esp: e => `${(e.score / 1000).toFixed(1)}s in ${e.guesses} ${e.guesses === 1 ? 'guess' : 'guesses'}`
+    }[lb.board];
+
+    let note = lb.entries.length ? '' : 'Nobody has made the board yet.';
+    if (lb.min_hands) note = `Ranked over at least ${lb.min_hands} hands. ${note}`;
+    document.getElementById('leaderboard-note').textContent = note;
+
+    const table = document.getElementById('leaderboard-entries');
+    table.innerHTML = '';
+    for (const e of lb.entries) {
+        const row = table.insertRow();
+        row.insertCell().textContent = `#${e.rank}`;
+        const who = row.insertCell();
+        who.append(avatarElement(e.avatar), ` ${e.name}`);
+        if (currentAccount && e.profile_id === currentAccount.id) row.classList.add('current');
+        row.insertCell().textContent = score(e);
+    }
+}
+
+function hideLeaderboard() {
+    document.getElementById('leaderboard-overlay').classList.add('hidden');
+}
+
+async function register() {
+    await accountRequest('register', { attach: document.getElementById('attach-game').checked });
+}
+
+async function login() {
+    await accountRequest('login', {});
+}
+
+async function logout() {
+    await accountRequest('logout', null);
+}
+
+// revokeSessions logs our account out everywhere but here.
+async function revokeSessions() {
+    try {
+        const res = await safeFetch('/api/account/revoke', { method: 'POST' });
+        document.getElementById('result').textContent = res.ok ? 'Logged out everywhere else.' : await res.text();
+    } catch (e) {
+        console.error(e);
+    }
+}
+
+// accountRequest signs up, logs in or out; either way we are someone else
+// now, so our game, table and socket are reloaded.
+async function accountRequest(action, body) {
+    if (body) {
+        body.username = document.getElementById('username').value;
+        body.password = document.getElementById('password').value;
+    }
+    try {
+        const res = await safeFetch(`/api/account/${action}`, {
+            method: 'POST',
+            body: body ? JSON.stringify(body) : undefined
+        });
+        if (!res.ok) {
+            document.getElementById('result').textContent = await res.text();
+            return;
+        }
+        setAccount(body ? await res.json() : null);
+    } catch (e) {
+        console.error(e);
+        return;
+    }
+    gameState = null;
+    renderHand('player-hand', [], true);
+    renderHand('opponent-hand', [], false);
+    document.getElementById('result').textContent = '';
+    await loadState();
+    reconnectChat();
+}
+
+// reconnectChat opens the socket again as whoever we are now.
+function reconnectChat() {
+    if (chatSocket) {
+        chatSocket.onclose = null;
+        chatSocket.close();
+    }
+    connectChat();
+}
+
 // ==================== ESP MINIGAME ====================
 
 let espSelection1 = -1;  // Selected index from hand1